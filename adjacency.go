@@ -0,0 +1,195 @@
+package interactions
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseAdjacency reads an adjacency matrix as a scenario, so a table of who
+// affects whom from a spreadsheet becomes a diagram. Rows are separated by
+// newlines or semicolons and cells by commas, as in a CSV file or inline:
+//
+//	0,1,0;0,0,1;1,0,0
+//
+// The cell in row i and column j is the effect of node i on node j: 0 for
+// none, 1 for an influence, -1 for an inhibition, and other numbers for an
+// edge of that weight. A cell on the diagonal is a self-loop. Where two
+// nodes affect each other alike, the two cells make one two-way edge: a
+// mutualism, or a competition when both are negative. Where one suppresses
+// the other and is fed by it as much, they make a predation edge.
+//
+// The nodes are named A, B, C and so on, unless the first row or column
+// labels them, with an empty or any corner cell when both do:
+//
+//	,Fox,Rabbit
+//	Fox,0,-1
+//	Rabbit,1,0
+//
+// Row and column labels must then name the same nodes in the same order.
+// The scenario has no title.
+func ParseAdjacency(src string) (Scenario, error) {
+	var rows [][]string
+	for _, line := range strings.FieldsFunc(src, func(r rune) bool { return r == '\n' || r == ';' }) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cells := strings.Split(line, ",")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return Scenario{}, fmt.Errorf("matrix: no rows")
+	}
+
+	// a header row or label column has a cell past the corner that is not
+	// a number
+	var columnLabels, rowLabels []string
+	if slices.ContainsFunc(rows[0][1:], notNumber) {
+		columnLabels = rows[0]
+		rows = rows[1:]
+	}
+	if len(rows) > 0 && slices.ContainsFunc(rows, func(r []string) bool { return notNumber(r[0]) }) {
+		rowLabels = make([]string, len(rows))
+		for i, r := range rows {
+			rowLabels[i] = r[0]
+			rows[i] = r[1:]
+		}
+		if columnLabels != nil {
+			columnLabels = columnLabels[1:]
+		}
+	}
+
+	n := len(rows)
+	if n == 0 {
+		return Scenario{}, fmt.Errorf("matrix: no rows")
+	}
+	for i, r := range rows {
+		if len(r) != n {
+			return Scenario{}, fmt.Errorf("matrix: row %d has %d cells, but there are %d rows", i+1, len(r), n)
+		}
+	}
+	names := rowLabels
+	if names == nil {
+		names = columnLabels
+	}
+	switch {
+	case names == nil:
+		names = make([]string, n)
+		for i := range names {
+			names[i] = string(rune('A' + i%26))
+			if i >= 26 {
+				names[i] += strconv.Itoa(i / 26)
+			}
+		}
+	case len(names) != n:
+		return Scenario{}, fmt.Errorf("matrix: %d labels for %d rows", len(names), n)
+	case rowLabels != nil && columnLabels != nil && !slices.Equal(rowLabels, columnLabels):
+		return Scenario{}, fmt.Errorf("matrix: row labels %s do not match column labels %s", strings.Join(rowLabels, ", "), strings.Join(columnLabels, ", "))
+	}
+	for i, name := range names {
+		if name == "" {
+			return Scenario{}, fmt.Errorf("matrix: label %d is empty", i+1)
+		}
+		if slices.Contains(names[:i], name) {
+			return Scenario{}, fmt.Errorf("matrix: label %q is used twice", name)
+		}
+	}
+
+	values := make([][]float64, n)
+	for i, r := range rows {
+		values[i] = make([]float64, n)
+		for j, cell := range r {
+			v, err := cellValue(cell)
+			if err != nil {
+				return Scenario{}, fmt.Errorf("matrix: row %d, column %d: %q is not a number", i+1, j+1, cell)
+			}
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return Scenario{}, fmt.Errorf("matrix: row %d, column %d: %q is not a finite number", i+1, j+1, cell)
+			}
+			values[i][j] = v
+		}
+	}
+
+	s := Scenario{Nodes: names}
+	for i := range n {
+		for j := range n {
+			v := values[i][j]
+			switch {
+			case v == 0:
+				continue
+			case i == j:
+				s.Edges = append(s.Edges, adjacencyEdge(names[i], names[j], v))
+			case values[j][i] == v && j < i:
+				// drawn with the two-way edge from node j
+			case values[j][i] == v:
+				e := adjacencyEdge(names[i], names[j], v)
+				e.Bidirectional = true
+				s.Edges = append(s.Edges, e)
+			case values[j][i] == -v && v > 0:
+				// drawn with the predation edge from node j
+			case values[j][i] == -v:
+				e := adjacencyEdge(names[i], names[j], v)
+				e.Kind = Predation
+				s.Edges = append(s.Edges, e)
+			default:
+				s.Edges = append(s.Edges, adjacencyEdge(names[i], names[j], v))
+			}
+		}
+	}
+	return s, nil
+}
+
+// LoadAdjacencyFile reads the adjacency matrix in a CSV file as a
+// scenario titled after the file; see ParseAdjacency.
+func LoadAdjacencyFile(path string) (Scenario, error) {
+	return LoadAdjacencyFileFS(nil, path)
+}
+
+// LoadAdjacencyFileFS is LoadAdjacencyFile reading the file name of fsys.
+func LoadAdjacencyFileFS(fsys fs.FS, name string) (Scenario, error) {
+	data, err := readFile(fsys, name)
+	if err != nil {
+		return Scenario{}, err
+	}
+	s, err := ParseAdjacency(string(data))
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %w", name, err)
+	}
+	s.Title = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return s, nil
+}
+
+// adjacencyEdge is the edge of a cell of value v of an adjacency matrix:
+// an inhibition when negative, weighted unless its size is 1.
+func adjacencyEdge(from, to string, v float64) Edge {
+	e := Edge{From: from, To: to}
+	if v < 0 {
+		e.Kind = Inhibition
+		v = -v
+	}
+	if v != 1 {
+		e.Weight = v
+	}
+	return e
+}
+
+// cellValue is the number in a matrix cell, 0 when it is empty.
+func cellValue(cell string) (float64, error) {
+	if cell == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(cell, 64)
+}
+
+// notNumber reports whether a matrix cell is a label rather than a value.
+func notNumber(cell string) bool {
+	_, err := cellValue(cell)
+	return err != nil
+}
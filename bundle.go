@@ -0,0 +1,94 @@
+package interactions
+
+import (
+	"image"
+	"math"
+)
+
+// WithBundledEdges draws the edges from a node to two or more others as a
+// single trunk that forks near them, rather than as separate lines all the
+// way, which cuts the clutter of the busiest panels where C and D each
+// point at both A and B. Only edges drawn alike are bundled together, and
+// edges the layout routes already, as Sugiyama does, are left alone.
+func WithBundledEdges() Option {
+	return func(o *options) { o.bundle = true }
+}
+
+// bundleKey groups the edges drawn alike from one node.
+type bundleKey struct {
+	from  string
+	kind  EdgeKind
+	style EdgeStyle
+	width int
+	faded bool
+}
+
+// bundleEdges routes the edges of s into bundles by giving each a bend at
+// the fork of its bundle. Edges with heads at both ends and self-loops are
+// not bundled, nor are bundles whose targets lie too far apart around
+// their source for a trunk to lead anywhere.
+func (o options) bundleEdges(s Scenario, layout *panelLayout) {
+	bundles := map[bundleKey][]int{}
+	var keys []bundleKey
+	for k, e := range s.Edges {
+		if _, _, tail := e.heads(); tail || e.From == e.To || len(layout.bends[k]) > 0 {
+			continue
+		}
+		key := bundleKey{from: e.From, kind: e.Kind, style: e.style(), width: e.lineWidth(), faded: o.fadedEdge(e)}
+		if _, ok := bundles[key]; !ok {
+			keys = append(keys, key)
+		}
+		bundles[key] = append(bundles[key], k)
+	}
+	for _, key := range keys {
+		edges := bundles[key]
+		if len(edges) < 2 {
+			continue
+		}
+		targets := make([]image.Point, len(edges))
+		for i, k := range edges {
+			targets[i] = layout.positions[s.Edges[k].To]
+		}
+		fork, ok := bundleFork(layout.positions[key.from], targets, layout.radius)
+		if !ok {
+			continue
+		}
+		if layout.bends == nil {
+			layout.bends = map[int][]image.Point{}
+		}
+		for _, k := range edges {
+			layout.bends[k] = []image.Point{fork}
+		}
+	}
+}
+
+// bundleFork is where the trunk from the node at from forks towards the
+// nodes at targets: most of the way to the middle of them, but far enough
+// from each, nodes of radius r, that the branches fan out before they
+// reach it.
+func bundleFork(from image.Point, targets []image.Point, r int) (image.Point, bool) {
+	var cx, cy float64
+	for _, t := range targets {
+		cx += float64(t.X)
+		cy += float64(t.Y)
+	}
+	cx /= float64(len(targets))
+	cy /= float64(len(targets))
+	dx, dy := cx-float64(from.X), cy-float64(from.Y)
+	if math.Hypot(dx, dy) < float64(4*r) {
+		return image.Point{}, false
+	}
+	for f := 0.6; f >= 0.3; f -= 0.1 {
+		fork := image.Pt(from.X+int(math.Round(dx*f)), from.Y+int(math.Round(dy*f)))
+		clear := true
+		for _, t := range targets {
+			if math.Hypot(float64(t.X-fork.X), float64(t.Y-fork.Y)) < float64(3*r) {
+				clear = false
+			}
+		}
+		if clear {
+			return fork, true
+		}
+	}
+	return image.Point{}, false
+}
@@ -0,0 +1,143 @@
+package interactions
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+)
+
+// PanelCache keeps panels already drawn, as PNGs, under a key that is a
+// hash of everything deciding how a panel looks: its scenario, the theme,
+// the panel's size and the options it is drawn with. A grid drawn again
+// after one scenario of it changes then draws only that panel afresh.
+// Caching is best effort, so a Put that fails only means the panel is
+// drawn again next time.
+type PanelCache interface {
+	// Get returns the PNG kept under key, or ok false if there is none.
+	Get(key string) (png []byte, ok bool)
+	// Put keeps png under key.
+	Put(key string, png []byte)
+}
+
+// WithPanelCache takes the panels of a raster image from c when it has
+// them, and keeps those it does not have in it once drawn. A nil c draws
+// every panel afresh, as without WithPanelCache. A custom Layout is part
+// of the key as its value, so one whose placement depends on anything
+// else must not be cached.
+func WithPanelCache(c PanelCache) Option {
+	return func(o *options) { o.cache = c }
+}
+
+// DirCache is a PanelCache keeping each panel as a PNG file, named by its
+// key, in the directory it names, which is created when first needed.
+// Several programs can share it. Nothing is ever removed from it, so
+// empty it now and then.
+type DirCache string
+
+func (d DirCache) Get(key string) ([]byte, bool) {
+	b, err := os.ReadFile(filepath.Join(string(d), key+".png"))
+	return b, err == nil
+}
+
+func (d DirCache) Put(key string, png []byte) {
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return
+	}
+	// written whole under another name first, so a program reading it
+	// meanwhile never sees half a panel
+	f, err := os.CreateTemp(string(d), key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(png)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(string(d), key+".png"))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// panelCacheVersion is part of every panel's key, to be raised whenever
+// panels are drawn differently by the same options, so that caches stop
+// giving the old drawings.
+const panelCacheVersion = 1
+
+// buildVersion is the version of this module in the running program, and
+// of its source when built from a checkout, so that an upgrade does not
+// draw panels from a cache filled by an older version.
+var buildVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/arran4/interactions" {
+			version = dep.Version
+		}
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" || s.Key == "vcs.time" {
+			version += " " + s.Value
+		}
+	}
+	return version
+})
+
+// panelKey is the PanelCache key of s drawn as the i'th panel with options
+// o, in a panel the size of rect of geometry geo. Where the panel is does
+// not change how it is drawn, so is not part of it.
+func (o options) panelKey(s Scenario, th Theme, geo geometry, rect image.Rectangle, i int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d %s\n%#v\n%#v\n%#v %dx%d\n%#v\n", panelCacheVersion, buildVersion(), s, th, geo, rect.Dx(), rect.Dy(), o.caption(i))
+	fmt.Fprintf(h, "%#v %q %d %#v %d %d %t %t\n", o.edgeColors, o.highlight, o.overflow, o.layout, o.stroke, o.mutualism, o.bundle, o.debugLayout)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedPanel draws the panel under key from o's PanelCache into rect of
+// img, reporting whether it had it.
+func (o options) cachedPanel(img *image.RGBA, rect image.Rectangle, key string) bool {
+	b, ok := o.cache.Get(key)
+	if !ok {
+		return false
+	}
+	panel, err := png.Decode(bytes.NewReader(b))
+	if err != nil || panel.Bounds().Size() != rect.Size() {
+		return false
+	}
+	draw.Draw(img, rect, panel, panel.Bounds().Min, draw.Src)
+	return true
+}
+
+// cachePanel keeps s, drawn as the i'th panel at rect of img, in o's
+// PanelCache under key, unless only part of it is there, as in a band of a
+// tiled grid, or it might not all be: text too wide for its place can run
+// past the panel's edge, where a cached panel would cut it off.
+func (o options) cachePanel(img *image.RGBA, rect image.Rectangle, s Scenario, geo geometry, i int, key string) {
+	if !rect.In(img.Bounds()) {
+		return
+	}
+	warned := false
+	o.warn = func(Warning) { warned = true }
+	if o.checkText(s, geo, i); warned {
+		return
+	}
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, img.SubImage(rect)); err != nil {
+		return
+	}
+	o.cache.Put(key, buf.Bytes())
+}
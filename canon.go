@@ -0,0 +1,159 @@
+package interactions
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CanonicalKey returns a key that two scenarios share exactly when they
+// draw the same structure once the nodes named in interchangeable are
+// relabelled among themselves: the same nodes, edges and spans, whatever
+// their titles, codes and the order they are listed in. With C and D
+// interchangeable, "C influences A" with D absent has the same key as "D
+// influences A" with C absent.
+func CanonicalKey(s Scenario, interchangeable ...string) string {
+	var present []string
+	for _, name := range interchangeable {
+		if slices.Contains(s.Nodes, name) {
+			present = append(present, name)
+		}
+	}
+
+	// the present names take the first interchangeable names in each
+	// possible order, and the smallest key wins
+	best := ""
+	permute(present, func(order []string) {
+		rename := map[string]string{}
+		for i, name := range order {
+			rename[name] = interchangeable[i]
+		}
+		if key := structureKey(s, rename); best == "" || key < best {
+			best = key
+		}
+	})
+	return best
+}
+
+// Isomorphic reports whether a and b draw the same structure once the nodes
+// named in interchangeable are relabelled among themselves; see
+// CanonicalKey.
+func Isomorphic(a, b Scenario, interchangeable ...string) bool {
+	return CanonicalKey(a, interchangeable...) == CanonicalKey(b, interchangeable...)
+}
+
+// Classes partitions scenarios into classes of those that are isomorphic,
+// with the nodes named in interchangeable relabelled among themselves; see
+// CanonicalKey. It returns the indexes of each class's members in order,
+// and the classes in the order of their first members.
+func Classes(scenarios []Scenario, interchangeable ...string) [][]int {
+	var classes [][]int
+	class := map[string]int{}
+	for i, s := range scenarios {
+		key := CanonicalKey(s, interchangeable...)
+		c, ok := class[key]
+		if !ok {
+			c = len(classes)
+			class[key] = c
+			classes = append(classes, nil)
+		}
+		classes[c] = append(classes[c], i)
+	}
+	return classes
+}
+
+// Mirror returns s with the roles of the nodes named a and b swapped: every
+// edge, span and node of one becomes the other's, so "A influences B"
+// becomes "B influences A". Titles and codes are left as they are.
+func Mirror(s Scenario, a, b string) Scenario {
+	swap := func(n string) string {
+		switch n {
+		case a:
+			return b
+		case b:
+			return a
+		}
+		return n
+	}
+	m := s
+	m.Nodes = make([]string, len(s.Nodes))
+	for i, n := range s.Nodes {
+		m.Nodes[i] = swap(n)
+	}
+	m.Edges = make([]Edge, len(s.Edges))
+	for i, e := range s.Edges {
+		e.From, e.To = swap(e.From), swap(e.To)
+		m.Edges[i] = e
+	}
+	if s.Spans != nil {
+		m.Spans = make(map[string]Span, len(s.Spans))
+		for n, sp := range s.Spans {
+			m.Spans[swap(n)] = sp
+		}
+	}
+	if s.Sizes != nil {
+		m.Sizes = make(map[string]Size, len(s.Sizes))
+		for n, sz := range s.Sizes {
+			m.Sizes[swap(n)] = sz
+		}
+	}
+	return m
+}
+
+// structureKey writes out the nodes, edges and spans of s, with the nodes
+// in rename renamed, in a fixed order.
+func structureKey(s Scenario, rename map[string]string) string {
+	name := func(n string) string {
+		if r, ok := rename[n]; ok {
+			return r
+		}
+		return n
+	}
+
+	nodes := make([]string, len(s.Nodes))
+	for i, n := range s.Nodes {
+		nodes[i] = strconv.Quote(name(n))
+	}
+	slices.Sort(nodes)
+
+	edges := make([]string, len(s.Edges))
+	for i, e := range s.Edges {
+		from, to, polarity := name(e.From), name(e.To), e.Polarity
+		// a two-way edge is the same drawn from either end
+		if e.Bidirectional && e.Kind != Predation && to < from {
+			from, to = to, from
+			if r := []rune(polarity); len(r) == 2 {
+				polarity = string([]rune{r[1], r[0]})
+			}
+		}
+		edges[i] = fmt.Sprintf("%q>%q %d %d %t %g %q %g %t", from, to, e.Kind, e.Style, e.Bidirectional, e.Weight, polarity, e.Probability, e.Conditional)
+	}
+	slices.Sort(edges)
+
+	spans := make([]string, 0, len(s.Spans))
+	for n, sp := range s.Spans {
+		spans = append(spans, fmt.Sprintf("%q %g %g", name(n), sp.Start, sp.End))
+	}
+	slices.Sort(spans)
+
+	return strings.Join(nodes, ",") + "|" + strings.Join(edges, ",") + "|" + strings.Join(spans, ",")
+}
+
+// permute calls f with every ordering of names, reusing one slice.
+func permute(names []string, f func([]string)) {
+	order := slices.Clone(names)
+	var rec func(k int)
+	rec = func(k int) {
+		if k == len(order) {
+			f(order)
+			return
+		}
+		for i := k; i < len(order); i++ {
+			order[k], order[i] = order[i], order[k]
+			rec(k + 1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	rec(0)
+}
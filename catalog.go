@@ -0,0 +1,62 @@
+package interactions
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Catalog holds named collections of scenarios, such as the generated
+// taxonomy and the sets of scenario files, for choosing one by name.
+type Catalog struct {
+	names []string
+	sets  map[string][]Scenario
+}
+
+// NewCatalog returns an empty catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{sets: map[string][]Scenario{}}
+}
+
+// Add adds the collection name, replacing any collection of that name.
+func (c *Catalog) Add(name string, scenarios []Scenario) {
+	if _, ok := c.sets[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.sets[name] = scenarios
+}
+
+// AddFile adds the named sets of a scenario file, in order of name,
+// filling in the variables of a template from vars unless it is nil; see
+// LoadScenarioTemplate.
+func (c *Catalog) AddFile(path string, vars map[string]string) error {
+	return c.AddFS(nil, path, vars)
+}
+
+// AddFS is AddFile reading the file name of fsys.
+func (c *Catalog) AddFS(fsys fs.FS, name string, vars map[string]string) error {
+	doc, _, err := readScenarioFile(fsys, name, vars)
+	if err != nil {
+		return err
+	}
+	for _, set := range slices.Sorted(maps.Keys(doc.Sets)) {
+		c.Add(set, doc.Sets[set])
+	}
+	return nil
+}
+
+// Names returns the names of the collections in the order they were added.
+func (c *Catalog) Names() []string {
+	return slices.Clone(c.names)
+}
+
+// Set returns the collection name.
+func (c *Catalog) Set(name string) ([]Scenario, error) {
+	scenarios, ok := c.sets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown set %q (want %s)", name, strings.Join(c.names, ", "))
+	}
+	return scenarios, nil
+}
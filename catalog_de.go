@@ -0,0 +1,79 @@
+package interactions
+
+// catalogDE is the German catalog.
+var catalogDE = map[string]string{
+	// grid title and legend
+	"Interaction patterns": "Interaktionsmuster",
+	"Interaction patterns of A and B (all basic combinations)":         "Interaktionsmuster von A und B (alle Grundkombinationen)",
+	"Interaction patterns of A and B with %s (all basic combinations)": "Interaktionsmuster von A und B mit %s (alle Grundkombinationen)",
+	"Interaction patterns of %s (all basic combinations)":              "Interaktionsmuster von %s (alle Grundkombinationen)",
+	"Interaction patterns of %s with %s (all basic combinations)":      "Interaktionsmuster von %s mit %s (alle Grundkombinationen)",
+	"%s and %s":                              "%s und %s",
+	" (page %d of %d)":                       " (Seite %d von %d)",
+	" (contents)":                            " (Inhalt)",
+	"No.":                                    "Nr.",
+	"Code":                                   "Code",
+	"Title":                                  "Titel",
+	"Page":                                   "Seite",
+	"×%d (symmetric)":                        "×%d (symmetrisch)",
+	"%s: unset":                              "%s: nicht gesetzt",
+	"Source: github.com/arran4/interactions": "Quelle: github.com/arran4/interactions",
+	"Legend":                                 "Legende",
+	"Influence":                              "Einfluss",
+	"Single arrow: influence (e.g. C → A)":   "Einfacher Pfeil: Einfluss (z. B. C → A)",
+	"Inhibition":                             "Hemmung",
+	"Tee head: inhibition (A ⊣ B)":           "T-Spitze: Hemmung (A ⊣ B)",
+	"Feedback":                               "Rückkopplung",
+	"Loop: self-reinforcement (A → A)":       "Schleife: Selbstverstärkung (A → A)",
+	"Mutualism":                              "Mutualismus",
+	"Double arrow: mutualism (A ↔ B)":        "Doppelpfeil: Mutualismus (A ↔ B)",
+	"Double line: mutualism (A = B)":         "Doppellinie: Mutualismus (A = B)",
+	"Delay":                                  "Verzögerung",
+	"Dashed arrow: delayed influence":        "Gestrichelter Pfeil: verzögerter Einfluss",
+	"Ecological signs (effect on each party: + gain, - loss, 0 none)": "Ökologische Vorzeichen (Wirkung auf jede Seite: + Gewinn, - Verlust, 0 keine)",
+	"++ mutualism, -- competition, +- predation":                      "++ Mutualismus, -- Konkurrenz, +- Prädation",
+	"+0 commensalism, -0 amensalism, 00 neutralism":                   "+0 Kommensalismus, -0 Amensalismus, 00 Neutralismus",
+	"Chronology":         "Zeitliche Abfolge",
+	"Within each panel:": "In jedem Feld:",
+	"Upper row = earlier (no incoming arrows)":        "Obere Reihe = früher (keine eingehenden Pfeile)",
+	"Lower row = later (influenced by others)":        "Untere Reihe = später (von anderen beeinflusst)",
+	"Boxes = processes, top to bottom = start to end": "Kästen = Prozesse, oben bis unten = Anfang bis Ende",
+	"Uncertain": "Unsicher",
+	"Dotted arrow: p=0.5 chance, ? conditional": "Gepunkteter Pfeil: p=0.5 Chance, ? bedingt",
+	"Edge colour by source":                     "Kantenfarbe nach Quelle",
+	"from %s":                                   "von %s",
+
+	// generated titles and subtitles
+	"A & B: no direct link":      "A & B: keine direkte Verbindung",
+	"A ↔ B (mutualism)":          "A ↔ B (Mutualismus)",
+	"A ⊣ B (amensalism)":         "A ⊣ B (Amensalismus)",
+	"A ⊣⊢ B (competition)":       "A ⊣⊢ B (Konkurrenz)",
+	"A preys on B (predation)":   "A erbeutet B (Prädation)",
+	"A preys on B":               "A erbeutet B",
+	"neutralism":                 "Neutralismus",
+	"commensalism":               "Kommensalismus",
+	"mutualism":                  "Mutualismus",
+	"amensalism":                 "Amensalismus",
+	"competition":                "Konkurrenz",
+	"predation":                  "Prädation",
+	"weak":                       "schwach",
+	"strong":                     "stark",
+	"delayed":                    "verzögert",
+	"sometimes":                  "manchmal",
+	"conditional":                "bedingt",
+	"A self-reinforcing":         "A selbstverstärkend",
+	"B self-reinforcing":         "B selbstverstärkend",
+	"A and B self-reinforcing":   "A und B selbstverstärkend",
+	"A meets B":                  "A grenzt an B",
+	"A overlaps B":               "A überlappt B",
+	"A contains B":               "A enthält B",
+	"No external influences":     "Keine äußeren Einflüsse",
+	"%s has no effect on A or B": "%s wirkt weder auf A noch auf B",
+	"%s influences A only":       "%s beeinflusst nur A",
+	"%s influences B only":       "%s beeinflusst nur B",
+	"%s influences both A and B": "%s beeinflusst A und B",
+	"%s has no effect":           "%s bleibt ohne Wirkung",
+	"%s influences %s":           "%s beeinflusst %s",
+	"%s preys on %s":             "%s erbeutet %s",
+	"No direct links":            "Keine direkten Verbindungen",
+}
@@ -0,0 +1,79 @@
+package interactions
+
+// catalogES is the Spanish catalog.
+var catalogES = map[string]string{
+	// grid title and legend
+	"Interaction patterns": "Patrones de interacción",
+	"Interaction patterns of A and B (all basic combinations)":         "Patrones de interacción de A y B (todas las combinaciones básicas)",
+	"Interaction patterns of A and B with %s (all basic combinations)": "Patrones de interacción de A y B con %s (todas las combinaciones básicas)",
+	"Interaction patterns of %s (all basic combinations)":              "Patrones de interacción de %s (todas las combinaciones básicas)",
+	"Interaction patterns of %s with %s (all basic combinations)":      "Patrones de interacción de %s con %s (todas las combinaciones básicas)",
+	"%s and %s":                              "%s y %s",
+	" (page %d of %d)":                       " (página %d de %d)",
+	" (contents)":                            " (índice)",
+	"No.":                                    "N.º",
+	"Code":                                   "Código",
+	"Title":                                  "Título",
+	"Page":                                   "Página",
+	"×%d (symmetric)":                        "×%d (simétrico)",
+	"%s: unset":                              "%s: sin valor",
+	"Source: github.com/arran4/interactions": "Fuente: github.com/arran4/interactions",
+	"Legend":                                 "Leyenda",
+	"Influence":                              "Influencia",
+	"Single arrow: influence (e.g. C → A)":   "Flecha simple: influencia (p. ej. C → A)",
+	"Inhibition":                             "Inhibición",
+	"Tee head: inhibition (A ⊣ B)":           "Punta en T: inhibición (A ⊣ B)",
+	"Feedback":                               "Retroalimentación",
+	"Loop: self-reinforcement (A → A)":       "Bucle: autorrefuerzo (A → A)",
+	"Mutualism":                              "Mutualismo",
+	"Double arrow: mutualism (A ↔ B)":        "Flecha doble: mutualismo (A ↔ B)",
+	"Double line: mutualism (A = B)":         "Línea doble: mutualismo (A = B)",
+	"Delay":                                  "Retardo",
+	"Dashed arrow: delayed influence":        "Flecha discontinua: influencia retardada",
+	"Ecological signs (effect on each party: + gain, - loss, 0 none)": "Signos ecológicos (efecto sobre cada parte: + ganancia, - pérdida, 0 ninguno)",
+	"++ mutualism, -- competition, +- predation":                      "++ mutualismo, -- competencia, +- depredación",
+	"+0 commensalism, -0 amensalism, 00 neutralism":                   "+0 comensalismo, -0 amensalismo, 00 neutralismo",
+	"Chronology":         "Cronología",
+	"Within each panel:": "En cada panel:",
+	"Upper row = earlier (no incoming arrows)":        "Fila superior = antes (sin flechas entrantes)",
+	"Lower row = later (influenced by others)":        "Fila inferior = después (influida por otros)",
+	"Boxes = processes, top to bottom = start to end": "Cajas = procesos, de arriba abajo = de inicio a fin",
+	"Uncertain": "Incierto",
+	"Dotted arrow: p=0.5 chance, ? conditional": "Flecha punteada: p=0.5 probabilidad, ? condicional",
+	"Edge colour by source":                     "Color de arista según origen",
+	"from %s":                                   "desde %s",
+
+	// generated titles and subtitles
+	"A & B: no direct link":      "A & B: sin vínculo directo",
+	"A ↔ B (mutualism)":          "A ↔ B (mutualismo)",
+	"A ⊣ B (amensalism)":         "A ⊣ B (amensalismo)",
+	"A ⊣⊢ B (competition)":       "A ⊣⊢ B (competencia)",
+	"A preys on B (predation)":   "A depreda a B (depredación)",
+	"A preys on B":               "A depreda a B",
+	"neutralism":                 "neutralismo",
+	"commensalism":               "comensalismo",
+	"mutualism":                  "mutualismo",
+	"amensalism":                 "amensalismo",
+	"competition":                "competencia",
+	"predation":                  "depredación",
+	"weak":                       "débil",
+	"strong":                     "fuerte",
+	"delayed":                    "retardado",
+	"sometimes":                  "a veces",
+	"conditional":                "condicional",
+	"A self-reinforcing":         "A se autorrefuerza",
+	"B self-reinforcing":         "B se autorrefuerza",
+	"A and B self-reinforcing":   "A y B se autorrefuerzan",
+	"A meets B":                  "A se encuentra con B",
+	"A overlaps B":               "A se solapa con B",
+	"A contains B":               "A contiene a B",
+	"No external influences":     "Sin influencias externas",
+	"%s has no effect on A or B": "%s no afecta a A ni a B",
+	"%s influences A only":       "%s influye solo en A",
+	"%s influences B only":       "%s influye solo en B",
+	"%s influences both A and B": "%s influye en A y en B",
+	"%s has no effect":           "%s no tiene efecto",
+	"%s influences %s":           "%s influye en %s",
+	"%s preys on %s":             "%s depreda a %s",
+	"No direct links":            "Sin vínculos directos",
+}
@@ -0,0 +1,131 @@
+//go:build js && wasm
+
+// Command interactions-wasm draws scenarios in the browser. It is built
+// apart from the interactions command, on the library alone, so the
+// WebAssembly module carries none of the command's servers and encoders.
+// Instead of running a command it registers functions on the JavaScript
+// global object and waits for calls:
+//
+//	renderScenario(code, options) -> Uint8Array
+//
+// renders the generated scenario with the given code or list number as a
+// PNG panel. options is an object whose keys are render flags: theme,
+// captions, scale and the generation options such as timing or externals.
+// Failures return an Error instead of the PNG bytes.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"math/big"
+	"syscall/js"
+
+	"github.com/arran4/interactions"
+	"github.com/arran4/interactions/internal/cliflags"
+)
+
+// maxScenarios is the most scenarios the generation options may make, so a
+// page cannot hang the browser.
+const maxScenarios = 10000
+
+func main() {
+	js.Global().Set("renderScenario", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) == 0 {
+			return jsError(errors.New("renderScenario needs a scenario code"))
+		}
+		options := js.Undefined()
+		if len(args) > 1 {
+			options = args[1]
+		}
+		png, err := renderScenarioPNG(args[0].String(), options)
+		if err != nil {
+			return jsError(err)
+		}
+		out := js.Global().Get("Uint8Array").New(len(png))
+		js.CopyBytesToJS(out, png)
+		return out
+	}))
+	select {}
+}
+
+// renderScenarioPNG draws one generated scenario as PNG bytes.
+func renderScenarioPNG(code string, options js.Value) ([]byte, error) {
+	fs := flag.NewFlagSet("renderScenario", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	themeName := fs.String("theme", "light", "colour theme: light or dark")
+	captions := fs.String("captions", "title,code", "comma-separated panel captions")
+	scale := fs.Int("scale", 1, "integer factor to enlarge the panel by")
+	var gen interactions.GeneratorOptions
+	cliflags.AddGeneratorFlags(fs, &gen)
+	generator := fs.String("generator", interactions.TaxonomyName, "generator of the scenarios")
+	if err := setFlagsFromJS(fs, options); err != nil {
+		return nil, err
+	}
+
+	generate, err := interactions.GeneratorNamed(*generator)
+	if err != nil {
+		return nil, err
+	}
+	if *generator == interactions.TaxonomyName {
+		n, err := interactions.CountScenarios(interactions.FromOptions(gen))
+		if err != nil {
+			return nil, err
+		}
+		if n.Cmp(big.NewInt(maxScenarios)) > 0 {
+			return nil, fmt.Errorf("these options generate %s scenarios, more than the limit of %d", n, maxScenarios)
+		}
+	}
+	th, err := interactions.ThemeNamed(*themeName)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := interactions.ParseCaptions(*captions)
+	if err != nil {
+		return nil, err
+	}
+	if *scale < 1 || *scale > cliflags.MaxScale {
+		return nil, fmt.Errorf("option scale must be from 1 to %d, got %d", cliflags.MaxScale, *scale)
+	}
+
+	scenarios := generate(gen)
+	n, err := interactions.FindScenario(scenarios, code)
+	if err != nil {
+		return nil, err
+	}
+	img := interactions.ScaleImage(interactions.DrawPanel(scenarios[n], th, interactions.WithCaptions(caps)), *scale)
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// setFlagsFromJS sets the flags named by the keys of a JavaScript object
+// to its values, converted to strings as JavaScript would.
+func setFlagsFromJS(fs *flag.FlagSet, options js.Value) error {
+	if options.Type() != js.TypeObject {
+		return nil
+	}
+	keys := js.Global().Get("Object").Call("keys", options)
+	for i := 0; i < keys.Length(); i++ {
+		name := keys.Index(i).String()
+		value := js.Global().Call("String", options.Get(name)).String()
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("option %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// jsError converts err to a JavaScript Error.
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// altText is the sidecar render --alt-text writes beside each image,
+// describing its panels for screen readers and alt attributes.
+type altText struct {
+	Image  string     `json:"image"`
+	Panels []altPanel `json:"panels"`
+}
+
+type altPanel struct {
+	// Number is the scenario's number in the list output.
+	Number      int    `json:"number"`
+	Code        string `json:"code,omitempty"`
+	Title       string `json:"title"`
+	Subtitle    string `json:"subtitle,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Alt describes what the panel draws, from interactions.Describe.
+	Alt string `json:"alt"`
+}
+
+// newAltPanel describes s, with list number n.
+func newAltPanel(s interactions.Scenario, n int) altPanel {
+	return altPanel{
+		Number:      n,
+		Code:        s.Code,
+		Title:       s.Title,
+		Subtitle:    s.Subtitle,
+		Description: s.Description,
+		Alt:         interactions.Describe(s),
+	}
+}
+
+// altTextName is the sidecar of the image file: interactions.png has
+// interactions.alt.json.
+func altTextName(imageFile string) string {
+	return strings.TrimSuffix(imageFile, filepath.Ext(imageFile)) + ".alt.json"
+}
+
+// writeAltText writes the sidecar of imageFile to dest, where the image
+// draws scenarios with the given list numbers.
+func writeAltText(dest sink, imageFile string, scenarios []interactions.Scenario, numbers []int) error {
+	alt := altText{Image: filepath.Base(imageFile), Panels: make([]altPanel, len(scenarios))}
+	for i, s := range scenarios {
+		alt.Panels[i] = newAltPanel(s, numbers[i])
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(alt); err != nil {
+		return err
+	}
+	name := altTextName(imageFile)
+	return logGenerated(name, writeFile(dest, name, buf.Bytes()))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arran4/interactions"
+)
+
+// benchResult is the time each stage of rendering took for one grid size,
+// the fastest of the runs.
+type benchResult struct {
+	Panels    int           `json:"panels"`
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Generate  time.Duration `json:"generate_ns"`
+	Layout    time.Duration `json:"layout_ns"`
+	Rasterize time.Duration `json:"rasterize_ns"`
+	Encode    time.Duration `json:"encode_ns"`
+	// Bytes is the size of the encoded image.
+	Bytes int64 `json:"bytes"`
+	// Alloc is how much memory drawing and encoding the image allocated,
+	// the least of the runs.
+	Alloc uint64 `json:"alloc_bytes"`
+}
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	sizes := fs.String("sizes", "16,80,320", "comma-separated numbers of panels to time, repeating the scenarios to fill larger grids")
+	columns := fs.Int("columns", 8, "number of columns in the grid")
+	runs := fs.Int("runs", 3, "times to run each size, reporting the fastest")
+	formatName := fs.String("format", "png", "image format to encode: png, jpeg or webp")
+	quality := fs.Int("quality", defaultQuality, "quality of jpeg and webp output, from 1 to 100")
+	jsonOut := fs.Bool("json", false, "print the results as JSON instead of a table")
+	reuse := fs.Bool("reuse", true, "release each image once encoded, for the next run to draw on, as serve does; false allocates each afresh")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	panels, err := parseSizes(*sizes)
+	if err != nil {
+		return err
+	}
+	if *columns < 1 {
+		return usageErrorf("columns must be at least 1")
+	}
+	if *runs < 1 {
+		return usageErrorf("--runs must be at least 1, got %d", *runs)
+	}
+	format, err := formatNamed(*formatName, "")
+	if err != nil {
+		return err
+	}
+	if *quality < 1 || *quality > 100 {
+		return usageErrorf("--quality must be from 1 to 100, got %d", *quality)
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	th, err := interactions.ThemeNamed("light")
+	if err != nil {
+		return err
+	}
+
+	results := make([]benchResult, len(panels))
+	for i, n := range panels {
+		r := benchResult{Panels: n}
+		var best [4]time.Duration
+		for run := range *runs {
+			t, alloc, err := benchOnce(n, *columns, format, *quality, *genOpts, th, *reuse, &r)
+			if err != nil {
+				return err
+			}
+			if run == 0 || alloc < r.Alloc {
+				r.Alloc = alloc
+			}
+			for stage := range t {
+				if run == 0 || t[stage] < best[stage] {
+					best[stage] = t[stage]
+				}
+			}
+		}
+		r.Generate, r.Layout, r.Rasterize, r.Encode = best[0], best[1], best[2], best[3]
+		results[i] = r
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "panels\tsize\tgenerate\tlayout\trasterize\tencode\ttotal\tbytes\talloc\t")
+	for _, r := range results {
+		total := r.Generate + r.Layout + r.Rasterize + r.Encode
+		fmt.Fprintf(tw, "%d\t%dx%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t\n", r.Panels, r.Width, r.Height,
+			benchDuration(r.Generate), benchDuration(r.Layout), benchDuration(r.Rasterize), benchDuration(r.Encode), benchDuration(total), r.Bytes, benchBytes(r.Alloc))
+	}
+	return tw.Flush()
+}
+
+// benchOnce times generating, laying out, drawing and encoding a grid of n
+// panels, recording the grid's size in r, and returns as well how much
+// drawing and encoding allocated. With reuse the image is released once
+// encoded, for the next run to draw on.
+func benchOnce(n, columns int, format imageFormat, quality int, opts generateOptions, th interactions.Theme, reuse bool, r *benchResult) ([4]time.Duration, uint64, error) {
+	var t [4]time.Duration
+	var before, after runtime.MemStats
+
+	start := time.Now()
+	generated := generateScenarios(opts)
+	t[0] = time.Since(start)
+	scenarios := make([]interactions.Scenario, n)
+	for i := range scenarios {
+		scenarios[i] = generated[i%len(generated)]
+	}
+
+	start = time.Now()
+	bounds := interactions.LayoutGrid(scenarios, columns)
+	t[1] = time.Since(start)
+	r.Width, r.Height = bounds.Dx(), bounds.Dy()
+
+	runtime.ReadMemStats(&before)
+	start = time.Now()
+	img := interactions.DrawGrid(scenarios, columns, th)
+	t[2] = time.Since(start)
+
+	w := &countingWriter{w: io.Discard}
+	start = time.Now()
+	if err := format.encode(w, img, quality); err != nil {
+		return t, 0, err
+	}
+	t[3] = time.Since(start)
+	if reuse {
+		interactions.ReleaseImage(img)
+	}
+	runtime.ReadMemStats(&after)
+	r.Bytes = w.n
+	return t, after.TotalAlloc - before.TotalAlloc, nil
+}
+
+// parseSizes reads the --sizes flag.
+func parseSizes(value string) ([]int, error) {
+	var sizes []int
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 {
+			return nil, usageErrorf("--sizes: %q is not a number of panels", part)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+// benchDuration rounds d for the table, keeping three significant figures
+// or so.
+func benchDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+// benchBytes gives n in the largest unit it is at least one of.
+func benchBytes(n uint64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
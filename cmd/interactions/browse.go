@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/arran4/interactions"
+	"golang.org/x/term"
+)
+
+func runBrowse(args []string) error {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	themeName := fs.String("theme", "light", "colour theme for rendered panels: light, dark, or a YAML theme file")
+	colors := addColorFlags(fs)
+	scenariosFile := fs.String("scenarios", "", "browse the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	if err := colors.apply(&th); err != nil {
+		return err
+	}
+	scenarios, err := loadScenarios(*scenariosFile, *genOpts, vars)
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return usageErrorf("browse needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	b := &browser{scenarios: scenarios, theme: th, out: os.Stdout}
+	b.refilter()
+	err = b.run(bufio.NewReader(os.Stdin), func() (int, int) {
+		w, h, err := term.GetSize(fd)
+		if err != nil {
+			return 80, 24
+		}
+		return w, h
+	})
+	fmt.Fprint(b.out, "\x1b[H\x1b[2J")
+	return err
+}
+
+// browser is the interactive state of the browse subcommand: a filter typed
+// by the user, the scenarios matching it and the selected one.
+type browser struct {
+	scenarios []interactions.Scenario
+	theme     interactions.Theme
+	out       io.Writer
+
+	filter  string
+	matches []int // indexes into scenarios
+	cursor  int   // index into matches
+	offset  int   // first match shown
+	status  string
+}
+
+// Key codes read from a raw terminal.
+const (
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyBackspace = 8
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+func (b *browser) run(in *bufio.Reader, size func() (int, int)) error {
+	for {
+		b.draw(size())
+
+		c, err := in.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case keyCtrlC, keyCtrlD:
+			return nil
+		case '\r', '\n':
+			b.renderSelected()
+		case keyBackspace, keyDelete:
+			if b.filter != "" {
+				_, n := utf8.DecodeLastRuneInString(b.filter)
+				b.filter = b.filter[:len(b.filter)-n]
+				b.refilter()
+			}
+		case keyCtrlU:
+			b.filter = ""
+			b.refilter()
+		case keyCtrlN:
+			b.move(1)
+		case keyCtrlP:
+			b.move(-1)
+		case keyEscape:
+			// arrow keys arrive as ESC [ A and ESC [ B
+			if next, _ := in.ReadByte(); next != '[' {
+				continue
+			}
+			switch arrow, _ := in.ReadByte(); arrow {
+			case 'A':
+				b.move(-1)
+			case 'B':
+				b.move(1)
+			}
+		default:
+			if c >= ' ' {
+				b.filter += string(c)
+				b.refilter()
+			}
+		}
+	}
+}
+
+// refilter recomputes the matches after the filter changes. Every
+// whitespace-separated term must appear in the code, title or subtitle, so
+// "mutualism delayed" narrows by two dimensions at once.
+func (b *browser) refilter() {
+	terms := strings.Fields(strings.ToLower(b.filter))
+	b.matches = b.matches[:0]
+	for i, s := range b.scenarios {
+		text := strings.ToLower(s.Code + " " + s.Title + " " + s.Subtitle)
+		matched := true
+		for _, t := range terms {
+			if !strings.Contains(text, t) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			b.matches = append(b.matches, i)
+		}
+	}
+	b.cursor, b.offset = 0, 0
+}
+
+func (b *browser) move(delta int) {
+	b.cursor += delta
+	if b.cursor >= len(b.matches) {
+		b.cursor = len(b.matches) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// renderSelected writes the selected panel to a temporary PNG and reports
+// where it went.
+func (b *browser) renderSelected() {
+	if len(b.matches) == 0 {
+		return
+	}
+	n := b.matches[b.cursor]
+	f, err := os.CreateTemp("", fmt.Sprintf("interactions-%d-*.png", n+1))
+	if err != nil {
+		b.status = "render failed: " + err.Error()
+		return
+	}
+	defer f.Close()
+	if err := png.Encode(f, interactions.DrawPanel(b.scenarios[n], b.theme)); err != nil {
+		b.status = "render failed: " + err.Error()
+		return
+	}
+	b.status = fmt.Sprintf("%02d rendered to %s", n+1, f.Name())
+}
+
+// draw repaints the whole screen. Raw mode needs explicit carriage returns.
+func (b *browser) draw(width, height int) {
+	rows := height - 3 // filter line, blank line and status line
+	if rows < 1 {
+		rows = 1
+	}
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+rows {
+		b.offset = b.cursor - rows + 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+	header := fmt.Sprintf("Filter: %s_  (%d of %d)  ↑/↓ select, Enter render, Ctrl-U clear, Ctrl-C quit",
+		b.filter, len(b.matches), len(b.scenarios))
+	sb.WriteString(truncate(header, width) + "\r\n\r\n")
+	for i := b.offset; i < len(b.matches) && i < b.offset+rows; i++ {
+		marker := "  "
+		if i == b.cursor {
+			marker = "> "
+		}
+		s := b.scenarios[b.matches[i]]
+		line := fmt.Sprintf("%s%s %s — %s", marker, interactions.ListNumber(b.matches[i]+1), strings.TrimSpace(s.Code+" "+s.Title), s.Subtitle)
+		if i == b.cursor {
+			sb.WriteString("\x1b[7m" + truncate(line, width) + "\x1b[0m\r\n")
+		} else {
+			sb.WriteString(truncate(line, width) + "\r\n")
+		}
+	}
+	fmt.Fprintf(&sb, "\x1b[%d;1H%s", height, truncate(b.status, width))
+	fmt.Fprint(b.out, sb.String())
+}
+
+// truncate shortens s to at most width runes.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// osFS opens files of the operating system by their paths as given,
+// absolute or relative, where os.DirFS only opens paths within its
+// directory.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// scenarioArchive splits a --scenarios path through a zip archive, such as
+// bundle.zip/week1.yaml, into the archive and the file within it, which is
+// empty for the archive alone. ok is false for a path through no archive.
+func scenarioArchive(file string) (archive, name string, ok bool) {
+	separators := "/" + string(filepath.Separator)
+	for end := 1; end <= len(file); end++ {
+		if end < len(file) && !strings.ContainsRune(separators, rune(file[end])) {
+			continue
+		}
+		prefix := file[:end]
+		if !strings.EqualFold(filepath.Ext(prefix), ".zip") {
+			continue
+		}
+		if info, err := os.Stat(prefix); err == nil && info.Mode().IsRegular() {
+			return prefix, filepath.ToSlash(strings.TrimLeft(file[end:], separators)), true
+		}
+	}
+	return "", "", false
+}
+
+// scenarioFiles calls read with each scenario file a --scenarios path
+// names, and the path to show for it in messages. A path through a zip
+// archive, such as bundle.zip/week1.yaml, names the file within it, and
+// the archive alone, as in bundle.zip, every .yaml and .yml file within
+// it in order of path, so a bundle of scenario files can be shared as one
+// file. Errors reading from an archive name it before the file within.
+func scenarioFiles(file string, read func(fsys fs.FS, name, shown string) error) error {
+	archive, name, ok := scenarioArchive(file)
+	if !ok {
+		return read(osFS{}, file, file)
+	}
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	defer r.Close()
+
+	names := []string{name}
+	if name == "" {
+		names = nil
+		err := fs.WalkDir(r, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := strings.ToLower(path.Ext(p)); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				names = append(names, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", archive, err)
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("%s: no .yaml or .yml scenario files", archive)
+		}
+	}
+	for _, name := range names {
+		if err := read(r, name, archive+"/"+name); err != nil {
+			return fmt.Errorf("%s: %w", archive, err)
+		}
+	}
+	return nil
+}
+
+// loadScenarioFiles reads the scenarios of each scenario file a
+// --scenarios path names with load, in order.
+func loadScenarioFiles(file string, load func(fsys fs.FS, name string) ([]interactions.Scenario, error)) ([]interactions.Scenario, error) {
+	var scenarios []interactions.Scenario
+	err := scenarioFiles(file, func(fsys fs.FS, name, _ string) error {
+		s, err := load(fsys, name)
+		scenarios = append(scenarios, s...)
+		return err
+	})
+	return scenarios, scenarioFileError(err)
+}
+
+// printFileProblems prints the problems of each scenario file a
+// --scenarios path names, and returns how many there are.
+func printFileProblems(file string) (int, error) {
+	count := 0
+	err := scenarioFiles(file, func(fsys fs.FS, name, shown string) error {
+		problems, err := interactions.ValidateFileFS(fsys, name)
+		for _, p := range problems {
+			fmt.Printf("%s:%s\n", shown, p)
+			count++
+		}
+		return err
+	})
+	return count, err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// runClasses groups the scenarios into classes that draw the same pattern
+// once the external actors are relabelled, prints them and renders a sheet
+// of each class's members.
+func runClasses(args []string) error {
+	fs := flag.NewFlagSet("classes", flag.ContinueOnError)
+	outputDir := fs.String("output-dir", "classes", "directory to write a sheet of each class to, created if need be, or a .zip, .tar.gz or .tgz archive of them")
+	force := fs.Bool("force", false, "rewrite output files even when they already hold what would be written, instead of leaving them with their modification times")
+	columns := fs.Int("columns", 4, "most panels across each sheet")
+	themeName := fs.String("theme", "light", "colour theme: light, dark, or a YAML theme file")
+	colors := addColorFlags(fs)
+	scenariosFile := fs.String("scenarios", "", "group the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	query := fs.String("query", "", `group only the scenarios matching this query, e.g. "ab=mutualism and c!=none"`)
+	singles := fs.Bool("singles", false, "also write sheets for classes of one scenario")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *columns < 1 {
+		return usageErrorf("columns must be at least 1")
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	if err := colors.apply(&th); err != nil {
+		return err
+	}
+
+	scenarios, err := loadScenarios(*scenariosFile, *genOpts, vars)
+	if err != nil {
+		return err
+	}
+	matches, err := matchingScenarios(scenarios, *query)
+	if err != nil {
+		return err
+	}
+	selected := make([]interactions.Scenario, len(matches))
+	for i, n := range matches {
+		selected[i] = scenarios[n]
+	}
+	classes := interactions.Classes(selected, genOpts.ExternalNames()...)
+	fmt.Printf("%d scenarios in %d classes\n", len(selected), len(classes))
+
+	// sheets in a directory are named by their paths, as they are logged
+	var dest sink = fileSink{force: *force}
+	dir := *outputDir
+	if isArchive(*outputDir) {
+		if dest, err = createArchive(*outputDir, *force); err != nil {
+			return err
+		}
+		dir = ""
+	} else if err := os.MkdirAll(*outputDir, 0o755); err != nil {
+		return err
+	}
+	width := len(fmt.Sprint(len(classes)))
+	for c, members := range classes {
+		refs := make([]string, len(members))
+		sheet := make([]interactions.Scenario, len(members))
+		numbers := make([]int, len(members))
+		for i, m := range members {
+			sheet[i] = selected[m]
+			numbers[i] = matches[m] + 1
+			refs[i] = interactions.ListNumber(numbers[i]) + " " + scenarioLabel(sheet[i])
+			if sheet[i].Subtitle != "" {
+				refs[i] += " — " + sheet[i].Subtitle
+			}
+		}
+		fmt.Printf("\nClass %d (%d):\n  %s\n", c+1, len(members), strings.Join(refs, "\n  "))
+		if len(members) == 1 && !*singles {
+			continue
+		}
+
+		g := gridSettings{
+			columns:   min(*columns, len(members)),
+			themeName: *themeName,
+			theme:     th,
+		}
+		// sheets can be only a couple of panels wide, too narrow for the
+		// legend
+		opts := []interactions.Option{
+			interactions.WithoutLegend(),
+			interactions.WithCaptions(interactions.Captions{Title: true, Code: true, Index: true}),
+			interactions.WithNumbers(numbers),
+			interactions.WithLanguage(genOpts.Lang),
+		}
+		file := filepath.Join(dir, fmt.Sprintf("class-%0*d.png", width, c+1))
+		if err := writeGrid([]outputFile{{name: file, format: imageFormats["png"], sink: dest}}, sheet, g, opts, nil, nil); err != nil {
+			return closeAfter(dest, err)
+		}
+	}
+	err = dest.Close()
+	if isArchive(*outputDir) {
+		err = logGenerated(*outputDir, err)
+	}
+	if err != nil {
+		return withKind(ioError, fmt.Errorf("writing %s: %w", *outputDir, err))
+	}
+	return nil
+}
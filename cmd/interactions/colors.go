@@ -0,0 +1,108 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"image/color"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// loadTheme returns the theme a --theme flag names: light, dark, or a YAML
+// theme file, which may be within a zip archive as in
+// bundle.zip/theme.yaml.
+func loadTheme(name string) (interactions.Theme, error) {
+	if ext := strings.ToLower(filepath.Ext(name)); ext != ".yaml" && ext != ".yml" {
+		th, err := interactions.ThemeNamed(name)
+		return th, withKind(usageError, err)
+	}
+	archive, file, ok := scenarioArchive(name)
+	if !ok {
+		th, err := interactions.LoadThemeFile(name)
+		return th, scenarioFileError(err)
+	}
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return interactions.Theme{}, fmt.Errorf("%s: %w", archive, err)
+	}
+	defer r.Close()
+	th, err := interactions.LoadThemeFileFS(r, file)
+	if err != nil {
+		return interactions.Theme{}, scenarioFileError(fmt.Errorf("%s: %w", archive, err))
+	}
+	return th, nil
+}
+
+// colorFlags are the flags overriding single colours of the theme.
+type colorFlags struct {
+	nodeFill, nodeBorder, edge, panel *string
+}
+
+func addColorFlags(fs *flag.FlagSet) *colorFlags {
+	return &colorFlags{
+		nodeFill:   fs.String("node-fill", "", "fill colour of nodes as hex, e.g. #dcebfa, overriding the theme"),
+		nodeBorder: fs.String("node-border", "", "border colour of nodes as hex, overriding the theme"),
+		edge:       fs.String("edge-color", "", "colour of edges as hex, overriding the theme"),
+		panel:      fs.String("panel-bg", "", "background colour of panels and the legend as hex, overriding the theme"),
+	}
+}
+
+// apply sets the colours given on the command line in th.
+func (c *colorFlags) apply(th *interactions.Theme) error {
+	for _, o := range []struct {
+		flag  string
+		value string
+		field *color.RGBA
+	}{
+		{"node-fill", *c.nodeFill, &th.NodeFill},
+		{"node-border", *c.nodeBorder, &th.NodeBorder},
+		{"edge-color", *c.edge, &th.Edge},
+		{"panel-bg", *c.panel, &th.Panel},
+	} {
+		if o.value == "" {
+			continue
+		}
+		col, err := interactions.ParseHexColor(o.value)
+		if err != nil {
+			return usageErrorf("--%s: %v", o.flag, err)
+		}
+		*o.field = col
+	}
+	return nil
+}
+
+// sourcePalette colours the edges of each source actor for
+// --color-by-source, repeating when there are more actors than colours.
+var sourcePalette = []color.RGBA{
+	{31, 119, 180, 255},
+	{255, 127, 14, 255},
+	{44, 160, 44, 255},
+	{148, 103, 189, 255},
+	{140, 86, 75, 255},
+	{227, 119, 194, 255},
+	{23, 190, 207, 255},
+	{188, 189, 34, 255},
+}
+
+// sourceColors gives every node other than A and B that an edge of
+// scenarios comes from a colour of sourcePalette, in order of name.
+func sourceColors(scenarios []interactions.Scenario) map[string]color.RGBA {
+	sources := map[string]bool{}
+	for _, s := range scenarios {
+		for _, e := range s.Edges {
+			if e.From != "A" && e.From != "B" {
+				sources[e.From] = true
+			}
+		}
+	}
+	colors := map[string]color.RGBA{}
+	for i, name := range slices.Sorted(maps.Keys(sources)) {
+		colors[name] = sourcePalette[i%len(sourcePalette)]
+	}
+	return colors
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A config file sets defaults for command flags, keyed by flag name:
+//
+//	theme: dark
+//	columns: 3
+//	output: out/interactions.png
+//	timing: true
+//
+// Each command takes the keys naming its own flags and ignores the rest. A
+// map named after a command sets defaults for it alone, overriding the
+// top-level keys:
+//
+//	render:
+//	  output: out/interactions.png
+//
+// Flags given on the command line win over every file.
+
+// projectConfigFile is the config file looked for in the working directory.
+const projectConfigFile = "interactions.yaml"
+
+// configValues holds the flag defaults loaded by loadConfig, by command,
+// with the top-level keys under "".
+var configValues map[string]map[string]string
+
+// configFiles returns the config files to load, lowest precedence first:
+// the user's config, then the project's. An explicit --config file replaces
+// both.
+func configFiles(explicit string) []string {
+	if explicit != "" {
+		return []string{explicit}
+	}
+	var files []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, "interactions", "config.yaml"))
+	}
+	return append(files, projectConfigFile)
+}
+
+// loadConfig reads the config files into configValues, later files
+// overriding earlier ones. Missing files are skipped unless named by
+// --config.
+func loadConfig(explicit string) error {
+	configValues = map[string]map[string]string{"": {}}
+	for _, file := range configFiles(explicit) {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) && explicit == "" {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var top map[string]yaml.Node
+		if err := yaml.Unmarshal(data, &top); err != nil {
+			return withKind(validationError, fmt.Errorf("%s: %w", file, err))
+		}
+		for key, node := range top {
+			if node.Kind == yaml.MappingNode {
+				var section map[string]string
+				if err := node.Decode(&section); err != nil {
+					return withKind(validationError, fmt.Errorf("%s: %s: %w", file, key, err))
+				}
+				if configValues[key] == nil {
+					configValues[key] = map[string]string{}
+				}
+				maps.Copy(configValues[key], section)
+				continue
+			}
+			var value string
+			if err := node.Decode(&value); err != nil {
+				return withKind(validationError, fmt.Errorf("%s: %s: %w", file, key, err))
+			}
+			configValues[""][key] = value
+		}
+	}
+	return nil
+}
+
+// flagAliases maps the shorthand flags to the flags they stand for, so
+// that setting either, on the command line or in a config file, counts as
+// setting the flag.
+var flagAliases = map[string]string{"o": "output"}
+
+// flagName is the name of the flag name stands for.
+func flagName(name string) string {
+	if long, ok := flagAliases[name]; ok {
+		return long
+	}
+	return name
+}
+
+// parseFlags parses a command's arguments, then sets the flags they left
+// out from the config files.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return withKind(usageError, err)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[flagName(f.Name)] = true })
+	values := map[string]string{}
+	for _, section := range []map[string]string{configValues[""], configValues[fs.Name()]} {
+		for key, value := range section {
+			values[flagName(key)] = value
+		}
+	}
+	for name, value := range values {
+		if set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return withKind(validationError, fmt.Errorf("config: %s: %w", name, err))
+		}
+	}
+	return nil
+}
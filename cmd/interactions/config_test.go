@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestConfigPrecedence checks that flag defaults come from the user's
+// config, then the project's, then a --config file, and that flags given on
+// the command line, by either name, win over them all.
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name          string
+		user, project string
+		explicit      string
+		args          []string
+		theme         string
+		columns       string
+		outputs       []string
+	}{
+		{
+			name:    "defaults",
+			theme:   "light",
+			columns: "8",
+		},
+		{
+			name:    "user config",
+			user:    "theme: dark\ncolumns: 3\n",
+			theme:   "dark",
+			columns: "3",
+		},
+		{
+			name:    "project config over user config",
+			user:    "theme: dark\ncolumns: 3\n",
+			project: "columns: 5\n",
+			theme:   "dark",
+			columns: "5",
+		},
+		{
+			name:     "--config over project and user config",
+			user:     "theme: dark\n",
+			project:  "columns: 5\n",
+			explicit: "columns: 6\n",
+			theme:    "light",
+			columns:  "6",
+		},
+		{
+			name:     "flags over every file",
+			user:     "theme: dark\n",
+			project:  "columns: 5\n",
+			explicit: "columns: 6\ntheme: dark\n",
+			args:     []string{"--columns", "7"},
+			theme:    "dark",
+			columns:  "7",
+		},
+		{
+			name:    "command section over top-level keys",
+			project: "columns: 5\nrender:\n  columns: 4\n",
+			theme:   "light",
+			columns: "4",
+		},
+		{
+			name:    "output from config",
+			project: "output: cfg.png\n",
+			theme:   "light",
+			columns: "8",
+			outputs: []string{"cfg.png"},
+		},
+		{
+			name:    "--output over config",
+			project: "output: cfg.png\n",
+			args:    []string{"--output", "mine.png"},
+			theme:   "light",
+			columns: "8",
+			outputs: []string{"mine.png"},
+		},
+		{
+			name:    "-o over config",
+			project: "output: cfg.png\n",
+			args:    []string{"-o", "mine.png"},
+			theme:   "light",
+			columns: "8",
+			outputs: []string{"mine.png"},
+		},
+		{
+			name:    "-o over o in config",
+			project: "o: cfg.png\n",
+			args:    []string{"-o", "mine.png"},
+			theme:   "light",
+			columns: "8",
+			outputs: []string{"mine.png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+			t.Setenv("AppData", filepath.Join(home, "AppData"))
+			work := t.TempDir()
+			t.Chdir(work)
+
+			if tt.user != "" {
+				dir, err := os.UserConfigDir()
+				if err != nil {
+					t.Fatal(err)
+				}
+				writeConfig(t, filepath.Join(dir, "interactions", "config.yaml"), tt.user)
+			}
+			if tt.project != "" {
+				writeConfig(t, filepath.Join(work, projectConfigFile), tt.project)
+			}
+			explicit := ""
+			if tt.explicit != "" {
+				explicit = filepath.Join(t.TempDir(), "explicit.yaml")
+				writeConfig(t, explicit, tt.explicit)
+			}
+			if err := loadConfig(explicit); err != nil {
+				t.Fatal(err)
+			}
+
+			fs := flag.NewFlagSet("render", flag.ContinueOnError)
+			var outputs []string
+			addOutput := func(name string) error {
+				outputs = append(outputs, name)
+				return nil
+			}
+			fs.Func("output", "", addOutput)
+			fs.Func("o", "", addOutput)
+			theme := fs.String("theme", "light", "")
+			columns := fs.String("columns", "8", "")
+			if err := parseFlags(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if *theme != tt.theme {
+				t.Errorf("theme = %q, want %q", *theme, tt.theme)
+			}
+			if *columns != tt.columns {
+				t.Errorf("columns = %q, want %q", *columns, tt.columns)
+			}
+			if !slices.Equal(outputs, tt.outputs) {
+				t.Errorf("outputs = %q, want %q", outputs, tt.outputs)
+			}
+		})
+	}
+}
+
+// writeConfig writes a config file holding content to path.
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// runDiff compares two scenario files, or one file against the generated
+// taxonomy, and can render the changed panels before and after.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	output := fs.String("output", "", "also render the changed panels, before and after side by side, to this PNG")
+	themeName := fs.String("theme", "light", "colour theme of the --output sheet: light, dark, or a YAML theme file")
+	colors := addColorFlags(fs)
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	if err := colors.apply(&th); err != nil {
+		return err
+	}
+
+	var old, new []interactions.Scenario
+	switch fs.NArg() {
+	case 1:
+		old = generateScenarios(*genOpts)
+		if new, err = loadScenarioFile(fs.Arg(0)); err != nil {
+			return err
+		}
+	case 2:
+		if old, err = loadScenarioFile(fs.Arg(0)); err != nil {
+			return err
+		}
+		if new, err = loadScenarioFile(fs.Arg(1)); err != nil {
+			return err
+		}
+	default:
+		return usageErrorf("diff needs a scenario file to compare with the generated scenarios, or an old and a new file")
+	}
+
+	changes := interactions.DiffScenarios(old, new)
+	counts := map[interactions.ChangeKind]int{}
+	var sheet []interactions.Scenario
+	for _, c := range changes {
+		counts[c.Kind]++
+		switch c.Kind {
+		case interactions.Added:
+			fmt.Printf("+ %s\n", scenarioLabel(*c.New))
+		case interactions.Removed:
+			fmt.Printf("- %s\n", scenarioLabel(*c.Old))
+		case interactions.Changed:
+			fmt.Printf("~ %s (%s)\n", scenarioLabel(*c.New), strings.Join(c.Fields, ", "))
+			before, after := *c.Old, *c.New
+			before.Title = "Before: " + before.Title
+			after.Title = "After: " + after.Title
+			sheet = append(sheet, before, after)
+		}
+	}
+	fmt.Printf("%d added, %d removed, %d changed\n", counts[interactions.Added], counts[interactions.Removed], counts[interactions.Changed])
+
+	if *output != "" {
+		if len(sheet) == 0 {
+			return usageErrorf("no changed scenarios to render")
+		}
+		format, err := formatNamed("", *output)
+		if err != nil {
+			return err
+		}
+		return renderAllScenarios([]outputFile{{name: *output, format: format, sink: sinkFor(*output, false)}}, sheet, gridSettings{columns: 2, themeName: *themeName, theme: th, quality: defaultQuality, legend: "off"})
+	}
+	return nil
+}
+
+// scenarioLabel names a scenario by its code and title.
+func scenarioLabel(s interactions.Scenario) string {
+	if s.Code == "" {
+		return s.Title
+	}
+	return s.Code + " " + s.Title
+}
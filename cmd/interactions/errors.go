@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// errorKind classifies a failure by what the user has to do about it, and
+// selects the process exit code, so scripts can tell a bad command line
+// from a missing file or a broken scenario.
+type errorKind int
+
+const (
+	// internalError is anything unexpected; exit code 1.
+	internalError errorKind = iota
+	// usageError is a bad command line: unknown flags, values or
+	// subcommands; exit code 2, as the flag package uses.
+	usageError
+	// ioError is a file that cannot be read or written; exit code 3.
+	ioError
+	// validationError is input that was read but is not valid, such as a
+	// malformed scenario file; exit code 4.
+	validationError
+)
+
+// exitCode is the process exit code for errors of kind k.
+func (k errorKind) exitCode() int {
+	return int(k) + 1
+}
+
+// cliError attaches a kind to an error.
+type cliError struct {
+	kind errorKind
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+
+func (e *cliError) Unwrap() error { return e.err }
+
+// usageErrorf formats a usage error.
+func usageErrorf(format string, args ...any) error {
+	return &cliError{usageError, fmt.Errorf(format, args...)}
+}
+
+// withKind marks err as being of kind, or returns nil if err is nil.
+func withKind(kind errorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &cliError{kind, err}
+}
+
+// scenarioFileError classifies an error from reading a scenario file: a
+// file that can be read but not parsed is a validation error.
+func scenarioFileError(err error) error {
+	if err == nil || kindOf(err) == ioError {
+		return err
+	}
+	return withKind(validationError, err)
+}
+
+// kindOf returns the kind of err. Errors from the file system are I/O
+// errors even when nothing marked them.
+func kindOf(err error) errorKind {
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.kind
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return ioError
+	}
+	return internalError
+}
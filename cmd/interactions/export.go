@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// runExport writes the scenarios in another format, named by the first
+// argument.
+func runExport(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("export needs a format: csv or markdown")
+	}
+	switch args[0] {
+	case "csv":
+		return runExportCSV(args[1:])
+	case "markdown":
+		return runExportMarkdown(args[1:])
+	default:
+		return usageErrorf("unknown export format %q (want csv or markdown)", args[0])
+	}
+}
+
+func runExportCSV(args []string) error {
+	fs := flag.NewFlagSet("export csv", flag.ContinueOnError)
+	output := fs.String("output", stdoutName, "path to write the CSV to, or - for standard output")
+	fs.StringVar(output, "o", stdoutName, "shorthand for --output")
+	force := fs.Bool("force", false, "rewrite output files even when they already hold what would be written, instead of leaving them with their modification times")
+	scenariosFile := fs.String("scenarios", "", "export the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	query := fs.String("query", "", `export only the scenarios matching this query, e.g. "ab=mutualism and c!=none"`)
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+
+	scenarios, err := loadScenarios(*scenariosFile, *genOpts, vars)
+	if err != nil {
+		return err
+	}
+	matches, err := matchingScenarios(scenarios, *query)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, *force, func(w io.Writer) error {
+		return writeCSV(w, scenarios, matches)
+	})
+}
+
+// writeCSV writes one row for each of the scenarios at the indexes in
+// matches, numbered as in the list output, with a column for the A-B
+// pattern, one for the pattern of each external actor, then the timing
+// dimensions and the size of the panel.
+func writeCSV(w io.Writer, scenarios []interactions.Scenario, matches []int) error {
+	externals := externalDimensions(scenarios)
+	header := append(append([]string{"index", "code", "title", "ab"}, externals...), "time", "type", "nodes", "edges")
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, n := range matches {
+		s := scenarios[n]
+		row := []string{strconv.Itoa(n + 1), s.Code, s.Title, s.Dimensions["ab"]}
+		for _, name := range externals {
+			row = append(row, s.Dimensions[name])
+		}
+		row = append(row, s.Dimensions["time"], s.Dimensions["type"], strconv.Itoa(len(s.Nodes)), strconv.Itoa(len(s.Edges)))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// externalDimensions returns the dimensions that hold the pattern of an
+// external actor, c, d and so on: those named by a single letter other
+// than a and b.
+func externalDimensions(scenarios []interactions.Scenario) []string {
+	names := map[string]bool{}
+	for _, s := range scenarios {
+		for name := range s.Dimensions {
+			if len(name) == 1 && name != "a" && name != "b" {
+				names[name] = true
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+func runExportMarkdown(args []string) error {
+	fs := flag.NewFlagSet("export markdown", flag.ContinueOnError)
+	output := fs.String("output", "catalog.md", "path to write the catalog to")
+	fs.StringVar(output, "o", "catalog.md", "shorthand for --output")
+	imagesDir := fs.String("images-dir", "images", "directory to write a PNG of each panel to, created if need be")
+	force := fs.Bool("force", false, "rewrite output files even when they already hold what would be written, instead of leaving them with their modification times")
+	nameTemplate := fs.String("name-template", defaultNameTemplate, "Go template naming each panel's PNG from its scenario's .Code, .N, .Title and .Hash, a short hash of the scenario")
+	themeName := fs.String("theme", "light", "colour theme of the panels: light, dark, or a YAML theme file")
+	colors := addColorFlags(fs)
+	scenariosFile := fs.String("scenarios", "", "export the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	query := fs.String("query", "", `export only the scenarios matching this query, e.g. "ab=mutualism and c!=none"`)
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *output == stdoutName {
+		return usageErrorf("export markdown writes images beside the catalog, so needs a file to write it to")
+	}
+	if isArchive(*imagesDir) {
+		return usageErrorf("--images-dir must be a directory the catalog can link into, not an archive")
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	naming, err := parseNameTemplate(*nameTemplate)
+	if err != nil {
+		return err
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	if err := colors.apply(&th); err != nil {
+		return err
+	}
+
+	scenarios, err := loadScenarios(*scenariosFile, *genOpts, vars)
+	if err != nil {
+		return err
+	}
+	matches, err := matchingScenarios(scenarios, *query)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(matches))
+	named := map[string]int{}
+	for i, n := range matches {
+		if names[i], err = panelFileName(naming, scenarios[n], n+1, ".png"); err != nil {
+			return err
+		}
+		if other, ok := named[names[i]]; ok {
+			return usageErrorf("--name-template gives scenarios %d and %d the same name %s", other, n+1, names[i])
+		}
+		named[names[i]] = n + 1
+	}
+
+	dest, err := dirSink(*imagesDir, *force)
+	if err != nil {
+		return err
+	}
+	// the catalog links to the images relative to where it is
+	rel, err := filepath.Rel(filepath.Dir(*output), *imagesDir)
+	if err != nil {
+		rel, err = filepath.Abs(*imagesDir)
+		if err != nil {
+			return err
+		}
+	}
+	images := make([]string, len(matches))
+	unchanged := 0
+	for i, n := range matches {
+		switch err := writePanelPNG(outputFile{name: names[i], format: imageFormats["png"], sink: dest}, scenarios[n], th); {
+		case errors.Is(err, errUnchanged):
+			unchanged++
+		case err != nil:
+			return err
+		}
+		images[i] = path.Join(filepath.ToSlash(rel), names[i])
+	}
+	switch unchanged {
+	case 0:
+		log.Printf("Generated: %d panels in %s", len(matches), *imagesDir)
+	case len(matches):
+		log.Printf("Unchanged: %d panels in %s", len(matches), *imagesDir)
+	default:
+		log.Printf("Generated: %d panels in %s, %d of them unchanged", len(matches), *imagesDir, unchanged)
+	}
+
+	return writeOutput(*output, *force, func(w io.Writer) error {
+		return writeMarkdownCatalog(w, scenarios, matches, images)
+	})
+}
+
+// writePanelPNG draws s on its own into the PNG file out, or returns
+// errUnchanged if it held that image already.
+func writePanelPNG(out outputFile, s interactions.Scenario, th interactions.Theme) error {
+	return writeEncoded(out, func(w io.Writer) error {
+		return interactions.EncodePNG(w, interactions.DrawPanel(s, th))
+	}, false)
+}
+
+// writeMarkdownCatalog writes a Markdown table of the scenarios at the
+// indexes in matches, each with its panel from the image of the same index
+// in images, its code, title and what it shows in words.
+func writeMarkdownCatalog(w io.Writer, scenarios []interactions.Scenario, matches []int, images []string) error {
+	var b strings.Builder
+	b.WriteString("# Interaction patterns\n\n")
+	fmt.Fprintf(&b, "%d scenarios, generated by [interactions](https://github.com/arran4/interactions).\n\n", len(matches))
+	b.WriteString("| # | Panel | Code | Title | Description |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for i, n := range matches {
+		s := scenarios[n]
+		title := markdownCell(s.Title)
+		if s.Subtitle != "" {
+			title += "<br>" + markdownCell(s.Subtitle)
+		}
+		description := interactions.Describe(s)
+		if s.Description != "" {
+			description = s.Description + " " + description
+		}
+		code := ""
+		if s.Code != "" {
+			code = "`" + s.Code + "`"
+		}
+		fmt.Fprintf(&b, "| %d | ![%s](%s) | %s | %s | %s |\n", n+1, markdownCell(s.Title), images[i], code, title, markdownCell(description))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes text for a cell of a Markdown table, which must stay
+// on one line and cannot contain a bare |.
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.NewReplacer("|", "\\|", "[", "\\[", "]", "\\]").Replace(text)
+}
+
+// writeOutput calls write with the file filename, or standard output when
+// filename is -, through its sink, so a file already holding what would be
+// written is left as it is unless force is set.
+func writeOutput(filename string, force bool, write func(io.Writer) error) error {
+	err := writeEncoded(outputFile{name: filename, sink: sinkFor(filename, force)}, write, false)
+	if filename == stdoutName {
+		return err
+	}
+	return logGenerated(filename, err)
+}
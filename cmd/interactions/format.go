@@ -0,0 +1,78 @@
+package main
+
+import (
+	"image"
+	"image/jpeg"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/arran4/interactions"
+	"github.com/gen2brain/webp"
+)
+
+// imageFormat is a file format render can write.
+type imageFormat struct {
+	name string
+	ext  string
+	// lossy formats take a quality from 1 to 100
+	lossy bool
+	// maxSide is the most pixels across or down an image of the format
+	// can be, or 0 for no limit
+	maxSide int
+	encode  func(w io.Writer, img image.Image, quality int) error
+}
+
+// imageFormats are the formats render can write, by name. Only PNG keeps
+// the metadata inspect reads, and only PNG can be written --tiled.
+var imageFormats = map[string]imageFormat{
+	"png": {name: "png", ext: ".png", encode: func(w io.Writer, img image.Image, _ int) error {
+		return interactions.EncodePNG(w, img)
+	}},
+	"jpeg": {name: "jpeg", ext: ".jpg", lossy: true, maxSide: 65535, encode: func(w io.Writer, img image.Image, quality int) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}},
+	"webp": {name: "webp", ext: ".webp", lossy: true, maxSide: 16383, encode: func(w io.Writer, img image.Image, quality int) error {
+		return webp.Encode(w, img, webp.Options{Quality: quality, Method: webp.DefaultMethod})
+	}},
+}
+
+// checkSize fails when an image of bounds b, to be written to the file
+// name, is larger than the format can hold, so a render too large for it
+// is refused before it is drawn.
+func (f imageFormat) checkSize(name string, b image.Rectangle) error {
+	if f.maxSide == 0 || max(b.Dx(), b.Dy()) <= f.maxSide {
+		return nil
+	}
+	return usageErrorf("%s would be %dx%d pixels, but %s images can be at most %d pixels on a side; change --columns, or use --max-rows or --split to write smaller images", name, b.Dx(), b.Dy(), f.name, f.maxSide)
+}
+
+// defaultQuality is the --quality of lossy formats, high enough to keep
+// the pixel font legible.
+const defaultQuality = 90
+
+// formatNamed looks up the --format flag, or with an empty name picks the
+// format from the output file's extension, defaulting to PNG. SVG and PDF
+// are refused with the reason: the title and legend of a grid are only
+// ever drawn as pixels, so only single panels can be SVG.
+func formatNamed(name, filename string) (imageFormat, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if name == "svg" || name == "pdf" || name == "" && (ext == ".svg" || ext == ".pdf") {
+		return imageFormat{}, usageErrorf("cannot write %s as svg or pdf: render draws a grid's title and legend only as pixels, so writes png, jpeg or webp; single panels can be had as svg from serve's /scenario/{code}.svg or interactions.WritePanelSVG, and there is no pdf output", filename)
+	}
+	if name == "" {
+		switch ext {
+		case ".jpg", ".jpeg":
+			name = "jpeg"
+		case ".webp":
+			name = "webp"
+		default:
+			name = "png"
+		}
+	}
+	f, ok := imageFormats[name]
+	if !ok {
+		return imageFormat{}, usageErrorf("unknown format %q (want png, jpeg or webp)", name)
+	}
+	return f, nil
+}
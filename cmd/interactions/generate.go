@@ -0,0 +1,227 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arran4/interactions"
+	"github.com/arran4/interactions/internal/cliflags"
+)
+
+// ----------------------------------------------------------------------
+// Scenario generation: all combinations
+// ----------------------------------------------------------------------
+
+// generateOptions switches optional dimensions of the generated taxonomy on.
+type generateOptions struct {
+	interactions.GeneratorOptions
+	// Generator is the name of the interactions.Generator to run.
+	Generator string
+	// Limit is the most scenarios the built-in taxonomy may generate, or 0
+	// for no limit.
+	Limit int
+	// DimensionsFile is a scenario file declaring dimensions to add to the
+	// taxonomy, which validate reads into Custom.
+	DimensionsFile string
+	// TitleTemplate and SubtitleTemplate retitle the generated scenarios;
+	// see titleTemplates.
+	TitleTemplate, SubtitleTemplate string
+	// generated are the scenarios validate has already generated to
+	// retitle them, which generateScenarios returns again
+	generated []interactions.Scenario
+}
+
+// defaultLimit is the most scenarios generated without --limit.
+const defaultLimit = 10000
+
+// validate checks the options, first reading the dimensions of
+// --dimensions.
+func (o *generateOptions) validate() error {
+	if _, err := interactions.GeneratorNamed(o.Generator); err != nil {
+		return withKind(usageError, err)
+	}
+	if o.DimensionsFile != "" && o.Custom == nil {
+		dims, err := interactions.LoadDimensionsFile(o.DimensionsFile)
+		if err != nil {
+			return scenarioFileError(err)
+		}
+		o.Custom = dims
+	}
+	if o.Limit < 0 {
+		return usageErrorf("limit must be at least 0")
+	}
+	n, err := interactions.CountScenarios(interactions.FromOptions(o.GeneratorOptions))
+	if err != nil {
+		return withKind(usageError, err)
+	}
+	if o.Generator == interactions.TaxonomyName && o.Limit > 0 && n.Cmp(big.NewInt(int64(o.Limit))) > 0 {
+		return usageErrorf("these options generate %s scenarios, more than --limit %d; raise --limit, or see how many with interactions generate --estimate", n, o.Limit)
+	}
+	titles, err := parseTitleTemplates(o.TitleTemplate, o.SubtitleTemplate)
+	if err != nil {
+		return err
+	}
+	if titles != (titleTemplates{}) && o.generated == nil {
+		scenarios := generateScenarios(*o)
+		if err := titles.apply(scenarios); err != nil {
+			return err
+		}
+		o.generated = scenarios
+	}
+	return nil
+}
+
+func addGenerateFlags(fs *flag.FlagSet) *generateOptions {
+	opts := &generateOptions{}
+	cliflags.AddGeneratorFlags(fs, &opts.GeneratorOptions)
+	fs.IntVar(&opts.Limit, "limit", defaultLimit, "fail rather than generate more scenarios than this, or 0 for no limit")
+	fs.StringVar(&opts.Generator, "generator", interactions.TaxonomyName, "generator of the scenarios: "+strings.Join(interactions.GeneratorNames(), ", "))
+	fs.StringVar(&opts.DimensionsFile, "dimensions", "", "add the dimensions declared in this scenario file to the taxonomy, a variant of every scenario for each of their values")
+	fs.StringVar(&opts.TitleTemplate, "title-template", "", `Go template retitling each generated scenario from its .Title, .Code and dimensions, e.g. "{{.AB}} | {{.Time}}"`)
+	fs.StringVar(&opts.SubtitleTemplate, "subtitle-template", "", "Go template of each generated scenario's subtitle, filled in as --title-template is")
+	return opts
+}
+
+// generateScenarios returns the scenarios of the --generator, which
+// validate has checked is registered, retitled by any title templates.
+func generateScenarios(opts generateOptions) []interactions.Scenario {
+	if opts.generated != nil {
+		return slices.Clone(opts.generated)
+	}
+	g, err := interactions.GeneratorNamed(opts.Generator)
+	if err != nil {
+		panic(err)
+	}
+	return g(opts.GeneratorOptions)
+}
+
+// runGenerate writes the generated taxonomy as a scenario file, to edit
+// or to read back with --scenarios, or with --estimate prints how many
+// scenarios the options would generate without generating them.
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	output := fs.String("output", stdoutName, "path to write the scenario file to, or - for standard output")
+	fs.StringVar(output, "o", stdoutName, "shorthand for --output")
+	force := fs.Bool("force", false, "rewrite output files even when they already hold what would be written, instead of leaving them with their modification times")
+	estimate := fs.Bool("estimate", false, "print how many scenarios the options would generate, whatever --limit is, instead of generating them")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *estimate {
+		genOpts.Limit = 0
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	if *estimate && genOpts.Generator != interactions.TaxonomyName {
+		// other generators can only be counted by running them
+		fmt.Println(len(generateScenarios(*genOpts)))
+		return nil
+	}
+	if *estimate {
+		n, err := interactions.CountScenarios(interactions.FromOptions(genOpts.GeneratorOptions))
+		if err != nil {
+			return withKind(usageError, err)
+		}
+		fmt.Println(n)
+		return nil
+	}
+	scenarios := generateScenarios(*genOpts)
+	return writeOutput(*output, *force, func(w io.Writer) error {
+		return interactions.WriteScenarioFile(w, scenarios)
+	})
+}
+
+// loadScenarios returns the scenarios in file, filling in the variables of
+// a template from vars, or the generated taxonomy when file is empty.
+func loadScenarios(file string, opts generateOptions, vars *templateFlags) ([]interactions.Scenario, error) {
+	if file != "" {
+		values, err := vars.values()
+		if err != nil {
+			return nil, err
+		}
+		if values != nil {
+			return loadScenarioFiles(file, func(fsys fs.FS, name string) ([]interactions.Scenario, error) {
+				return interactions.LoadScenarioTemplateFS(fsys, name, values)
+			})
+		}
+		return loadScenarioFile(file)
+	}
+	return generateScenarios(opts), nil
+}
+
+// loadCatalog returns the generated scenarios as a set named after their
+// --generator, such as "taxonomy", and the named sets of file, when there
+// is one, filling in the variables of a template from vars.
+func loadCatalog(file string, opts generateOptions, vars *templateFlags) (*interactions.Catalog, error) {
+	catalog := interactions.NewCatalog()
+	catalog.Add(opts.Generator, generateScenarios(opts))
+	if file != "" {
+		values, err := vars.values()
+		if err != nil {
+			return nil, err
+		}
+		err = scenarioFiles(file, func(fsys fs.FS, name, _ string) error {
+			return catalog.AddFS(fsys, name, values)
+		})
+		if err != nil {
+			return nil, scenarioFileError(err)
+		}
+	}
+	return catalog, nil
+}
+
+// loadSet returns the scenarios of the set named by --set; see
+// loadCatalog.
+func loadSet(file, set string, opts generateOptions, vars *templateFlags) ([]interactions.Scenario, error) {
+	catalog, err := loadCatalog(file, opts, vars)
+	if err != nil {
+		return nil, err
+	}
+	scenarios, err := catalog.Set(set)
+	if err != nil {
+		return nil, withKind(usageError, err)
+	}
+	return scenarios, nil
+}
+
+// loadScenarioFile reads a scenario file, or those of a bundle; see
+// scenarioFiles.
+func loadScenarioFile(file string) ([]interactions.Scenario, error) {
+	return loadScenarioFiles(file, interactions.LoadScenarioFileFS)
+}
+
+// loadDOTFile reads the digraphs in a Graphviz DOT file as scenarios.
+func loadDOTFile(file string) ([]interactions.Scenario, error) {
+	scenarios, err := interactions.LoadDOTFile(file)
+	return scenarios, scenarioFileError(err)
+}
+
+// loadMatrix reads the scenario of an adjacency matrix given to --matrix:
+// the path of a CSV file, or the matrix itself.
+func loadMatrix(matrix string) ([]interactions.Scenario, error) {
+	var s interactions.Scenario
+	var err error
+	if isMatrixFile(matrix) {
+		s, err = interactions.LoadAdjacencyFile(matrix)
+	} else {
+		s, err = interactions.ParseAdjacency(matrix)
+	}
+	if err != nil {
+		return nil, scenarioFileError(err)
+	}
+	return []interactions.Scenario{s}, nil
+}
+
+// isMatrixFile reports whether --matrix names a CSV file rather than
+// giving the matrix inline.
+func isMatrixFile(matrix string) bool {
+	return strings.EqualFold(filepath.Ext(matrix), ".csv")
+}
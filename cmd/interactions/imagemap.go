@@ -0,0 +1,52 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"image"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// defaultMapHref is the --map-href that links each panel to an anchor
+// named by its code.
+const defaultMapHref = "#{code}"
+
+// imageMapName is the HTML snippet of the image file: interactions.png has
+// interactions.map.html.
+func imageMapName(imageFile string) string {
+	return strings.TrimSuffix(imageFile, filepath.Ext(imageFile)) + ".map.html"
+}
+
+// mapHref fills in the --map-href pattern for s, the n'th scenario in the
+// list output: {code} becomes its code, or n when it has none, and {n}
+// becomes n.
+func mapHref(pattern string, s interactions.Scenario, n int) string {
+	return strings.NewReplacer("{code}", cmp.Or(s.Code, strconv.Itoa(n)), "{n}", strconv.Itoa(n)).Replace(pattern)
+}
+
+// writeImageMap writes an HTML snippet beside imageFile to dest: an <img>
+// of it and a <map> whose areas, one at each of rects, link the panels of
+// scenarios, with the given list numbers, to hrefPattern.
+func writeImageMap(dest sink, imageFile string, scenarios []interactions.Scenario, numbers []int, rects []image.Rectangle, hrefPattern string) error {
+	base := filepath.Base(imageFile)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<img src=\"%s\" usemap=\"#%s\" alt=\"Interaction patterns\">\n", html.EscapeString(base), html.EscapeString(name))
+	fmt.Fprintf(&b, "<map name=\"%s\">\n", html.EscapeString(name))
+	for i, s := range scenarios {
+		r := rects[i]
+		title := html.EscapeString(s.Title)
+		fmt.Fprintf(&b, "  <area shape=\"rect\" coords=\"%d,%d,%d,%d\" href=\"%s\" alt=\"%s\" title=\"%s\">\n",
+			r.Min.X, r.Min.Y, r.Max.X, r.Max.Y, html.EscapeString(mapHref(hrefPattern, s, numbers[i])), title, title)
+	}
+	b.WriteString("</map>\n")
+
+	file := imageMapName(imageFile)
+	return logGenerated(file, writeFile(dest, file, []byte(b.String())))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// version is the release version, set by the release build with
+// -ldflags "-X main.version=...".
+var version = ""
+
+// toolVersion returns the version of this build: the release version, the
+// module version for go install builds, or "devel".
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "devel"
+}
+
+// PNG text keywords written by render. Software is the standard PNG
+// keyword; the others are specific to this tool.
+const (
+	metaSoftware = "Software"
+	metaCodes    = "interactions:codes"
+	metaColumns  = "interactions:columns"
+	metaTheme    = "interactions:theme"
+	metaPage     = "interactions:page"
+	metaAxes     = "interactions:axes"
+	metaSections = "interactions:sections"
+)
+
+// renderMetadata describes a rendered grid for its PNG text chunks.
+// Scenarios without a code are listed by their position.
+func renderMetadata(scenarios []interactions.Scenario, columns int, themeName string) []interactions.TextChunk {
+	codes := make([]string, len(scenarios))
+	for i, s := range scenarios {
+		codes[i] = s.Code
+		if codes[i] == "" {
+			codes[i] = "#" + strconv.Itoa(i+1)
+		}
+	}
+	return []interactions.TextChunk{
+		{Keyword: metaSoftware, Text: "interactions " + toolVersion()},
+		{Keyword: metaCodes, Text: strings.Join(codes, ",")},
+		{Keyword: metaColumns, Text: strconv.Itoa(columns)},
+		{Keyword: metaTheme, Text: themeName},
+	}
+}
+
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("inspect needs at least one PNG file")
+	}
+
+	for i, file := range fs.Args() {
+		chunks, err := readPNGText(file)
+		if err != nil {
+			return err
+		}
+		if fs.NArg() > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(file + ":")
+		}
+		if len(chunks) == 0 {
+			fmt.Println("no metadata")
+		}
+		for _, c := range chunks {
+			fmt.Printf("%s: %s\n", c.Keyword, c.Text)
+		}
+	}
+	return nil
+}
+
+func readPNGText(file string) ([]interactions.TextChunk, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	chunks, err := interactions.ReadPNGText(f)
+	if err != nil {
+		return nil, withKind(validationError, fmt.Errorf("%s: %w", file, err))
+	}
+	return chunks, nil
+}
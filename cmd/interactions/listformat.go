@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/arran4/interactions"
+)
+
+// listFormats are the values of list --format.
+var listFormats = []string{"text", "json", "csv", "tsv"}
+
+// listEntry is a scenario as list --format json writes it.
+type listEntry struct {
+	Index      int               `json:"index"`
+	Code       string            `json:"code,omitempty"`
+	Title      string            `json:"title"`
+	Subtitle   string            `json:"subtitle,omitempty"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+}
+
+// writeList writes the scenarios at the indexes in matches in format, one
+// of listFormats, numbered by their place in scenarios. long adds the
+// subtitles to the text format; the others always have them.
+func writeList(w io.Writer, format string, scenarios []interactions.Scenario, matches []int, long bool) error {
+	switch format {
+	case "json":
+		entries := make([]listEntry, len(matches))
+		for i, n := range matches {
+			s := scenarios[n]
+			entries[i] = listEntry{Index: n + 1, Code: s.Code, Title: s.Title, Subtitle: s.Subtitle, Dimensions: s.Dimensions}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv", "tsv":
+		return writeListTable(w, format == "tsv", scenarios, matches)
+	}
+
+	codeWidth := 0
+	for _, s := range scenarios {
+		codeWidth = max(codeWidth, len(s.Code))
+	}
+	for _, n := range matches {
+		s := scenarios[n]
+		title := s.Title
+		if codeWidth > 0 {
+			title = fmt.Sprintf("%-*s  %s", codeWidth, s.Code, s.Title)
+		}
+		if long {
+			title += " — " + s.Subtitle
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", interactions.ListNumber(n+1), title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeListTable writes list --format csv, or tsv when tabs is true: a
+// header, then a row for each scenario with its index, code, title and
+// subtitle and a column for every dimension any of them records, in order
+// of name.
+func writeListTable(w io.Writer, tabs bool, scenarios []interactions.Scenario, matches []int) error {
+	names := map[string]bool{}
+	for _, n := range matches {
+		for name := range scenarios[n].Dimensions {
+			names[name] = true
+		}
+	}
+	dims := slices.Sorted(maps.Keys(names))
+
+	cw := csv.NewWriter(w)
+	if tabs {
+		cw.Comma = '\t'
+	}
+	if err := cw.Write(append([]string{"index", "code", "title", "subtitle"}, dims...)); err != nil {
+		return err
+	}
+	for _, n := range matches {
+		s := scenarios[n]
+		row := []string{strconv.Itoa(n + 1), s.Code, s.Title, s.Subtitle}
+		for _, name := range dims {
+			row = append(row, s.Dimensions[name])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
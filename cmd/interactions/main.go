@@ -0,0 +1,1067 @@
+// interactions: generate a grid of all basic interaction patterns
+// between A and B, with external influences from C and D.
+// Project home: https://github.com/arran4/interactions
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/arran4/interactions"
+)
+
+func run(args []string) (err error) {
+	global := flag.NewFlagSet("interactions", flag.ContinueOnError)
+	global.Usage = printGlobalUsage
+	configFile := global.String("config", "", "read flag defaults from this file instead of the user and project config files")
+	cpuProfile := global.String("cpuprofile", "", "write a CPU profile of the command to this file, for go tool pprof")
+	memProfile := global.String("memprofile", "", "write a memory profile to this file once the command is done, for go tool pprof")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withKind(usageError, err)
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		printGlobalUsage()
+		return nil
+	}
+	if err := loadConfig(*configFile); err != nil {
+		return err
+	}
+	stop, err := startProfiles(*cpuProfile, *memProfile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if perr := stop(); err == nil {
+			err = perr
+		}
+	}()
+
+	switch args[0] {
+	case "render":
+		return runRender(args[1:])
+	case "list":
+		return runList(args[1:])
+	case "serve":
+		return runServe(args[1:])
+	case "browse":
+		return runBrowse(args[1:])
+	case "show":
+		return runShow(args[1:])
+	case "generate":
+		return runGenerate(args[1:])
+	case "validate":
+		return runValidate(args[1:])
+	case "inspect":
+		return runInspect(args[1:])
+	case "diff":
+		return runDiff(args[1:])
+	case "stats":
+		return runStats(args[1:])
+	case "export":
+		return runExport(args[1:])
+	case "classes":
+		return runClasses(args[1:])
+	case "bench":
+		return runBench(args[1:])
+	case "help", "--help", "-h":
+		printGlobalUsage()
+		return nil
+	default:
+		printGlobalUsage()
+		return usageErrorf("unknown subcommand %q", args[0])
+	}
+}
+
+// The smallest --panel-width and --panel-height that leave room for a
+// title and two rows of nodes.
+const (
+	minPanelWidth  = 160
+	minPanelHeight = 180
+)
+
+func runRender(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	var outputNames []string
+	addOutput := func(name string) error {
+		outputNames = append(outputNames, name)
+		return nil
+	}
+	fs.Func("output", "path to write the generated image, or - for standard output; repeat to encode the same render in several formats, e.g. --output out.png --output out.webp (default interactions.png)", addOutput)
+	fs.Func("o", "shorthand for --output", addOutput)
+	formatName := fs.String("format", "", "image format: png, jpeg or webp (default from the --output extension, else png)")
+	quality := fs.Int("quality", defaultQuality, "quality of jpeg and webp output, from 1 to 100")
+	columns := fs.Int("columns", 8, "number of columns in the grid (use 3 for README-friendly long form)")
+	panelWidth := fs.Int("panel-width", 360, "width of each panel in pixels; the diagram scales to fit")
+	panelHeight := fs.Int("panel-height", 220, "height of each panel in pixels, before room for any description; the diagram scales to fit")
+	margin := fs.Int("margin", 20, "space between panels, and around the image, in pixels")
+	mutualism := fs.String("mutualism", "arrows", "glyph of mutualism: arrows, shown in the legend as two opposing arrows, double-headed for a single double-headed arrow there too, or parallel for two parallel lines without heads")
+	strokeWidth := fs.Int("stroke-width", 1, "width in pixels of edges, node outlines and borders; thicker lines survive scaling the image down")
+	themeName := fs.String("theme", "light", "colour theme: light, dark, or a YAML theme file")
+	colors := addColorFlags(fs)
+	scenariosFile := fs.String("scenarios", "", "render the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	set := fs.String("set", "", "render the scenarios of this named set of the --scenarios file, or of the --generator by its name, e.g. "+interactions.TaxonomyName)
+	dotFile := fs.String("from-dot", "", "render the digraphs in this Graphviz DOT file instead of the generated taxonomy")
+	matrix := fs.String("matrix", "", `render the adjacency matrix in this CSV file, or given inline as e.g. "0,1,0;0,0,1;1,0,0", instead of the generated taxonomy`)
+	watch := fs.Bool("watch", false, "re-render whenever the --scenarios, --from-dot or --matrix file changes")
+	sel := addSelectionFlags(fs, "render")
+	tiled := fs.Bool("tiled", false, "draw and encode the grid one row of panels at a time to bound memory use")
+	legend := fs.String("legend", "on", "legend placement: on, off, or separate to write it to legend.png beside the output")
+	captions := fs.String("captions", "title,code,index", "comma-separated panel captions: title, title-below, code, index, or none")
+	overflow := fs.String("overflow", "draw", "text too wide for its place on a panel, which is always warned of: draw it in full, or ellipsis to cut it short")
+	split := fs.Bool("split", false, "write each scenario as an image of its own, with an index.json listing them, into the --output directory, or a .zip, .tar.gz or .tgz archive of them")
+	nameTemplate := fs.String("name-template", defaultNameTemplate, "Go template naming each image of a --split render from its scenario's .Code, .N, .Title and .Hash, a short hash of the scenario")
+	maxRows := fs.Int("max-rows", 0, "split the output into numbered pages of at most this many rows of panels (0 for one image)")
+	axes := fs.String("axes", "", "lay the grid out by two dimensions, rows then columns, e.g. ab,time")
+	layout := fs.String("layout", "grid", "panel layout: grid, or poster for a single column with a heading band for each section")
+	sections := fs.String("sections", "ab", "dimension whose changes start a new section of a --layout poster")
+	nodeLayout := fs.String("node-layout", "layered", "how the nodes of each panel are placed: "+strings.Join(interactions.LayoutNames(), ", "))
+	layoutSeed := fs.Uint64("layout-seed", 1, "seed of the starting places of a --node-layout force, so the same seed gives the same layout")
+	bundleEdges := fs.Bool("bundle-edges", false, "draw the edges from an actor to several others as one trunk that forks near them")
+	colorBySource := fs.Bool("color-by-source", false, "colour the edges from each actor other than A and B differently, with a legend row")
+	altTextFlag := fs.Bool("alt-text", false, "write a JSON sidecar describing each panel in words beside each image, e.g. interactions.alt.json")
+	progress := fs.Bool("progress", true, "show a progress bar on standard error while drawing, when it is a terminal")
+	imageMap := fs.Bool("image-map", false, "write an HTML <img> and <map> making each panel a link beside each image, e.g. interactions.map.html")
+	mapHref := fs.String("map-href", defaultMapHref, "link of each panel in the --image-map: {code} is the scenario's code and {n} its list number")
+	debugLayout := fs.Bool("debug-layout", false, "draw each panel's rows, padding, node bounding boxes and text baselines over it in translucent colours, for diagnosing layout and overflow")
+	cacheDir := fs.String("cache", "", "keep each panel drawn in this directory, keyed by a hash of its scenario and the options, and take unchanged panels from it instead of drawing them again")
+	strict := fs.Bool("strict", false, "fail with exit status 4 if drawing gave any warnings, such as text too wide for its place or an edge through a node")
+	force := fs.Bool("force", false, "rewrite output files even when they already hold what would be written, instead of leaving them with their modification times")
+	verify := fs.Bool("verify", false, "render each image twice and fail unless both give identical bytes")
+	highlight := fs.String("highlight", "", "draw this actor, e.g. B, and its edges in the accent colour and fade everything else")
+	watermark := fs.String("watermark", "", `text drawn large and faint across the image, e.g. "DRAFT"`)
+	footer := fs.String("footer", "", "line of text added under the panels, e.g. who rendered the image")
+	logoFile := fs.String("logo", "", "PNG or JPEG image stamped into the top right corner, scaled down to at most 40 pixels high")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *columns < 1 {
+		return usageErrorf("columns must be at least 1")
+	}
+	if len(slices.DeleteFunc([]string{*scenariosFile, *dotFile, *matrix}, func(s string) bool { return s == "" })) > 1 {
+		return usageErrorf("only one of --scenarios, --from-dot and --matrix can be used")
+	}
+	if *set != "" && (*dotFile != "" || *matrix != "") {
+		return usageErrorf("--set picks a set of the --scenarios file, and cannot be used with --from-dot or --matrix")
+	}
+	var matrixFile string
+	if isMatrixFile(*matrix) {
+		matrixFile = *matrix
+	}
+	sourceFile := cmp.Or(*scenariosFile, *dotFile, matrixFile)
+	if *watch && sourceFile == "" {
+		return usageErrorf("--watch needs a --scenarios, --from-dot or --matrix file to watch")
+	}
+	if *legend != "on" && *legend != "off" && *legend != "separate" {
+		return usageErrorf("unknown legend placement %q (want on, off or separate)", *legend)
+	}
+	if *panelWidth < minPanelWidth || *panelHeight < minPanelHeight {
+		return usageErrorf("panels must be at least %dx%d pixels, got %dx%d", minPanelWidth, minPanelHeight, *panelWidth, *panelHeight)
+	}
+	if *margin < 0 {
+		return usageErrorf("--margin must not be negative, got %d", *margin)
+	}
+	if *strokeWidth < 1 {
+		return usageErrorf("--stroke-width must be at least 1, got %d", *strokeWidth)
+	}
+	if *maxRows < 0 {
+		return usageErrorf("--max-rows must not be negative, got %d", *maxRows)
+	}
+	if *axes != "" && (*tiled || *maxRows > 0) {
+		return usageErrorf("--axes cannot be combined with --tiled or --max-rows")
+	}
+	if *layout != "grid" && *layout != "poster" {
+		return usageErrorf("unknown layout %q (want grid or poster)", *layout)
+	}
+	if *layout == "poster" && (*axes != "" || *tiled || *maxRows > 0) {
+		return usageErrorf("--layout poster cannot be combined with --axes, --tiled or --max-rows")
+	}
+	var naming *template.Template
+	if *split {
+		var err error
+		if naming, err = parseNameTemplate(*nameTemplate); err != nil {
+			return err
+		}
+		if len(outputNames) == 0 {
+			outputNames = []string{"interactions"}
+		}
+		if len(outputNames) > 1 || outputNames[0] == stdoutName {
+			return usageErrorf("--split writes a directory or archive of images, so needs a single --output other than -")
+		}
+		if *tiled || *maxRows > 0 || *axes != "" || *layout != "grid" || *legend == "separate" || *altTextFlag || *imageMap {
+			return usageErrorf("--split cannot be combined with --tiled, --max-rows, --axes, --layout poster, --legend separate, --alt-text or --image-map")
+		}
+	}
+	if len(outputNames) == 0 {
+		outputNames = []string{"interactions.png"}
+	}
+	for i, name := range outputNames {
+		if slices.Contains(outputNames[:i], name) {
+			return usageErrorf("--output %s is given twice", name)
+		}
+	}
+	if len(outputNames) > 1 && *formatName != "" {
+		return usageErrorf("--format sets the format of a single --output; with several, each takes its format from its extension")
+	}
+	if slices.Contains(outputNames, stdoutName) && (*watch || *maxRows > 0 || *legend == "separate" || *altTextFlag || *imageMap) {
+		return usageErrorf("--output - writes a single image, so cannot be combined with --watch, --max-rows, --legend separate, --alt-text or --image-map")
+	}
+	caps, err := interactions.ParseCaptions(*captions)
+	if err != nil {
+		return withKind(usageError, err)
+	}
+	ov, ok := overflowModes[*overflow]
+	if !ok {
+		return usageErrorf("unknown overflow %q (want draw or ellipsis)", *overflow)
+	}
+	glyph, ok := mutualismGlyphs[*mutualism]
+	if !ok {
+		return usageErrorf("unknown mutualism glyph %q (want arrows, double-headed or parallel)", *mutualism)
+	}
+	placement, err := interactions.LayoutNamed(*nodeLayout)
+	if err != nil {
+		return withKind(usageError, err)
+	}
+	if force, ok := placement.(interactions.Force); ok {
+		force.Seed = *layoutSeed
+		placement = force
+	}
+	outputs := make([]outputFile, len(outputNames))
+	for i, name := range outputNames {
+		format, err := formatNamed(*formatName, name)
+		if err != nil {
+			return err
+		}
+		if *tiled && format.name != "png" {
+			return usageErrorf("--tiled only writes PNG")
+		}
+		outputs[i] = outputFile{name: name, format: format, sink: sinkFor(name, *force)}
+	}
+	if *quality < 1 || *quality > 100 {
+		return usageErrorf("--quality must be from 1 to 100, got %d", *quality)
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	if err := colors.apply(&th); err != nil {
+		return err
+	}
+	var logo image.Image
+	if *logoFile != "" {
+		if logo, err = loadLogo(*logoFile); err != nil {
+			return err
+		}
+	}
+
+	render := func() error {
+		var scenarios []interactions.Scenario
+		switch {
+		case *set != "":
+			scenarios, err = loadSet(*scenariosFile, *set, *genOpts, vars)
+		case *dotFile != "":
+			scenarios, err = loadDOTFile(*dotFile)
+		case *matrix != "":
+			scenarios, err = loadMatrix(*matrix)
+		default:
+			scenarios, err = loadScenarios(*scenariosFile, *genOpts, vars)
+		}
+		if err != nil {
+			return err
+		}
+		matches, mirrored, err := sel.apply(scenarios, genOpts.ExternalNames())
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return usageErrorf("no scenarios match %q", *sel.query)
+		}
+
+		var axisDims []string
+		if *axes != "" {
+			if axisDims, err = parseAxes(scenarios, *axes); err != nil {
+				return err
+			}
+		}
+		sectionDim := ""
+		if *layout == "poster" {
+			sectionDim = strings.ToLower(strings.TrimSpace(*sections))
+			if err := checkField(scenarios, sectionDim); err != nil {
+				return usageErrorf("--sections: %w", err)
+			}
+		}
+
+		selected, numbers := numbered(scenarios, matches)
+		var badges []string
+		if *sel.reduceSymmetry {
+			badges = make([]string, len(matches))
+			for i, n := range matches {
+				if mirrored[n] {
+					badges[i] = interactions.Translate(genOpts.Lang, "×%d (symmetric)", 2)
+				}
+			}
+		}
+		opts := []interactions.Option{
+			interactions.WithCaptions(caps),
+			interactions.WithLanguage(genOpts.Lang),
+			interactions.WithPanelSize(*panelWidth, *panelHeight),
+			interactions.WithMargin(*margin),
+			interactions.WithStrokeWidth(*strokeWidth),
+			interactions.WithMutualism(glyph),
+			interactions.WithOverflow(ov),
+			interactions.WithLayout(placement),
+			interactions.WithWatermark(*watermark),
+			interactions.WithFooter(*footer),
+			interactions.WithPanelCache(panelCache(*cacheDir)),
+		}
+		if logo != nil {
+			opts = append(opts, interactions.WithLogo(logo))
+		}
+		if *colorBySource {
+			opts = append(opts, interactions.WithEdgeColors(sourceColors(selected)))
+		}
+		if *bundleEdges {
+			opts = append(opts, interactions.WithBundledEdges())
+		}
+		if *debugLayout {
+			opts = append(opts, interactions.WithLayoutDebug())
+		}
+		if *highlight != "" {
+			if !slices.ContainsFunc(selected, func(s interactions.Scenario) bool { return slices.Contains(s.Nodes, *highlight) }) {
+				return usageErrorf("--highlight: no scenario has an actor named %q", *highlight)
+			}
+			opts = append(opts, interactions.WithHighlight(*highlight))
+		}
+		g := gridSettings{
+			columns:   *columns,
+			themeName: *themeName,
+			theme:     th,
+			quality:   *quality,
+			tiled:     *tiled,
+			legend:    *legend,
+			maxRows:   *maxRows,
+			axes:      axisDims,
+			sections:  sectionDim,
+			numbers:   numbers,
+			badges:    badges,
+			altText:   *altTextFlag,
+			imageMap:  *imageMap,
+			mapHref:   *mapHref,
+			verify:    *verify,
+			force:     *force,
+			progress:  *progress,
+			strict:    *strict,
+			naming:    naming,
+			opts:      opts,
+		}
+		if *split {
+			return renderSplit(outputs[0].name, outputs[0].format, selected, g)
+		}
+		return renderAllScenarios(outputs, selected, g)
+	}
+	if *watch {
+		// a file within a bundle changes with its archive
+		if archive, _, ok := scenarioArchive(sourceFile); ok {
+			sourceFile = archive
+		}
+		return watchFile(sourceFile, render)
+	}
+	return render()
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	longForm := fs.Bool("long", false, "print subtitles along with scenario titles")
+	scenariosFile := fs.String("scenarios", "", "list the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	set := fs.String("set", "", "list the scenarios of this named set of the --scenarios file, or of the --generator by its name, e.g. "+interactions.TaxonomyName)
+	listSets := fs.Bool("sets", false, "list the named sets, of the --scenarios file and of the --generator, instead of scenarios")
+	sel := addSelectionFlags(fs, "list")
+	sortSpec := fs.String("sort", "", "list the scenarios grouped by these comma-separated fields, e.g. time,ab, each in the order its values first appear")
+	format := fs.String("format", "text", "output format: "+strings.Join(listFormats, ", ")+"; the others give the index, code, title, subtitle and dimension values of each scenario")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains(listFormats, *format) {
+		return usageErrorf("unknown format %q (want %s)", *format, strings.Join(listFormats, ", "))
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+
+	if *listSets {
+		catalog, err := loadCatalog(*scenariosFile, *genOpts, vars)
+		if err != nil {
+			return err
+		}
+		names := catalog.Names()
+		width := 0
+		for _, name := range names {
+			width = max(width, len(name))
+		}
+		for _, name := range names {
+			scenarios, _ := catalog.Set(name)
+			fmt.Printf("%-*s  %d\n", width, name, len(scenarios))
+		}
+		return nil
+	}
+	var scenarios []interactions.Scenario
+	var err error
+	if *set != "" {
+		scenarios, err = loadSet(*scenariosFile, *set, *genOpts, vars)
+	} else {
+		scenarios, err = loadScenarios(*scenariosFile, *genOpts, vars)
+	}
+	if err != nil {
+		return err
+	}
+	matches, _, err := sel.apply(scenarios, genOpts.ExternalNames())
+	if err != nil {
+		return err
+	}
+	if err := sortScenarios(scenarios, matches, *sortSpec); err != nil {
+		return err
+	}
+	return writeList(os.Stdout, *format, scenarios, matches, *longForm)
+}
+
+// onlyScenarios returns the indexes of the scenarios named in a
+// comma-separated list of codes or list numbers, in the order given.
+func onlyScenarios(scenarios []interactions.Scenario, refs string) ([]int, error) {
+	var selected []int
+	for _, ref := range strings.Split(refs, ",") {
+		n, err := interactions.FindScenario(scenarios, strings.TrimSpace(ref))
+		if err != nil {
+			return nil, withKind(usageError, err)
+		}
+		selected = append(selected, n)
+	}
+	return selected, nil
+}
+
+// numbered returns the scenarios at the indexes in matches, and the number
+// of each panel: its place in the full list, as list numbers it.
+func numbered(scenarios []interactions.Scenario, matches []int) (selected []interactions.Scenario, numbers []int) {
+	selected = make([]interactions.Scenario, len(matches))
+	numbers = make([]int, len(matches))
+	for i, n := range matches {
+		selected[i] = scenarios[n]
+		numbers[i] = n + 1
+	}
+	return selected, numbers
+}
+
+// inRange keeps the indexes in matches that the --range selection spec
+// picks out of n scenarios, or all of them when spec is empty.
+func inRange(matches []int, spec string, n int) ([]int, error) {
+	if spec == "" {
+		return matches, nil
+	}
+	picked, err := interactions.ParseRange(spec, n)
+	if err != nil {
+		return nil, usageErrorf("--range: %w", err)
+	}
+	return slices.DeleteFunc(matches, func(i int) bool { return !slices.Contains(picked, i) }), nil
+}
+
+// dedupe keeps the first of the indexes in matches of each set of
+// scenarios that are the same up to relabelling the external actors.
+func dedupe(scenarios []interactions.Scenario, matches []int, externals []string) []int {
+	seen := map[string]bool{}
+	return slices.DeleteFunc(matches, func(i int) bool {
+		key := interactions.CanonicalKey(scenarios[i], externals...)
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+		return false
+	})
+}
+
+// reduceMirrors keeps the first of the indexes in matches of each pair of
+// scenarios that are mirror images of each other, with the roles of A and B
+// swapped and the externals, if any, relabelled among themselves. mirrored
+// reports, by index into scenarios, which of those kept stand for a pair.
+func reduceMirrors(scenarios []interactions.Scenario, matches []int, externals []string) (kept []int, mirrored []bool) {
+	mirrored = make([]bool, len(scenarios))
+	first := map[string]int{}
+	for _, i := range matches {
+		key := interactions.CanonicalKey(scenarios[i], externals...)
+		mirror := interactions.CanonicalKey(interactions.Mirror(scenarios[i], "A", "B"), externals...)
+		if j, ok := first[mirror]; ok && mirror != key {
+			mirrored[j] = true
+			continue
+		}
+		if _, ok := first[key]; !ok {
+			first[key] = i
+		}
+		kept = append(kept, i)
+	}
+	return kept, mirrored
+}
+
+// sampleOf picks n of the indexes in matches at random, the same ones for
+// the same seed, keeping them in their order. With n at least the number
+// of matches it keeps them all.
+func sampleOf(matches []int, n int, seed uint64) []int {
+	if n >= len(matches) {
+		return matches
+	}
+	// PCG's output is fixed for a seed across Go releases, unlike the
+	// global generator's
+	r := rand.New(rand.NewPCG(seed, 0))
+	picked := r.Perm(len(matches))[:n]
+	slices.Sort(picked)
+	sampled := make([]int, n)
+	for i, p := range picked {
+		sampled[i] = matches[p]
+	}
+	return sampled
+}
+
+// overflowModes are the values of the --overflow flag.
+var overflowModes = map[string]interactions.Overflow{
+	"draw":     interactions.OverflowDraw,
+	"ellipsis": interactions.OverflowEllipsis,
+}
+
+// mutualismGlyphs are the values of the --mutualism flag.
+var mutualismGlyphs = map[string]interactions.Mutualism{
+	"arrows":        interactions.MutualismArrows,
+	"double-headed": interactions.MutualismDoubleHeaded,
+	"parallel":      interactions.MutualismParallel,
+}
+
+// matchingScenarios returns the indexes of the scenarios matching query, or
+// of every scenario when query is empty. Indexes rather than scenarios let
+// list keep numbering scenarios by their place in the full set.
+func matchingScenarios(scenarios []interactions.Scenario, query string) ([]int, error) {
+	var matches []int
+	if query == "" {
+		for i := range scenarios {
+			matches = append(matches, i)
+		}
+		return matches, nil
+	}
+
+	q, err := interactions.ParseQuery(query)
+	if err != nil {
+		return nil, withKind(usageError, err)
+	}
+	for _, name := range q.Fields() {
+		if err := checkField(scenarios, name); err != nil {
+			return nil, usageErrorf("query: %w", err)
+		}
+	}
+
+	for i, s := range scenarios {
+		if q.Match(s) {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
+// checkField fails if name is not one of the query fields of the
+// scenarios, as interactions.QueryFields lists them.
+func checkField(scenarios []interactions.Scenario, name string) error {
+	if names := interactions.QueryFields(scenarios); !slices.Contains(names, name) {
+		return usageErrorf("unknown field %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// parseAxes reads the --axes flag, the row and column dimensions of a
+// matrix layout separated by a comma.
+func parseAxes(scenarios []interactions.Scenario, value string) ([]string, error) {
+	row, col, ok := strings.Cut(value, ",")
+	if !ok {
+		return nil, usageErrorf("--axes wants a row and a column dimension, e.g. ab,time; got %q", value)
+	}
+	axes := []string{strings.ToLower(strings.TrimSpace(row)), strings.ToLower(strings.TrimSpace(col))}
+	for _, name := range axes {
+		if err := checkField(scenarios, name); err != nil {
+			return nil, usageErrorf("--axes: %w", err)
+		}
+	}
+	return axes, nil
+}
+
+// runValidate checks the scenario files named as arguments, or the
+// generated taxonomy when there are none, and fails if any have problems.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	schema := fs.Bool("schema", false, "print the JSON Schema of scenario files, for editors, instead of validating")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *schema {
+		_, err := os.Stdout.Write(interactions.ScenarioSchema())
+		return err
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+
+	count := 0
+	if fs.NArg() == 0 {
+		for i, s := range generateScenarios(*genOpts) {
+			for _, p := range interactions.Validate(s) {
+				fmt.Printf("%s %s: %s\n", interactions.ListNumber(i+1), s.Title, p)
+				count++
+			}
+		}
+	}
+	for _, file := range fs.Args() {
+		n, err := printFileProblems(file)
+		if err != nil {
+			return scenarioFileError(err)
+		}
+		count += n
+	}
+	if count > 0 {
+		return withKind(validationError, fmt.Errorf("%d problems found", count))
+	}
+	return nil
+}
+
+func printGlobalUsage() {
+	fmt.Println("Usage: interactions [--config file] [--cpuprofile file] [--memprofile file] <command> [options]")
+	fmt.Println()
+	fmt.Println("Global options:")
+	fmt.Println("  --config FILE      Read flag defaults from FILE instead of the user and project config files")
+	fmt.Println("  --cpuprofile FILE  Write a CPU profile of the command to FILE, for go tool pprof")
+	fmt.Println("  --memprofile FILE  Write a memory profile to FILE once the command is done")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  render   Generate the interactions grid PNG (use --output to set the destination)")
+	fmt.Println("  list     List scenario titles (use --long to include subtitles)")
+	fmt.Println("  serve    Serve rendered grids and panels over HTTP (use --addr to set the address)")
+	fmt.Println("  browse   Filter scenarios interactively and render the selected panel")
+	fmt.Println("  show     Display scenario panels inline in terminals that support images")
+	fmt.Println("  generate Write the generated scenarios as a scenario file (use --estimate to count them)")
+	fmt.Println("  validate Check scenario files for missing nodes, duplicates, bad spans and sizes")
+	fmt.Println("  inspect  Print the metadata recorded in rendered PNGs")
+	fmt.Println("  diff     Compare scenario files, or one file with the generated scenarios")
+	fmt.Println("  stats    Count the scenarios by dimension value and by size")
+	fmt.Println("  classes  Group scenarios that are the same pattern with C, D, ... relabelled")
+	fmt.Println("  export   Write the scenarios as a CSV table or a Markdown catalog: export csv|markdown")
+	fmt.Println("  bench    Time generation, layout, drawing and encoding over grid sizes")
+	fmt.Println("  help     Show this help text")
+	fmt.Println()
+	fmt.Println("Generation options (render, list, serve, browse, show, generate, validate, diff, stats, classes, export and bench):")
+	fmt.Println("  --strengths   Add weak and strong variants of each A-B edge")
+	fmt.Println("  --delays      Add a delayed (dashed) variant of each A-B edge")
+	fmt.Println("  --uncertain   Add variants of each A-B edge that happen only sometimes or conditionally")
+	fmt.Println("  --feedback    Add A and/or B self-reinforcement loops")
+	fmt.Println("  --ecology     Add competition and predation, and label A-B edges with +/-/0 signs")
+	fmt.Println("  --timing      Add process variants where A meets, overlaps or contains B")
+	fmt.Println("  --chains      Add indirect influence through a mediator (A -> E -> B and so on)")
+	fmt.Println("  --actors N    Number of core actors from A onwards, with a pattern for each pair (default 2)")
+	fmt.Println("  --externals N Number of external actors after the core actors (default 2)")
+	fmt.Println("  --limit N     Fail rather than generate more than N scenarios, 0 for no limit (default 10000)")
+	fmt.Println("  --generator G Generate the scenarios with a registered generator instead of the built-in taxonomy")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  go run ./cmd/interactions render --output interactions.png")
+	fmt.Println("  go run ./cmd/interactions render --columns 3 --output interactions-long.png")
+	fmt.Println("  go run ./cmd/interactions list --long")
+	fmt.Println("  go run ./cmd/interactions render --only AB3.C1.D0,AB4.C0.D2 --output picked.png")
+	fmt.Println("  go run ./cmd/interactions render --timing --axes ab,time --query \"c=none and d=none\" --output matrix.png")
+	fmt.Println("  go run ./cmd/interactions render --layout poster --output poster.png")
+	fmt.Println("  go run ./cmd/interactions render --output interactions.png --output interactions.webp")
+	fmt.Println("  go run ./cmd/interactions render --split --output scenarios.zip")
+	fmt.Println("  go run ./cmd/interactions render --scenarios my-scenarios.yaml --node-layout force --layout-seed 7")
+	fmt.Println("  go run ./cmd/interactions render --watermark DRAFT --footer \"rendered by interactions\" --logo logo.png")
+	fmt.Println("  go run ./cmd/interactions list --query \"ab=mutualism and c!=none\"")
+	fmt.Println("  go run ./cmd/interactions render --scenarios my-scenarios.yaml --watch")
+	fmt.Println("  go run ./cmd/interactions render --scenarios approval.yaml --var Org=Finance --var Doc=Budget")
+	fmt.Println("  go run ./cmd/interactions render --scenarios lessons.yaml --set ecology-basics")
+	fmt.Println("  go run ./cmd/interactions render --scenarios lessons.zip/week1.yaml")
+	fmt.Println("  go run ./cmd/interactions render --matrix \"0,1,0;0,0,1;1,0,0\" --output matrix.png")
+	fmt.Println("  go run ./cmd/interactions serve --addr localhost:8080")
+	fmt.Println("  go run ./cmd/interactions browse --ecology")
+	fmt.Println("  go run ./cmd/interactions show AB3.C1.D0")
+	fmt.Println("  go run ./cmd/interactions generate --actors 3 --externals 1 --output three.yaml")
+	fmt.Println("  go run ./cmd/interactions generate --actors 3 --ecology --estimate")
+	fmt.Println("  go run ./cmd/interactions validate my-scenarios.yaml")
+	fmt.Println("  go run ./cmd/interactions validate --schema > scenario.schema.json")
+	fmt.Println("  go run ./cmd/interactions inspect interactions.png")
+	fmt.Println("  go run ./cmd/interactions diff --output changes.png old.yaml new.yaml")
+	fmt.Println("  go run ./cmd/interactions stats --ecology")
+	fmt.Println("  go run ./cmd/interactions classes --output-dir classes")
+	fmt.Println("  go run ./cmd/interactions export csv --output taxonomy.csv")
+	fmt.Println("  go run ./cmd/interactions export markdown --images-dir imgs/ --output catalog.md")
+	fmt.Println("  go run ./cmd/interactions export markdown --name-template '{{.Code}}'")
+	fmt.Println("  go run ./cmd/interactions bench --sizes 80,640 --json")
+}
+
+// gridSettings are the render flags that shape the grid image.
+type gridSettings struct {
+	columns   int
+	themeName string
+	theme     interactions.Theme
+	quality   int
+	tiled     bool
+	// legend is on, off or separate, which writes the legend to legend.png
+	// (or the format's extension) beside the grid
+	legend string
+	// maxRows splits the grid into pages of at most this many rows of
+	// panels when it is above 0
+	maxRows int
+	// axes are the row and column dimensions of a matrix layout, if any
+	axes []string
+	// sections is the section dimension of a poster layout, if any
+	sections string
+	// numbers are the list numbers of the scenarios, for their captions
+	numbers []int
+	// badges are the notes drawn on the panels, if any
+	badges []string
+	// altText writes a sidecar describing the panels beside each image
+	altText bool
+	// imageMap writes an HTML image map beside each image, linking each
+	// panel to mapHref
+	imageMap bool
+	mapHref  string
+	// verify renders each image twice and fails if the bytes differ
+	verify bool
+	// force rewrites files that would not change
+	force bool
+	// progress shows a progress bar while drawing
+	progress bool
+	// strict fails the render if there were any warnings
+	strict bool
+	// naming names the images of a split render, by defaultNameTemplate
+	// when nil
+	naming *template.Template
+	opts   []interactions.Option
+}
+
+// outputFile is a file render writes an image to, in the format given by
+// --format or its extension, through sink, which also takes the files
+// written beside it.
+type outputFile struct {
+	name   string
+	format imageFormat
+	sink   sink
+}
+
+// renamed returns the file beside o named by name, with the extension of o's
+// format kept from o.
+func (o outputFile) renamed(name func(base, ext string) string) outputFile {
+	ext := filepath.Ext(o.name)
+	return outputFile{name: name(strings.TrimSuffix(o.name, ext), ext), format: o.format, sink: o.sink}
+}
+
+// beside returns the file named name in the directory of o, in o's format.
+func (o outputFile) beside(name string) outputFile {
+	return outputFile{name: filepath.Join(filepath.Dir(o.name), name), format: o.format, sink: o.sink}
+}
+
+// renderAllScenarios writes the grid to each of outputs, or with maxRows
+// set and more rows than that, to numbered pages beside them:
+// interactions.png becomes interactions-1.png, interactions-2.png and so
+// on, each with the title and legend, led by interactions-contents.png
+// listing the page of each scenario. Each image is drawn once however many
+// outputs it is encoded to, and the sidecars describe the first output.
+func renderAllScenarios(outputs []outputFile, scenarios []interactions.Scenario, g gridSettings) (err error) {
+	opts := g.opts
+	if g.legend != "on" {
+		opts = append(opts, interactions.WithoutLegend())
+	}
+	if g.legend == "separate" {
+		legend := interactions.DrawLegend(scenarios, g.columns, g.theme, g.opts...)
+		written := map[string]bool{}
+		for _, out := range outputs {
+			legendFile := out.beside("legend" + out.format.ext)
+			if written[legendFile.name] {
+				continue
+			}
+			written[legendFile.name] = true
+			if err := logGenerated(legendFile.name, writeImage(legendFile, legend, g)); err != nil {
+				return err
+			}
+		}
+	}
+
+	var bar *progressBar
+	if g.progress {
+		bar = newProgressBar(len(scenarios))
+	}
+
+	// warnings are logged once the images are written, clear of the
+	// progress bar, and once each however many times a panel is drawn
+	var warnings interactions.Warnings
+	opts = append(opts, interactions.CollectWarnings(&warnings))
+	defer func() { reportWarnings(warnings, g.strict, &err) }()
+
+	perPage := len(scenarios)
+	if g.maxRows > 0 {
+		perPage = g.maxRows * g.columns
+	}
+	pages := (len(scenarios) + perPage - 1) / perPage
+	if pages <= 1 {
+		var meta []interactions.TextChunk
+		if g.axes != nil {
+			meta = append(meta, interactions.TextChunk{Keyword: metaAxes, Text: strings.Join(g.axes, ",")})
+		}
+		if g.sections != "" {
+			meta = append(meta, interactions.TextChunk{Keyword: metaSections, Text: g.sections})
+		}
+		err := writeGrid(outputs, scenarios, g, append(opts, interactions.WithNumbers(g.numbers), interactions.WithBadges(g.badges), bar.option(0)), meta, bar)
+		if err != nil {
+			return err
+		}
+		return writeSidecars(outputs[0], scenarios, g.numbers, opts, g)
+	}
+
+	for _, out := range outputs {
+		pageFiles := make([]string, len(scenarios))
+		for i := range scenarios {
+			pageFiles[i] = filepath.Base(out.renamed(func(base, ext string) string { return fmt.Sprintf("%s-%d%s", base, i/perPage+1, ext) }).name)
+		}
+		contentsFile := out.renamed(func(base, ext string) string { return base + "-contents" + ext })
+		contents := interactions.DrawContents(scenarios, pageFiles, g.theme, append(slices.Clip(opts), interactions.WithNumbers(g.numbers))...)
+		if err := logGenerated(contentsFile.name, writeImage(contentsFile, contents, g)); err != nil {
+			return err
+		}
+	}
+
+	for p := range pages {
+		lo, hi := p*perPage, min((p+1)*perPage, len(scenarios))
+		pageOpts := append(slices.Clip(opts), interactions.WithNumbers(g.numbers[lo:hi]), interactions.WithPage(p+1, pages), bar.option(lo))
+		if g.badges != nil {
+			pageOpts = append(pageOpts, interactions.WithBadges(g.badges[lo:hi]))
+		}
+		page := interactions.TextChunk{Keyword: metaPage, Text: fmt.Sprintf("%d/%d", p+1, pages)}
+		pageOutputs := make([]outputFile, len(outputs))
+		for i, out := range outputs {
+			pageOutputs[i] = out.renamed(func(base, ext string) string { return fmt.Sprintf("%s-%d%s", base, p+1, ext) })
+		}
+		if err := writeGrid(pageOutputs, scenarios[lo:hi], g, pageOpts, []interactions.TextChunk{page}, bar); err != nil {
+			return err
+		}
+		if err := writeSidecars(pageOutputs[0], scenarios[lo:hi], g.numbers[lo:hi], pageOpts, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportWarnings logs warnings and, for --strict, fails with them when
+// *err, the error of the render, does not already say it failed.
+func reportWarnings(warnings interactions.Warnings, strict bool, err *error) {
+	for _, w := range warnings {
+		log.Println("Warning:", w)
+	}
+	if strict && len(warnings) > 0 && *err == nil {
+		*err = withKind(validationError, fmt.Errorf("--strict: drawing gave warnings (%d)", len(warnings)))
+	}
+}
+
+// writeSidecars writes the files asked for beside the image out of
+// scenarios, with the given list numbers, drawn with opts: the alt text and
+// the image map.
+func writeSidecars(out outputFile, scenarios []interactions.Scenario, numbers []int, opts []interactions.Option, g gridSettings) error {
+	if g.altText {
+		if err := writeAltText(out.sink, out.name, scenarios, numbers); err != nil {
+			return err
+		}
+	}
+	if !g.imageMap {
+		return nil
+	}
+	var rects []image.Rectangle
+	switch {
+	case g.axes != nil:
+		rects = interactions.MatrixPanelRects(scenarios, g.axes[0], g.axes[1], opts...)
+	case g.sections != "":
+		rects = interactions.PosterPanelRects(scenarios, g.sections, opts...)
+	default:
+		rects = interactions.PanelRects(scenarios, g.columns, opts...)
+	}
+	return writeImageMap(out.sink, out.name, scenarios, numbers, rects, g.mapHref)
+}
+
+// stdoutName is the --output name that writes to standard output.
+const stdoutName = "-"
+
+// writeGrid draws one grid image and writes it to each of outputs,
+// recording how it was made in the text chunks of PNG outputs for inspect
+// to read back. The output - writes to standard output. The progress bar,
+// if any, is cleared once the image is drawn.
+func writeGrid(outputs []outputFile, scenarios []interactions.Scenario, g gridSettings, opts []interactions.Option, meta []interactions.TextChunk, bar *progressBar) error {
+	draw := func() image.Image {
+		defer bar.clear()
+		switch {
+		case g.axes != nil:
+			return interactions.DrawMatrix(scenarios, g.axes[0], g.axes[1], g.theme, opts...)
+		case g.sections != "":
+			return interactions.DrawPoster(scenarios, g.sections, g.theme, opts...)
+		default:
+			return interactions.DrawGrid(scenarios, g.columns, g.theme, opts...)
+		}
+	}
+	var bounds image.Rectangle
+	switch {
+	case g.axes != nil:
+		bounds = interactions.MatrixBounds(scenarios, g.axes[0], g.axes[1], opts...)
+	case g.sections != "":
+		bounds = interactions.PosterBounds(scenarios, g.sections, opts...)
+	default:
+		bounds = interactions.GridBounds(scenarios, g.columns, opts...)
+	}
+	for _, out := range outputs {
+		if err := out.format.checkSize(out.name, bounds); err != nil {
+			return err
+		}
+	}
+	// the image is drawn for the first output and encoded again for the
+	// rest, except that --verify draws it afresh each time, and released
+	// once done with, for the next page or the next run to draw on
+	var img image.Image
+	release := func() {
+		if rgba, ok := img.(*image.RGBA); ok {
+			interactions.ReleaseImage(rgba)
+		}
+	}
+	defer release()
+	for _, out := range outputs {
+		encode := func(w io.Writer) error {
+			if out.format.name == "png" {
+				w = interactions.NewPNGTextWriter(w, append(renderMetadata(scenarios, g.columns, g.themeName), meta...))
+			}
+			if g.tiled {
+				defer bar.clear()
+				return interactions.WriteTiledGrid(w, scenarios, g.columns, g.theme, opts...)
+			}
+			if img == nil || g.verify {
+				release()
+				img = draw()
+			}
+			return out.format.encode(w, img, g.quality)
+		}
+		err := writeEncoded(out, encode, g.verify)
+		if out.name == stdoutName {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := logGenerated(out.name, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEncoded writes out with encode, or with verify runs it twice and
+// fails unless both give the same bytes. It returns errUnchanged if out
+// held those bytes already.
+func writeEncoded(out outputFile, encode func(io.Writer) error, verify bool) error {
+	if verify {
+		var err error
+		if encode, err = verifiedEncoding(out.name, encode); err != nil {
+			return err
+		}
+	}
+	w, err := out.sink.create(out.name)
+	if err != nil {
+		return err
+	}
+	if err := closeAfter(w, encode(w)); err != nil {
+		if errors.Is(err, errUnchanged) {
+			return err
+		}
+		if out.name == stdoutName {
+			return withKind(ioError, fmt.Errorf("writing to standard output: %w", err))
+		}
+		return withKind(ioError, fmt.Errorf("writing %s: %w", out.name, err))
+	}
+	return nil
+}
+
+// writeImage writes img to out.
+func writeImage(out outputFile, img image.Image, g gridSettings) error {
+	return writeEncoded(out, func(w io.Writer) error {
+		return out.format.encode(w, img, g.quality)
+	}, g.verify)
+}
+
+// loadLogo reads the --logo image, in any format the image package can
+// decode.
+func loadLogo(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, usageErrorf("--logo %s: %v", filename, err)
+	}
+	return img, nil
+}
+
+// verifiedEncoding runs encode twice, drawing the image from scratch each
+// time, and fails unless both runs give the same bytes. It returns an
+// encoder that writes those bytes, for render --verify.
+func verifiedEncoding(filename string, encode func(io.Writer) error) (func(io.Writer) error, error) {
+	var first, second bytes.Buffer
+	if err := encode(&first); err != nil {
+		return nil, err
+	}
+	if err := encode(&second); err != nil {
+		return nil, err
+	}
+	a, b := sha256.Sum256(first.Bytes()), sha256.Sum256(second.Bytes())
+	if a != b {
+		return nil, fmt.Errorf("--verify: %s is not reproducible: rendering it twice gave sha256 %x then %x", filename, a, b)
+	}
+	log.Printf("Verified: %s (sha256 %x)", filename, a)
+	return func(w io.Writer) error {
+		_, err := w.Write(first.Bytes())
+		return err
+	}, nil
+}
+
+// closeAfter closes f after a write that ended with err, returning the
+// first error of the two. A file that can be aborted, such as one of a
+// fileSink, is given err, so a failed write is discarded rather than
+// replacing what was there.
+func closeAfter(f io.Closer, err error) error {
+	var cerr error
+	if a, ok := f.(abortCloser); ok {
+		cerr = a.CloseWithError(err)
+	} else {
+		cerr = f.Close()
+	}
+	if err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Print(err)
+		os.Exit(kindOf(err).exitCode())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/arran4/interactions"
+)
+
+// defaultNameTemplate is the --name-template that names each panel's file
+// by its code and a short hash of its content, as in
+// AB3.C0.D2-9af31c.png.
+const defaultNameTemplate = "{{.Code}}-{{.Hash}}"
+
+// panelName is what --name-template is filled in with for a scenario.
+type panelName struct {
+	// Code is the scenario's code, or its list number when it has none.
+	Code string
+	// N is its list number.
+	N     int
+	Title string
+	// Hash is the first six hex digits of the sha256 of the scenario as
+	// JSON, which changes whenever the scenario does, though not with the
+	// theme or other options the panel is drawn with.
+	Hash string
+}
+
+// parseNameTemplate parses a --name-template.
+func parseNameTemplate(text string) (*template.Template, error) {
+	t, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, usageErrorf("--name-template: %v", err)
+	}
+	return t, nil
+}
+
+// panelFileName fills in t for s, the n'th scenario in the list output,
+// and adds ext. The name must not be empty or reach into another
+// directory.
+func panelFileName(t *template.Template, s interactions.Scenario, n int, ext string) (string, error) {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	err = t.Execute(&b, panelName{
+		Code:  cmp.Or(s.Code, strconv.Itoa(n)),
+		N:     n,
+		Title: s.Title,
+		Hash:  fmt.Sprintf("%x", sha256.Sum256(content))[:6],
+	})
+	if err != nil {
+		return "", usageErrorf("--name-template: %v", err)
+	}
+	name := b.String()
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", usageErrorf("--name-template gives %q for scenario %d, which is not a file name", name, n)
+	}
+	return name + ext, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/arran4/interactions"
+	"github.com/arran4/interactions/imagetest"
+)
+
+// areaPattern matches an area of an --image-map written with --map-href
+// {code}: its corners and the code of its panel.
+var areaPattern = regexp.MustCompile(`<area shape="rect" coords="(\d+),(\d+),(\d+),(\d+)" href="([^"]*)"`)
+
+// TestListAndRenderNumbering checks that render captions each panel of a
+// PNG grid with the number list --sort gives its scenario, whatever the
+// selection.
+func TestListAndRenderNumbering(t *testing.T) {
+	// keep the user's and the project's config out of it
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+	t.Chdir(home)
+
+	th, err := interactions.ThemeNamed("light")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"--range", "5-9,40,71-73"},
+		{"--strengths", "--range", "98-102"},
+		{"--query", "ab=mutualism"},
+		{"--dedupe", "--range", "1-40"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			listed := map[string]int{}
+			out := captureStdout(t, func() error {
+				return runList(append([]string{"--sort", "time,ab", "--format", "json"}, args...))
+			})
+			var entries []listEntry
+			if err := json.Unmarshal(out, &entries); err != nil {
+				t.Fatalf("list --format json: %v", err)
+			}
+			for _, e := range entries {
+				listed[e.Code] = e.Index
+			}
+
+			dir := t.TempDir()
+			grid := filepath.Join(dir, "grid.png")
+			renderArgs := []string{"--output", grid, "--captions", "index", "--legend", "off", "--image-map", "--map-href", "{code}"}
+			if err := runRender(append(renderArgs, args...)); err != nil {
+				t.Fatal(err)
+			}
+			img, err := imagetest.ReadPNG(grid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			imageMap, err := os.ReadFile(imageMapName(grid))
+			if err != nil {
+				t.Fatal(err)
+			}
+			areas := areaPattern.FindAllStringSubmatch(string(imageMap), -1)
+			if len(areas) != len(entries) {
+				t.Fatalf("render drew %d panels, list printed %d scenarios", len(areas), len(entries))
+			}
+
+			fs := flag.NewFlagSet("render", flag.ContinueOnError)
+			addSelectionFlags(fs, "render")
+			genOpts := addGenerateFlags(fs)
+			if err := fs.Parse(args); err != nil {
+				t.Fatal(err)
+			}
+			scenarios := generateScenarios(*genOpts)
+			for _, area := range areas {
+				code := area[5]
+				n, ok := listed[code]
+				if !ok {
+					t.Errorf("render drew %s, which list did not print", code)
+					continue
+				}
+				i, err := interactions.FindScenario(scenarios, code)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var corners [4]int
+				for j := range corners {
+					corners[j], _ = strconv.Atoi(area[1+j])
+				}
+				drawn := img.(interface {
+					SubImage(image.Rectangle) image.Image
+				}).SubImage(image.Rect(corners[0], corners[1], corners[2], corners[3]))
+
+				// a panel drawn alone, captioned with the number list
+				// gave, framed by the margin the grid also leaves
+				want := interactions.DrawPanel(scenarios[i], th,
+					interactions.WithCaptions(interactions.Captions{Index: true}),
+					interactions.WithNumbers([]int{n}))
+				m := (want.Bounds().Dx() - drawn.Bounds().Dx()) / 2
+				want = want.SubImage(image.Rect(m, m, want.Bounds().Dx()-m, want.Bounds().Dy()-m)).(*image.RGBA)
+				if diff, _ := imagetest.Compare(want, drawn, 0); diff > 0 {
+					t.Errorf("panel of %s is not captioned %s, as list numbers it: %d pixels differ", code, interactions.ListNumber(n), diff)
+				}
+			}
+		})
+	}
+}
+
+// captureStdout returns what run writes to standard output.
+func captureStdout(t *testing.T, run func() error) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- b
+	}()
+	err = run()
+	w.Close()
+	b := <-out
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
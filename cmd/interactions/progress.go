@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/arran4/interactions"
+	"golang.org/x/term"
+)
+
+// progressWidth is the width of the bar in characters.
+const progressWidth = 30
+
+// progressBar shows on standard error how many panels of a render have been
+// drawn, so a large render is not silent for seconds on end. A nil
+// progressBar shows nothing.
+type progressBar struct {
+	w     io.Writer
+	total int
+}
+
+// newProgressBar returns a bar for a render of total panels, or nil when
+// standard error is not a terminal and the bar would only clutter a log.
+func newProgressBar(total int) *progressBar {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return &progressBar{w: os.Stderr, total: total}
+}
+
+// option reports the progress of drawing a grid whose first panel is
+// panel offset of the whole render, counting from 0.
+func (p *progressBar) option(offset int) interactions.Option {
+	if p == nil {
+		return interactions.WithProgress(nil)
+	}
+	return interactions.WithProgress(func(done, total int) {
+		p.draw(offset+done, done == total)
+	})
+}
+
+// draw redraws the bar with done panels drawn. Once a grid's panels are
+// all drawn the image is still to be encoded, which can take longer.
+func (p *progressBar) draw(done int, encoding bool) {
+	filled := done * progressWidth / p.total
+	status := ""
+	if encoding {
+		status = ", encoding"
+	}
+	fmt.Fprintf(p.w, "\r[%s%s] %d/%d panels%s\x1b[K", strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled), done, p.total, status)
+}
+
+// clear erases the bar, so the next message starts on a clean line.
+func (p *progressBar) clear() {
+	if p != nil {
+		fmt.Fprint(p.w, "\r\x1b[K")
+	}
+}
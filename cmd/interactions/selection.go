@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// selectionFlags pick the scenarios a command works on out of those
+// loaded, the same way for render and list, so that both number and order
+// them alike.
+type selectionFlags struct {
+	query, only, rangeSpec *string
+	dedupe, reduceSymmetry *bool
+	sample                 *int
+	seed                   *uint64
+}
+
+// addSelectionFlags adds the selection flags to fs, worded for the
+// command's verb, such as "render".
+func addSelectionFlags(fs *flag.FlagSet, verb string) *selectionFlags {
+	return &selectionFlags{
+		query:          fs.String("query", "", verb+` only the scenarios matching this query, e.g. "ab=mutualism and c!=none"`),
+		only:           fs.String("only", "", verb+" only these comma-separated scenarios, by code (e.g. AB3.C1.D0) or list number"),
+		rangeSpec:      fs.String("range", "", verb+" only the scenarios at these list numbers, e.g. 101-164 or 1,5,9-12"),
+		dedupe:         fs.Bool("dedupe", false, verb+" only the first of scenarios that are the same but for which external actor (C, D, ...) is which"),
+		reduceSymmetry: fs.Bool("reduce-symmetry", false, verb+" one of each pair of scenarios that are mirror images with A and B swapped, marked ×2 (symmetric)"),
+		sample:         fs.Int("sample", 0, verb+" a random sample of this many of the selected scenarios (0 for all of them)"),
+		seed:           fs.Uint64("seed", 1, "seed of the --sample, so the same seed picks the same scenarios"),
+	}
+}
+
+// apply returns the indexes of the scenarios selected, in order, and, by
+// index into scenarios, which of those kept by --reduce-symmetry stand for
+// a mirrored pair. externals are the external actors relabelled by
+// --dedupe and --reduce-symmetry.
+func (f *selectionFlags) apply(scenarios []interactions.Scenario, externals []string) (matches []int, mirrored []bool, err error) {
+	if *f.sample < 0 {
+		return nil, nil, usageErrorf("--sample must not be negative, got %d", *f.sample)
+	}
+	matches, err = matchingScenarios(scenarios, *f.query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if *f.only != "" {
+		refs, err := onlyScenarios(scenarios, *f.only)
+		if err != nil {
+			return nil, nil, err
+		}
+		matches = slices.DeleteFunc(refs, func(n int) bool { return !slices.Contains(matches, n) })
+	}
+	if matches, err = inRange(matches, *f.rangeSpec, len(scenarios)); err != nil {
+		return nil, nil, err
+	}
+	if !*f.dedupe {
+		externals = nil
+	} else {
+		matches = dedupe(scenarios, matches, externals)
+	}
+	if *f.reduceSymmetry {
+		matches, mirrored = reduceMirrors(scenarios, matches, externals)
+	}
+	if *f.sample > 0 {
+		matches = sampleOf(matches, *f.sample, *f.seed)
+	}
+	return matches, mirrored, nil
+}
+
+// sortScenarios orders the indexes in matches by the comma-separated
+// fields of spec, the first before the rest, keeping the order of those
+// alike. Each field's values go in the order they first appear in
+// scenarios, which for the generated taxonomy is the order of their codes:
+// sorting by time puts events before scenarios where A meets B.
+func sortScenarios(scenarios []interactions.Scenario, matches []int, spec string) error {
+	if spec == "" {
+		return nil
+	}
+	var fields []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if err := checkField(scenarios, name); err != nil {
+			return usageErrorf("--sort: %w", err)
+		}
+		fields = append(fields, name)
+	}
+	rank := make([]map[string]int, len(fields))
+	for i, name := range fields {
+		rank[i] = map[string]int{}
+		for _, s := range scenarios {
+			v := interactions.Field(s, name)
+			if _, ok := rank[i][v]; !ok {
+				rank[i][v] = len(rank[i])
+			}
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b int) int {
+		for i, name := range fields {
+			if d := rank[i][interactions.Field(scenarios[a], name)] - rank[i][interactions.Field(scenarios[b], name)]; d != 0 {
+				return d
+			}
+		}
+		return 0
+	})
+	return nil
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/interactions"
+	"github.com/arran4/interactions/internal/cliflags"
+)
+
+// maxScale caps the scale query parameter so a single request cannot ask for
+// an enormous image.
+const maxScale = cliflags.MaxScale
+
+// maxImagePixels caps the pixels of an image a single request can ask for,
+// once scaled, so it cannot run the server out of memory: the full grid at
+// the largest scale would take well over a gigabyte.
+const maxImagePixels = 1 << 26
+
+// Limits on POST /render, so a single request cannot tie up the server.
+const (
+	maxRenderBody      = 1 << 20
+	maxRenderScenarios = 1024
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	columns := fs.Int("columns", 8, "default number of columns in /grid.png")
+	cacheDir := fs.String("cache", "", "keep each panel drawn in this directory, keyed by a hash of its scenario and the options, and take unchanged panels from it instead of drawing them again")
+	profiling := fs.Bool("pprof", false, "also serve the runtime profiles of the server under /debug/pprof/, for go tool pprof")
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *columns < 1 {
+		return usageErrorf("columns must be at least 1")
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+
+	srv := &previewServer{
+		scenarios: generateScenarios(*genOpts),
+		columns:   *columns,
+		lang:      genOpts.Lang,
+		cache:     panelCache(*cacheDir),
+		profiling: *profiling,
+	}
+	log.Printf("Serving %d scenarios on http://%s/", len(srv.scenarios), *addr)
+	return http.ListenAndServe(*addr, srv.routes())
+}
+
+// previewServer renders scenarios on demand over HTTP.
+type previewServer struct {
+	scenarios []interactions.Scenario
+	columns   int
+	// lang is the language of the grid title and legend
+	lang string
+	// cache keeps the panels drawn, nil for none
+	cache interactions.PanelCache
+	// profiling serves the runtime profiles under /debug/pprof/
+	profiling bool
+}
+
+// panelCache is the PanelCache of the --cache directory dir, nil for none.
+func panelCache(dir string) interactions.PanelCache {
+	if dir == "" {
+		return nil
+	}
+	return interactions.DirCache(dir)
+}
+
+func (p *previewServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", p.handleIndex)
+	mux.HandleFunc("GET /grid.png", p.handleGrid)
+	mux.HandleFunc("GET /scenario/{file}", p.handleScenario)
+	mux.HandleFunc("GET /scenarios", p.handleScenarios)
+	mux.HandleFunc("POST /render", p.handleRender)
+	if p.profiling {
+		mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+		mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+// handleIndex lists the scenarios with links to their panels.
+func (p *previewServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<!DOCTYPE html>")
+	fmt.Fprintln(w, "<title>Interaction patterns</title>")
+	fmt.Fprintln(w, `<p><a href="/grid.png">Full grid</a></p>`)
+	fmt.Fprintln(w, "<ol>")
+	for i, s := range p.scenarios {
+		ref := s.Code
+		if ref == "" {
+			ref = strconv.Itoa(i + 1)
+		}
+		fmt.Fprintf(w, "<li>%s — %s (<a href=\"/scenario/%s.png\">png</a>, <a href=\"/scenario/%s.svg\">svg</a>)</li>\n",
+			html.EscapeString(s.Title), html.EscapeString(s.Subtitle), ref, ref)
+	}
+	fmt.Fprintln(w, "</ol>")
+}
+
+func (p *previewServer) handleGrid(w http.ResponseWriter, r *http.Request) {
+	th, scale, err := renderParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	columns := p.columns
+	if v := r.URL.Query().Get("columns"); v != "" {
+		columns, err = strconv.Atoi(v)
+		if err != nil || columns < 1 {
+			http.Error(w, "columns must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	// more columns than panels only widens the grid with empty space
+	columns = min(columns, len(p.scenarios))
+	opts := []interactions.Option{interactions.WithLanguage(p.lang), interactions.WithPanelCache(p.cache)}
+	if err := checkGridSize(p.scenarios, columns, scale, opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	img, err := interactions.RenderContext(r.Context(), p.scenarios, columns, th, opts...)
+	if err != nil {
+		// the client has gone away, so there is no one to answer
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	if err := encodeScaled(w, img, scale); err != nil {
+		log.Printf("failed to encode grid: %v", err)
+	}
+}
+
+// encodeScaled writes img, enlarged by scale, to w as a PNG, then releases
+// both images for the next request to draw on, which spares a busy server
+// allocating a fresh canvas, tens of megabytes for a whole grid, for each.
+func encodeScaled(w io.Writer, img *image.RGBA, scale int) error {
+	scaled := interactions.ScaleImage(img, scale)
+	err := png.Encode(w, scaled)
+	interactions.ReleaseImage(scaled)
+	interactions.ReleaseImage(img)
+	return err
+}
+
+// handleScenario serves /scenario/{ref}.png and /scenario/{ref}.svg, where
+// ref is the scenario's code or its number in the list output.
+func (p *previewServer) handleScenario(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	ext := path.Ext(file)
+	ref := strings.TrimSuffix(file, ext)
+	n, err := interactions.FindScenario(p.scenarios, ref)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	s := p.scenarios[n]
+
+	th, scale, err := renderParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch ext {
+	case ".png":
+		w.Header().Set("Content-Type", "image/png")
+		err = encodeScaled(w, interactions.DrawPanel(s, th, interactions.WithPanelCache(p.cache)), scale)
+	case ".svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		err = interactions.WritePanelSVG(w, s, th, scale)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to write scenario %s: %v", ref, err)
+	}
+}
+
+// handleScenarios lists the built-in scenarios as JSON, in the form POST
+// /render accepts.
+func (p *previewServer) handleScenarios(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.scenarios); err != nil {
+		log.Printf("failed to encode scenarios: %v", err)
+	}
+}
+
+// renderRequest is the JSON body of POST /render.
+type renderRequest struct {
+	Scenarios []interactions.Scenario `json:"scenarios"`
+	// Format is png, the default, svg, or zip for a zip archive of an image
+	// of each scenario with an index.json, as render --split writes. SVG
+	// output is a single panel, so needs exactly one scenario.
+	Format   string `json:"format"`
+	Theme    string `json:"theme"`
+	Scale    int    `json:"scale"`
+	Columns  int    `json:"columns"`
+	Legend   *bool  `json:"legend"`
+	Captions string `json:"captions"`
+}
+
+// handleRender draws the scenarios posted in a renderRequest as a grid, or
+// as an SVG panel.
+func (p *previewServer) handleRender(w http.ResponseWriter, r *http.Request) {
+	var req renderRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRenderBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		status := http.StatusBadRequest
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "bad request body: "+err.Error(), status)
+		return
+	}
+
+	th, scale, opts, err := req.params()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts = append(opts, interactions.WithLanguage(p.lang), interactions.WithPanelCache(p.cache))
+	var problems []string
+	for i, s := range req.Scenarios {
+		for _, prob := range interactions.Validate(s) {
+			problems = append(problems, fmt.Sprintf("scenarios[%d].%s", i, prob))
+		}
+	}
+	if len(problems) > 0 {
+		http.Error(w, strings.Join(problems, "\n"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	columns := req.Columns
+	if columns == 0 {
+		columns = p.columns
+	}
+	columns = min(columns, len(req.Scenarios))
+	switch req.Format {
+	case "", "png":
+		if err := checkGridSize(req.Scenarios, columns, scale, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var img *image.RGBA
+		if img, err = interactions.RenderContext(r.Context(), req.Scenarios, columns, th, opts...); err != nil {
+			// the client has gone away
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		err = encodeScaled(w, img, scale)
+	case "svg":
+		if len(req.Scenarios) != 1 {
+			http.Error(w, "svg output needs exactly one scenario", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		err = interactions.WritePanelSVG(w, req.Scenarios[0], th, scale, opts...)
+	case "zip":
+		if scale != 1 {
+			http.Error(w, "zip output is drawn at scale 1", http.StatusBadRequest)
+			return
+		}
+		numbers := make([]int, len(req.Scenarios))
+		for i := range numbers {
+			numbers[i] = i + 1
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="scenarios.zip"`)
+		dest := newArchiveSink(w, "scenarios.zip")
+		_, err = writeSplit(dest, imageFormats["png"], req.Scenarios, gridSettings{
+			themeName: cmp.Or(req.Theme, "light"),
+			theme:     th,
+			numbers:   numbers,
+			opts:      opts,
+		})
+		err = closeAfter(dest, err)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q (want png, svg or zip)", req.Format), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to render posted scenarios: %v", err)
+	}
+}
+
+// params checks the request's options and converts them to rendering
+// parameters, applying the same defaults as the query parameters of the GET
+// endpoints.
+func (req renderRequest) params() (interactions.Theme, int, []interactions.Option, error) {
+	fail := func(format string, args ...any) (interactions.Theme, int, []interactions.Option, error) {
+		return interactions.Theme{}, 0, nil, fmt.Errorf(format, args...)
+	}
+	switch n := len(req.Scenarios); {
+	case n == 0:
+		return fail("no scenarios")
+	case n > maxRenderScenarios:
+		return fail("%d scenarios is more than the limit of %d", n, maxRenderScenarios)
+	}
+	if req.Columns < 0 {
+		return fail("columns must be a positive integer")
+	}
+
+	name := req.Theme
+	if name == "" {
+		name = "light"
+	}
+	th, err := interactions.ThemeNamed(name)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	scale := req.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if scale < 1 || scale > maxScale {
+		return fail("scale must be an integer from 1 to %d", maxScale)
+	}
+
+	var opts []interactions.Option
+	if req.Legend != nil && !*req.Legend {
+		opts = append(opts, interactions.WithoutLegend())
+	}
+	if req.Captions != "" {
+		caps, err := interactions.ParseCaptions(req.Captions)
+		if err != nil {
+			return fail("%v", err)
+		}
+		opts = append(opts, interactions.WithCaptions(caps))
+	}
+	return th, scale, opts, nil
+}
+
+// checkGridSize fails if the grid of scenarios in columns, drawn with opts
+// and enlarged by scale, would be over maxImagePixels.
+func checkGridSize(scenarios []interactions.Scenario, columns, scale int, opts []interactions.Option) error {
+	b := interactions.GridBounds(scenarios, columns, opts...)
+	w, h := b.Dx()*scale, b.Dy()*scale
+	if w*h > maxImagePixels {
+		return fmt.Errorf("the image would be %dx%d pixels, more than the limit of %d", w, h, maxImagePixels)
+	}
+	return nil
+}
+
+// renderParams reads the theme and scale query parameters shared by the
+// image endpoints.
+func renderParams(r *http.Request) (interactions.Theme, int, error) {
+	q := r.URL.Query()
+
+	name := q.Get("theme")
+	if name == "" {
+		name = "light"
+	}
+	th, err := interactions.ThemeNamed(name)
+	if err != nil {
+		return interactions.Theme{}, 0, err
+	}
+
+	scale := 1
+	if v := q.Get("scale"); v != "" {
+		scale, err = strconv.Atoi(v)
+		if err != nil || scale < 1 || scale > maxScale {
+			return interactions.Theme{}, 0, fmt.Errorf("scale must be an integer from 1 to %d", maxScale)
+		}
+	}
+	return th, scale, nil
+}
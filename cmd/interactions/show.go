@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arran4/interactions"
+	"golang.org/x/term"
+)
+
+// graphicsProtocol is a way of drawing images inline in a terminal.
+type graphicsProtocol string
+
+const (
+	protoKitty  graphicsProtocol = "kitty"
+	protoITerm2 graphicsProtocol = "iterm2"
+	protoSixel  graphicsProtocol = "sixel"
+	// protoNone writes a temporary PNG instead.
+	protoNone graphicsProtocol = "none"
+)
+
+func runShow(args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	themeName := fs.String("theme", "light", "colour theme: light, dark, or a YAML theme file")
+	colors := addColorFlags(fs)
+	scale := fs.Int("scale", 1, "enlarge the panels by this factor")
+	protocol := fs.String("protocol", "auto", "terminal graphics protocol: auto, kitty, iterm2, sixel, or none to write a temporary PNG")
+	scenariosFile := fs.String("scenarios", "", "show the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return usageErrorf("show needs at least one scenario, by code (e.g. AB3.C1.D0) or list number")
+	}
+	if *scale < 1 || *scale > maxScale {
+		return usageErrorf("--scale must be from 1 to %d", maxScale)
+	}
+	proto := graphicsProtocol(*protocol)
+	switch proto {
+	case "auto":
+		proto = detectGraphics()
+	case protoKitty, protoITerm2, protoSixel, protoNone:
+	default:
+		return usageErrorf("unknown protocol %q (want auto, kitty, iterm2, sixel or none)", *protocol)
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		return err
+	}
+	if err := colors.apply(&th); err != nil {
+		return err
+	}
+	scenarios, err := loadScenarios(*scenariosFile, *genOpts, vars)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range fs.Args() {
+		n, err := interactions.FindScenario(scenarios, ref)
+		if err != nil {
+			return withKind(usageError, err)
+		}
+		img := interactions.ScaleImage(interactions.DrawPanel(scenarios[n], th), *scale)
+		if err := showImage(os.Stdout, img, proto, n); err != nil {
+			return withKind(ioError, err)
+		}
+	}
+	return nil
+}
+
+// showImage writes img to w inline using proto, or for protoNone writes it
+// to a temporary PNG named after scenario n and prints the path.
+func showImage(w io.Writer, img image.Image, proto graphicsProtocol, n int) error {
+	if proto == protoSixel {
+		if err := writeSixel(w, img); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	switch proto {
+	case protoKitty:
+		return writeKitty(w, buf.Bytes())
+	case protoITerm2:
+		return writeITerm2(w, buf.Bytes())
+	}
+
+	f, err := os.CreateTemp("", fmt.Sprintf("interactions-%d-*.png", n+1))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return closeAfter(f, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%02d rendered to %s\n", n+1, f.Name())
+	return err
+}
+
+// detectGraphics guesses the graphics protocol of the terminal on stdout
+// from the environment, falling back to asking the terminal whether it
+// supports sixel. It returns protoNone when stdout is not a terminal.
+func detectGraphics() graphicsProtocol {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return protoNone
+	}
+	termName := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || termName == "xterm-kitty" || termName == "xterm-ghostty" || program == "ghostty":
+		return protoKitty
+	case program == "iTerm.app" || program == "WezTerm" || os.Getenv("LC_TERMINAL") == "iTerm2":
+		return protoITerm2
+	case strings.Contains(termName, "sixel") || termName == "mlterm" || termName == "foot" || strings.HasPrefix(termName, "foot-"):
+		return protoSixel
+	}
+	if querySixel() {
+		return protoSixel
+	}
+	return protoNone
+}
+
+// sixelQueryTimeout is how long querySixel waits for the terminal to answer.
+const sixelQueryTimeout = 200 * time.Millisecond
+
+// querySixel sends the terminal a primary device attributes request and
+// reports whether the reply lists sixel graphics, attribute 4. Terminals
+// that do not reply within sixelQueryTimeout count as not supporting it.
+func querySixel() bool {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return false
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return false
+	}
+	defer term.Restore(fd, state)
+
+	reply := make(chan string, 1)
+	go func() {
+		// the reply looks like ESC [ ? 62 ; 4 ; 22 c
+		s, _ := bufio.NewReader(os.Stdin).ReadString('c')
+		reply <- s
+	}()
+	fmt.Fprint(os.Stdout, "\x1b[c")
+	select {
+	case s := <-reply:
+		// the first attribute is the device class
+		attrs := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "\x1b[?"), "c"), ";")
+		return slices.Contains(attrs[1:], "4")
+	case <-time.After(sixelQueryTimeout):
+	}
+	return false
+}
+
+// kittyChunkSize is the most base64 data one kitty graphics escape carries.
+const kittyChunkSize = 4096
+
+// writeKitty displays a PNG with the kitty graphics protocol, sending it
+// in chunks with m=1 on every chunk but the last.
+func writeKitty(w io.Writer, pngData []byte) error {
+	data := base64.StdEncoding.EncodeToString(pngData)
+	for i := 0; i < len(data); i += kittyChunkSize {
+		chunk := data[i:min(i+kittyChunkSize, len(data))]
+		more := 0
+		if i+kittyChunkSize < len(data) {
+			more = 1
+		}
+		control := fmt.Sprintf("m=%d", more)
+		if i == 0 {
+			control = "a=T,f=100," + control
+		}
+		if _, err := fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", control, chunk); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// writeITerm2 displays a PNG with iTerm2's inline image escape.
+func writeITerm2(w io.Writer, pngData []byte) error {
+	_, err := fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a\n",
+		len(pngData), base64.StdEncoding.EncodeToString(pngData))
+	return err
+}
+
+// writeSixel encodes img as sixel graphics. Panels use only a few colours,
+// so each gets a register of its own; images with more than 256 are
+// reduced to a fixed palette first.
+func writeSixel(w io.Writer, img image.Image) error {
+	p := paletted(img)
+	b := p.Bounds()
+	bw := bufio.NewWriter(w)
+
+	// DCS q starts sixel data; the raster attributes give a 1:1 aspect
+	// ratio and the image size
+	fmt.Fprintf(bw, "\x1bPq\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, c := range p.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	// each band of six rows is drawn once per colour it uses, returning to
+	// the start of the band with $ in between
+	row := make([]byte, b.Dx())
+	for y0 := b.Min.Y; y0 < b.Max.Y; y0 += 6 {
+		var used [256]bool
+		for y := y0; y < min(y0+6, b.Max.Y); y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				used[p.ColorIndexAt(x, y)] = true
+			}
+		}
+		first := true
+		for ci := range len(p.Palette) {
+			if !used[uint8(ci)] {
+				continue
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				var bits byte
+				for dy := range 6 {
+					if y := y0 + dy; y < b.Max.Y && p.ColorIndexAt(x, y) == uint8(ci) {
+						bits |= 1 << dy
+					}
+				}
+				row[x-b.Min.X] = '?' + bits
+			}
+			if !first {
+				bw.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(bw, "#%d", ci)
+			writeSixelRuns(bw, row)
+		}
+		bw.WriteByte('-')
+	}
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixelRuns writes a row of sixels, shortening runs of more than three
+// of the same with the ! repeat introducer.
+func writeSixelRuns(w *bufio.Writer, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, row[i])
+		} else {
+			w.Write(row[i:j])
+		}
+		i = j
+	}
+}
+
+// paletted converts img to a paletted image, using its own colours when
+// there are at most 256 and the Plan 9 palette otherwise.
+func paletted(img image.Image) *image.Paletted {
+	pal := exactPalette(img)
+	if pal == nil {
+		pal = palette.Plan9
+	}
+	p := image.NewPaletted(img.Bounds(), pal)
+	draw.Draw(p, p.Bounds(), img, img.Bounds().Min, draw.Src)
+	return p
+}
+
+// exactPalette returns the colours of img, or nil if there are more than
+// 256.
+func exactPalette(img image.Image) color.Palette {
+	b := img.Bounds()
+	var pal color.Palette
+	seen := map[color.RGBA]bool{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if seen[c] {
+				continue
+			}
+			if len(pal) == 256 {
+				return nil
+			}
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+	return pal
+}
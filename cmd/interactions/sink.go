@@ -0,0 +1,325 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sink is where render writes its files: images, pages, legends and
+// sidecars alike. The render pipeline only ever writes through a sink, so
+// the same render can go to files, standard output, an archive or an HTTP
+// response.
+type sink interface {
+	// create starts writing the file name, which closing the writer
+	// finishes.
+	create(name string) (io.WriteCloser, error)
+	// Close finishes the sink once every file is written.
+	Close() error
+}
+
+// abortCloser is a file, or a sink, that can be closed with the error
+// writing it ended with, to discard what was written instead of finishing
+// it. CloseWithError(nil) is Close.
+type abortCloser interface {
+	CloseWithError(err error) error
+}
+
+// fileSink writes files to the file system, by name within dir, or as
+// named when dir is empty. A file already holding what would be written is
+// left as it is, so its modification time does not tell make and the like
+// that it changed, unless force is set.
+type fileSink struct {
+	dir   string
+	force bool
+}
+
+func (s fileSink) create(name string) (io.WriteCloser, error) {
+	path := filepath.Join(s.dir, name)
+	tmp, err := createTemp(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sinkFile{tmp: tmp, hash: sha256.New(), path: path, force: s.force}, nil
+}
+
+// errUnchanged is returned on closing a file of a fileSink that already
+// held what was written to it, and was left as it was.
+var errUnchanged = errors.New("unchanged")
+
+// sinkFile is a file of a fileSink being written. It goes to a temporary
+// file beside path, and is hashed as it is written, so however large it is
+// it is never held in memory. Closing it renames the temporary file to
+// path, unless the file there already has the same content hash and force
+// is not set, or writing it failed, when it removes it instead.
+type sinkFile struct {
+	tmp   *os.File
+	hash  hash.Hash
+	path  string
+	force bool
+	err   error
+}
+
+func (f *sinkFile) Write(p []byte) (int, error) {
+	n, err := f.tmp.Write(p)
+	f.hash.Write(p[:n])
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+func (f *sinkFile) Close() error {
+	return f.CloseWithError(nil)
+}
+
+// CloseWithError closes f as Close does, unless err, or an error writing
+// the temporary file, is not nil, when it removes the temporary file and
+// leaves the file at path as it was.
+func (f *sinkFile) CloseWithError(err error) error {
+	err = closeAfter(f.tmp, cmp.Or(err, f.err))
+	if err == nil && !f.force && f.unchanged() {
+		err = errUnchanged
+	}
+	if err == nil {
+		err = os.Rename(f.tmp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.tmp.Name())
+	}
+	return err
+}
+
+// unchanged reports whether the file at f.path has the content hash of
+// what was written.
+func (f *sinkFile) unchanged() bool {
+	var sum [sha256.Size]byte
+	f.hash.Sum(sum[:0])
+	old, err := fileHash(f.path)
+	return err == nil && old == sum
+}
+
+// createTemp creates a new temporary file in the directory of path to be
+// renamed to it. Unlike os.CreateTemp, it gives the file the permissions
+// os.Create would.
+func createTemp(path string) (*os.File, error) {
+	dir, base := filepath.Split(path)
+	for {
+		f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, rand.Uint32())), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+}
+
+// fileHash is the sha256 of the content of the file path.
+func fileHash(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
+
+// logGenerated logs that the file name was written, or that it was left
+// unchanged, given err from writing it, and returns any other error.
+func logGenerated(name string, err error) error {
+	switch {
+	case errors.Is(err, errUnchanged):
+		log.Println("Unchanged:", name)
+	case err != nil:
+		return err
+	default:
+		log.Println("Generated:", name)
+	}
+	return nil
+}
+
+func (fileSink) Close() error { return nil }
+
+// writerSink writes its file to w, as --output - does to standard output.
+// Closing it leaves w open.
+type writerSink struct {
+	w io.Writer
+}
+
+func (s writerSink) create(string) (io.WriteCloser, error) {
+	return nopCloser{s.w}, nil
+}
+
+func (writerSink) Close() error { return nil }
+
+// nopCloser is a writer whose Close does nothing.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// sinkFor returns the sink of the output file name: standard output for -,
+// else the file system, rewriting unchanged files with force.
+func sinkFor(name string, force bool) sink {
+	if name == stdoutName {
+		return writerSink{os.Stdout}
+	}
+	return fileSink{force: force}
+}
+
+// dirSink returns the sink writing files into name: an archive if it has
+// the extension of one, else a directory, created if need be.
+func dirSink(name string, force bool) (sink, error) {
+	if isArchive(name) {
+		return createArchive(name, force)
+	}
+	if err := os.MkdirAll(name, 0o755); err != nil {
+		return nil, err
+	}
+	return fileSink{dir: name, force: force}, nil
+}
+
+// isArchive reports whether name has the extension of an archive a sink
+// can write: .zip, .tar.gz or .tgz.
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// newArchiveSink returns a sink writing its files into an archive on w, a
+// zip archive if name ends in .zip and otherwise a gzipped tar archive.
+// Closing the sink finishes the archive but leaves w open.
+func newArchiveSink(w io.Writer, name string) sink {
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		return &zipSink{w: zip.NewWriter(w)}
+	}
+	gz := gzip.NewWriter(w)
+	return &tarSink{gz: gz, w: tar.NewWriter(gz)}
+}
+
+// createArchive creates the archive file name, as newArchiveSink writes
+// it, leaving it as it is when it would not change, as a fileSink does,
+// unless force is set.
+func createArchive(name string, force bool) (sink, error) {
+	f, err := fileSink{force: force}.create(name)
+	if err != nil {
+		return nil, err
+	}
+	return closingSink{newArchiveSink(f, name), f}, nil
+}
+
+// closingSink is a sink that also closes the file under it.
+type closingSink struct {
+	sink
+	f io.Closer
+}
+
+func (s closingSink) Close() error {
+	return s.CloseWithError(nil)
+}
+
+// CloseWithError closes s, discarding the file under it, and so keeping
+// whatever was there before, when err is not nil.
+func (s closingSink) CloseWithError(err error) error {
+	return closeAfter(s.f, closeAfter(s.sink, err))
+}
+
+// archiveTime is the date of every file in an archive, the earliest a zip
+// archive can hold, so the same render gives the same archive.
+var archiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// zipSink writes its files into a zip archive.
+type zipSink struct {
+	w *zip.Writer
+}
+
+func (s *zipSink) create(name string) (io.WriteCloser, error) {
+	w, err := s.w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveTime})
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{w}, nil
+}
+
+func (s *zipSink) Close() error {
+	return s.w.Close()
+}
+
+// tarSink writes its files into a gzipped tar archive. A tar header holds
+// the size of its file, so each file is kept in memory until it is closed.
+type tarSink struct {
+	gz *gzip.Writer
+	w  *tar.Writer
+}
+
+func (s *tarSink) create(name string) (io.WriteCloser, error) {
+	return &tarFile{sink: s, name: name}, nil
+}
+
+func (s *tarSink) Close() error {
+	err := s.w.Close()
+	if gzErr := s.gz.Close(); err == nil {
+		err = gzErr
+	}
+	return err
+}
+
+// tarFile is a file of a tarSink being written.
+type tarFile struct {
+	bytes.Buffer
+	sink *tarSink
+	name string
+}
+
+func (f *tarFile) Close() error {
+	return f.CloseWithError(nil)
+}
+
+// CloseWithError leaves f out of the archive when err is not nil.
+func (f *tarFile) CloseWithError(err error) error {
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: f.name, Mode: 0o644, Size: int64(f.Len()), Typeflag: tar.TypeReg, ModTime: archiveTime}
+	if err := f.sink.w.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = f.sink.w.Write(f.Bytes())
+	return err
+}
+
+// writeFile writes the file name of s, holding data, or returns
+// errUnchanged if it held data already.
+func writeFile(s sink, name string, data []byte) error {
+	w, err := s.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if err := closeAfter(w, err); err != nil {
+		if errors.Is(err, errUnchanged) {
+			return err
+		}
+		return withKind(ioError, fmt.Errorf("writing %s: %w", name, err))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/arran4/interactions"
+)
+
+// splitIndexName is the file listing the images of a --split render.
+const splitIndexName = "index.json"
+
+// splitIndex is the index.json of a --split render.
+type splitIndex struct {
+	Images []splitImage `json:"images"`
+}
+
+// splitImage is one image of a --split render and the panel it draws.
+type splitImage struct {
+	File string `json:"file"`
+	altPanel
+}
+
+// writeSplit writes each of scenarios as an image of its own in format, a
+// single panel named by g.naming, by default its code and a hash of the
+// scenario as export markdown names them, to dest, with an index.json listing the images and
+// what each draws. It returns how many of the files held what they would
+// have been written with already, and were left as they were.
+func writeSplit(dest sink, format imageFormat, scenarios []interactions.Scenario, g gridSettings) (unchanged int, err error) {
+	naming := g.naming
+	if naming == nil {
+		if naming, err = parseNameTemplate(defaultNameTemplate); err != nil {
+			return 0, err
+		}
+	}
+	index := splitIndex{Images: make([]splitImage, len(scenarios))}
+	named := map[string]int{}
+	for i, s := range scenarios {
+		name, err := panelFileName(naming, s, g.numbers[i], format.ext)
+		if err != nil {
+			return 0, err
+		}
+		if other, ok := named[name]; ok {
+			return 0, usageErrorf("--split: scenarios %d and %d would both be named %s", other, g.numbers[i], name)
+		}
+		named[name] = g.numbers[i]
+		index.Images[i] = splitImage{File: name, altPanel: newAltPanel(s, g.numbers[i])}
+	}
+
+	var bar *progressBar
+	if g.progress {
+		bar = newProgressBar(len(scenarios))
+	}
+	defer bar.clear()
+	var warnings interactions.Warnings
+	opts := append(g.opts, interactions.CollectWarnings(&warnings))
+	defer func() { reportWarnings(warnings, g.strict, &err) }()
+
+	for i, s := range scenarios {
+		panelOpts := append(opts[:len(opts):len(opts)], interactions.WithNumbers(g.numbers[i:i+1]))
+		if g.badges != nil {
+			panelOpts = append(panelOpts, interactions.WithBadges(g.badges[i:i+1]))
+		}
+		encode := func(w io.Writer) error {
+			if format.name == "png" {
+				w = interactions.NewPNGTextWriter(w, renderMetadata([]interactions.Scenario{s}, 1, g.themeName))
+			}
+			return format.encode(w, interactions.DrawPanel(s, g.theme, panelOpts...), g.quality)
+		}
+		switch err := writeEncoded(outputFile{name: index.Images[i].File, format: format, sink: dest}, encode, g.verify); {
+		case errors.Is(err, errUnchanged):
+			unchanged++
+		case err != nil:
+			return unchanged, err
+		}
+		if bar != nil {
+			bar.draw(i+1, false)
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(index); err != nil {
+		return unchanged, err
+	}
+	if err := writeFile(dest, splitIndexName, buf.Bytes()); errors.Is(err, errUnchanged) {
+		unchanged++
+	} else if err != nil {
+		return unchanged, err
+	}
+	return unchanged, nil
+}
+
+// renderSplit writes each of scenarios as an image of its own in format
+// into name: an archive if it has the extension of one, else a directory,
+// created if need be.
+func renderSplit(name string, format imageFormat, scenarios []interactions.Scenario, g gridSettings) error {
+	dest, err := dirSink(name, g.force)
+	if err != nil {
+		return err
+	}
+	unchanged, err := writeSplit(dest, format, scenarios, g)
+	closeErr := closeAfter(dest, err)
+	switch {
+	case err != nil:
+		return err
+	case errors.Is(closeErr, errUnchanged):
+		log.Println("Unchanged:", name)
+		return nil
+	case closeErr != nil:
+		return withKind(ioError, fmt.Errorf("writing %s: %w", name, closeErr))
+	}
+	switch unchanged {
+	case 0:
+		log.Printf("Generated: %d images and %s in %s", len(scenarios), splitIndexName, name)
+	case len(scenarios) + 1:
+		log.Printf("Unchanged: %d images and %s in %s", len(scenarios), splitIndexName, name)
+	default:
+		log.Printf("Generated: %d images and %s in %s, %d of the files unchanged", len(scenarios), splitIndexName, name, unchanged)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arran4/interactions"
+)
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	scenariosFile := fs.String("scenarios", "", "count the scenarios in this YAML file instead of the generated taxonomy")
+	vars := addTemplateFlags(fs)
+	query := fs.String("query", "", `count only the scenarios matching this query, e.g. "ab=mutualism and c!=none"`)
+	genOpts := addGenerateFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := genOpts.validate(); err != nil {
+		return err
+	}
+
+	scenarios, err := loadScenarios(*scenariosFile, *genOpts, vars)
+	if err != nil {
+		return err
+	}
+	matches, err := matchingScenarios(scenarios, *query)
+	if err != nil {
+		return err
+	}
+	selected := make([]interactions.Scenario, len(matches))
+	for i, n := range matches {
+		selected[i] = scenarios[n]
+	}
+	st := interactions.Stats(selected)
+
+	fmt.Printf("Scenarios: %d\n", st.Scenarios)
+	fmt.Printf("Edges:     %d", st.Edges)
+	if st.Scenarios > 0 {
+		fmt.Printf(" (%.1f per scenario)", float64(st.Edges)/float64(st.Scenarios))
+	}
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, d := range st.Dimensions {
+		fmt.Fprintf(tw, "\n%s:\n", d.Name)
+		for _, v := range d.Values {
+			fmt.Fprintf(tw, "  %s\t%d\n", cmp.Or(v.Value, "(unset)"), v.Count)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Scenarios by nodes (rows) and edges (columns):")
+	return printBreakdown(st.Breakdown)
+}
+
+// printBreakdown prints the matrix of scenarios by node and edge count, with
+// totals, leaving out node counts no scenario has.
+func printBreakdown(breakdown [][]int) error {
+	if len(breakdown) == 0 {
+		return nil
+	}
+	width := len(breakdown[0])
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{"nodes"}
+	for e := range width {
+		header = append(header, strconv.Itoa(e))
+	}
+	fmt.Fprintln(tw, strings.Join(append(header, "total"), "\t")+"\t")
+
+	totals := make([]int, width)
+	for n, row := range breakdown {
+		cells := []string{strconv.Itoa(n)}
+		sum := 0
+		for e, count := range row {
+			cells = append(cells, strconv.Itoa(count))
+			sum += count
+			totals[e] += count
+		}
+		if sum == 0 {
+			continue
+		}
+		fmt.Fprintln(tw, strings.Join(append(cells, strconv.Itoa(sum)), "\t")+"\t")
+	}
+	cells := []string{"total"}
+	sum := 0
+	for _, count := range totals {
+		cells = append(cells, strconv.Itoa(count))
+		sum += count
+	}
+	fmt.Fprintln(tw, strings.Join(append(cells, strconv.Itoa(sum)), "\t")+"\t")
+	return tw.Flush()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/arran4/interactions"
+)
+
+// titleTemplates are the --title-template and --subtitle-template that
+// retitle the generated scenarios, each nil to keep the usual text.
+type titleTemplates struct {
+	title, subtitle *template.Template
+}
+
+// parseTitleTemplates parses the --title-template and --subtitle-template,
+// either of which may be empty.
+func parseTitleTemplates(title, subtitle string) (titleTemplates, error) {
+	var t titleTemplates
+	var err error
+	if t.title, err = parseTitleTemplate("title-template", title); err != nil {
+		return t, err
+	}
+	t.subtitle, err = parseTitleTemplate("subtitle-template", subtitle)
+	return t, err
+}
+
+func parseTitleTemplate(flag, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	t, err := template.New(flag).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, usageErrorf("--%s: %v", flag, err)
+	}
+	return t, nil
+}
+
+// apply retitles scenarios in place. A template is filled in with the
+// scenario's .Title, .Subtitle and .Code as generated and a field for each
+// of its dimensions: those named after actors, such as ab and c, in upper
+// case as .AB and .C, and the rest capitalised, as .Time and .Strength.
+func (t titleTemplates) apply(scenarios []interactions.Scenario) error {
+	if t.title == nil && t.subtitle == nil {
+		return nil
+	}
+	for i := range scenarios {
+		s := &scenarios[i]
+		data := titleData(*s)
+		if t.title != nil {
+			var b strings.Builder
+			if err := t.title.Execute(&b, data); err != nil {
+				return usageErrorf("--title-template: %v", err)
+			}
+			s.Title = b.String()
+		}
+		if t.subtitle != nil {
+			var b strings.Builder
+			if err := t.subtitle.Execute(&b, data); err != nil {
+				return usageErrorf("--subtitle-template: %v", err)
+			}
+			s.Subtitle = b.String()
+		}
+	}
+	return nil
+}
+
+// titleData is what the title templates are filled in with for s.
+func titleData(s interactions.Scenario) map[string]string {
+	data := map[string]string{"Title": s.Title, "Subtitle": s.Subtitle, "Code": s.Code}
+	for name, value := range s.Dimensions {
+		data[titleField(name)] = value
+	}
+	return data
+}
+
+// titleField is the template field of the dimension name: actor names of
+// one or two letters, such as ab and c, in upper case, and the rest
+// capitalised.
+func titleField(name string) string {
+	if len(name) <= 2 {
+		return strings.ToUpper(name)
+	}
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[n:]
+}
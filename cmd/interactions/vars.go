@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// templateFlags are the --var and --var-file flags filling in the
+// variables of a template scenario file.
+type templateFlags struct {
+	vars    map[string]string
+	varFile string
+}
+
+// addTemplateFlags adds --var and --var-file to fs.
+func addTemplateFlags(fs *flag.FlagSet) *templateFlags {
+	t := &templateFlags{vars: map[string]string{}}
+	fs.Func("var", "fill in a variable of a template --scenarios file, as key=value, e.g. Org=Finance; repeatable", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("want key=value, not %q", s)
+		}
+		t.vars[key] = value
+		return nil
+	})
+	fs.StringVar(&t.varFile, "var-file", "", "fill in the variables of a template --scenarios file from the key=value lines of this env file; --var overrides it")
+	return t
+}
+
+// values returns the variables given, or nil when there are none and the
+// scenario file is not a template.
+func (t *templateFlags) values() (map[string]string, error) {
+	if t == nil || len(t.vars) == 0 && t.varFile == "" {
+		return nil, nil
+	}
+	vars := map[string]string{}
+	if t.varFile != "" {
+		fileVars, err := readVarFile(t.varFile)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(vars, fileVars)
+	}
+	maps.Copy(vars, t.vars)
+	return vars, nil
+}
+
+// readVarFile reads the variables of an env file: key=value lines, with
+// blank lines, # comments and an "export " before the key allowed, and
+// quotes around the value removed.
+func readVarFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, withKind(validationError, fmt.Errorf("%s:%d: want key=value, not %q", file, n, line))
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	return vars, sc.Err()
+}
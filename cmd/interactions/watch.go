@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// watchInterval is how often --watch checks the scenario file for changes.
+const watchInterval = 500 * time.Millisecond
+
+// watchFile calls render once at the start and again each time the
+// modification time of file changes. Render errors are reported and
+// watching continues, so a half-saved file does not end the session. It
+// only returns if file cannot be read at the start.
+func watchFile(file string, render func() error) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	log.Printf("Watching %s for changes (Ctrl-C to stop)", file)
+
+	var last time.Time
+	modTime := info.ModTime()
+	for {
+		if !modTime.Equal(last) {
+			last = modTime
+			if err := render(); err != nil {
+				log.Printf("render failed: %v", err)
+			}
+		}
+		time.Sleep(watchInterval)
+		// the file may be briefly missing while an editor replaces it, in
+		// which case modTime keeps its last value
+		if info, err := os.Stat(file); err == nil {
+			modTime = info.ModTime()
+		}
+	}
+}
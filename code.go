@@ -0,0 +1,123 @@
+package interactions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Code is the short identifier of a scenario, such as "AB3.C1.D0": a
+// dot-separated list of segments, each the upper-case letters naming a
+// dimension followed by the number of its value.
+//
+// The generated taxonomy gives every scenario a code whose meaning never
+// changes between releases. New dimensions only add a segment when they
+// take a value other than their default, so adding one does not alter the
+// codes of existing scenarios.
+type Code []CodeSegment
+
+// CodeSegment is one dimension of a Code.
+type CodeSegment struct {
+	Dim   string
+	Value int
+}
+
+func (c Code) String() string {
+	parts := make([]string, len(c))
+	for i, seg := range c {
+		parts[i] = seg.Dim + strconv.Itoa(seg.Value)
+	}
+	return strings.Join(parts, ".")
+}
+
+// ParseCode parses a code, ignoring case.
+func ParseCode(s string) (Code, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty scenario code")
+	}
+	var c Code
+	for _, part := range strings.Split(strings.ToUpper(s), ".") {
+		letters := strings.TrimRight(part, "0123456789")
+		digits := part[len(letters):]
+		if letters == "" || digits == "" || strings.Trim(letters, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("bad scenario code %q: segment %q is not letters followed by a number", s, part)
+		}
+		v, err := strconv.Atoi(digits)
+		if err != nil {
+			return nil, fmt.Errorf("bad scenario code %q: %w", s, err)
+		}
+		c = append(c, CodeSegment{Dim: letters, Value: v})
+	}
+	return c, nil
+}
+
+// ListNumber is how the list command numbers the scenario at 1-based
+// position n, and how Captions.Index labels its panel, so the two can be
+// read side by side: "05.", "417.".
+func ListNumber(n int) string {
+	return fmt.Sprintf("%02d.", n)
+}
+
+// FindScenario returns the index of the scenario a reference names. The
+// reference is either a scenario code or a 1-based position in the list.
+func FindScenario(scenarios []Scenario, ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(scenarios) {
+			return 0, fmt.Errorf("scenario %d out of range 1 to %d", n, len(scenarios))
+		}
+		return n - 1, nil
+	}
+
+	code, err := ParseCode(ref)
+	if err != nil {
+		return 0, err
+	}
+	want := code.String()
+	for i, s := range scenarios {
+		if s.Code == "" {
+			continue
+		}
+		if c, err := ParseCode(s.Code); err == nil && c.String() == want {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no scenario with code %s", want)
+}
+
+// ParseRange reads a selection of list positions such as "101-164" or
+// "1,5,9-12" among n scenarios: 1-based positions and inclusive ranges of
+// them, separated by commas. It returns the 0-based indexes selected, in
+// order and without repeats.
+func ParseRange(spec string, n int) ([]int, error) {
+	selected := make([]bool, n)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("bad range %q: %q is not a number or two numbers joined by -", spec, part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("bad range %q: %q is not a number or two numbers joined by -", spec, part)
+			}
+		}
+		if first > last {
+			return nil, fmt.Errorf("bad range %q: %q runs backwards", spec, part)
+		}
+		if first < 1 || last > n {
+			return nil, fmt.Errorf("bad range %q: %q is out of range 1 to %d", spec, part, n)
+		}
+		for i := first; i <= last; i++ {
+			selected[i-1] = true
+		}
+	}
+	var indexes []int
+	for i, ok := range selected {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,114 @@
+// Package combinatorics enumerates the cartesian product of dimensions,
+// each taking the values 0 to its size less one, a tuple at a time:
+//
+//	for t := range combinatorics.Product(
+//		combinatorics.Dim{Name: "AB", Size: 5},
+//		combinatorics.Dim{Name: "SW", Size: 3, Needs: "AB"},
+//		combinatorics.Dim{Name: "C", Size: 4},
+//	) {
+//		fmt.Println(t)
+//	}
+//
+// A dimension may need an earlier one: it only takes values other than 0
+// when that one does, so a strength is only varied when there is an edge
+// to be strong. Tuples are made as they are asked for, so a product far
+// too large to hold can still be walked, or counted with Count.
+package combinatorics
+
+import (
+	"iter"
+	"math/big"
+)
+
+// Dim describes one position of the tuples.
+type Dim struct {
+	// Name identifies the dimension to those needing it.
+	Name string
+	// Size is how many values the dimension takes, from 0.
+	Size int
+	// Needs is the name of an earlier dimension that must be other than 0
+	// for this one to take other than 0, or "" for none. A name of no
+	// earlier dimension is ignored.
+	Needs string
+}
+
+// Product returns the tuples of the product of dims in order, varying the
+// last dimension fastest. The slice yielded is reused for the next tuple,
+// so copy it to keep it. A dimension of size 0 leaves the product empty.
+func Product(dims ...Dim) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		needs := needed(dims)
+		values := make([]int, len(dims))
+		var next func(i int) bool
+		next = func(i int) bool {
+			if i == len(dims) {
+				return yield(values)
+			}
+			n := dims[i].Size
+			if j := needs[i]; j >= 0 && values[j] == 0 {
+				n = min(n, 1)
+			}
+			for v := range n {
+				values[i] = v
+				if !next(i + 1) {
+					return false
+				}
+			}
+			return true
+		}
+		next(0)
+	}
+}
+
+// Count returns how many tuples Product makes of dims, worked out without
+// making them, as there can be far too many to. Dimensions that others
+// need must not need any themselves.
+func Count(dims ...Dim) *big.Int {
+	// a dimension that others need counts its value 0 once for every
+	// combination of the dimensions needing it held at 0, or left empty
+	// by a size of 0, and each other value once for every combination of
+	// them
+	needs := needed(dims)
+	zeros := make([]*big.Int, len(dims))
+	dependents := make([]*big.Int, len(dims))
+	for i, d := range dims {
+		j := needs[i]
+		if j < 0 {
+			continue
+		}
+		if dependents[j] == nil {
+			zeros[j], dependents[j] = big.NewInt(1), big.NewInt(1)
+		}
+		zeros[j].Mul(zeros[j], big.NewInt(int64(min(d.Size, 1))))
+		dependents[j].Mul(dependents[j], big.NewInt(int64(d.Size)))
+	}
+	total := big.NewInt(1)
+	for i, d := range dims {
+		if needs[i] >= 0 {
+			continue
+		}
+		n := big.NewInt(int64(d.Size))
+		if dep := dependents[i]; dep != nil && d.Size > 0 {
+			n.Mul(big.NewInt(int64(d.Size-1)), dep)
+			n.Add(n, zeros[i])
+		}
+		total.Mul(total, n)
+	}
+	return total
+}
+
+// needed returns the index of the dimension each of dims needs, or -1.
+func needed(dims []Dim) []int {
+	index := map[string]int{}
+	needs := make([]int, len(dims))
+	for i, d := range dims {
+		needs[i] = -1
+		if j, ok := index[d.Needs]; ok && d.Needs != "" {
+			needs[i] = j
+		}
+		if _, ok := index[d.Name]; !ok {
+			index[d.Name] = i
+		}
+	}
+	return needs
+}
@@ -0,0 +1,43 @@
+package combinatorics_test
+
+import (
+	"testing"
+
+	"github.com/arran4/interactions/combinatorics"
+)
+
+// TestCountMatchesProduct checks that Count gives as many tuples as
+// walking Product makes, whatever the sizes and needs of the dimensions.
+func TestCountMatchesProduct(t *testing.T) {
+	type Dim = combinatorics.Dim
+	tests := []struct {
+		name string
+		dims []Dim
+	}{
+		{"no dimensions", nil},
+		{"one dimension", []Dim{{Name: "A", Size: 5}}},
+		{"independent dimensions", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 3}, {Name: "C", Size: 4}}},
+		{"independent dimension of size 0", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 0}}},
+		{"dependent dimension", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 3, Needs: "A"}, {Name: "C", Size: 4}}},
+		{"dependent dimension of size 0", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 0, Needs: "A"}, {Name: "C", Size: 4}}},
+		{"dependent dimension of size 1", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 1, Needs: "A"}}},
+		{"several dependents", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 3, Needs: "A"}, {Name: "C", Size: 2, Needs: "A"}}},
+		{"several dependents, one of size 0", []Dim{{Name: "A", Size: 5}, {Name: "B", Size: 3, Needs: "A"}, {Name: "C", Size: 0, Needs: "A"}}},
+		{"needed dimension of size 0", []Dim{{Name: "A", Size: 0}, {Name: "B", Size: 3, Needs: "A"}}},
+		{"needed dimension of size 1", []Dim{{Name: "A", Size: 1}, {Name: "B", Size: 3, Needs: "A"}}},
+		{"two needed dimensions", []Dim{{Name: "A", Size: 4}, {Name: "B", Size: 2, Needs: "A"}, {Name: "C", Size: 3}, {Name: "D", Size: 5, Needs: "C"}}},
+		{"need of no earlier dimension", []Dim{{Name: "A", Size: 3, Needs: "B"}, {Name: "B", Size: 4}}},
+		{"need of an unknown dimension", []Dim{{Name: "A", Size: 3, Needs: "Z"}, {Name: "B", Size: 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			walked := int64(0)
+			for range combinatorics.Product(tt.dims...) {
+				walked++
+			}
+			if got := combinatorics.Count(tt.dims...); !got.IsInt64() || got.Int64() != walked {
+				t.Errorf("Count = %v, Product made %d tuples", got, walked)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package interactions
+
+import (
+	"image"
+	"strconv"
+)
+
+// DrawContents draws a table of contents for a grid split across pages: a
+// row for each scenario with its number, code, title and subtitle, and
+// where it is, pages[i] naming the page or file of scenarios[i]. The
+// numbers are those set by WithNumbers, as on the panels.
+func DrawContents(scenarios []Scenario, pages []string, th Theme, opts ...Option) *image.RGBA {
+	o := collectOptions(opts)
+	rows := [][]string{{o.tr("No."), o.tr("Code"), o.tr("Title"), o.tr("Page")}}
+	for i, s := range scenarios {
+		page := ""
+		if i < len(pages) {
+			page = pages[i]
+		}
+		title := s.Title
+		if s.Subtitle != "" {
+			title += " - " + s.Subtitle
+		}
+		rows = append(rows, []string{strconv.Itoa(o.caption(i).number), s.Code, title, page})
+	}
+
+	// each column is as wide as its widest cell
+	columns := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for c, cell := range row {
+			columns[c] = max(columns[c], textWidth(cell))
+		}
+	}
+	tableWidth := 2*legendPadding + (len(columns)-1)*contentsColumnGap
+	for _, w := range columns {
+		tableWidth += w
+	}
+
+	title := o.gridTitle(scenarios) + o.tr(" (contents)")
+	m := o.geometry().margin
+	width := max(tableWidth, textWidth(title)) + 2*m
+	table := image.Rect(m, m+headerHeight, width-m, m+headerHeight+2*legendPadding+len(rows)*contentsRowHeight)
+	canvas := newCanvas(image.Rect(0, 0, width, table.Max.Y+m+o.footerHeight()))
+	fillRect(canvas, canvas.Bounds(), th.Background)
+	drawTitle(canvas, width, title, th, o)
+	fillRect(canvas, table, th.Panel)
+	drawRectBorder(canvas, table, th.LegendBorder, o.strokeWidth())
+
+	for r, row := range rows {
+		y := table.Min.Y + legendPadding + r*contentsRowHeight + 14
+		col := th.Text
+		if r == 0 {
+			col = th.Title
+		}
+		x := table.Min.X + legendPadding
+		for c, cell := range row {
+			drawLabel(canvas, cell, x, y, col)
+			x += columns[c] + contentsColumnGap
+		}
+	}
+	o.drawStamps(canvas, canvas.Bounds(), th)
+	return o.finish(canvas)
+}
+
+const (
+	// contentsRowHeight is the height of each row of a table of contents,
+	// and contentsColumnGap the space between its columns.
+	contentsRowHeight = 20
+	contentsColumnGap = 20
+)
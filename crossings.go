@@ -0,0 +1,136 @@
+package interactions
+
+import (
+	"slices"
+)
+
+// orderSweeps is how many times orderRows sweeps down and back up the
+// rows.
+const orderSweeps = 4
+
+// orderRows reorders the nodes within each of rows to cross fewer of links,
+// the pairs of nodes joined by a line, by the barycenter heuristic: each
+// node moves to the average place of the nodes it is linked to in the row
+// before, sweeping down the rows and back up. Only lines between
+// neighbouring rows are counted. A new order is kept only when it crosses
+// strictly fewer lines, so rows that cannot be improved keep the order
+// they came in.
+func orderRows(rows [][]string, links [][2]string) [][]string {
+	best := make([][]string, len(rows))
+	for k, row := range rows {
+		best[k] = slices.Clone(row)
+	}
+	fewest := crossings(best, links)
+	if fewest == 0 {
+		return best
+	}
+
+	order := make([][]string, len(best))
+	for k, row := range best {
+		order[k] = slices.Clone(row)
+	}
+	for range orderSweeps {
+		for k := 1; k < len(order); k++ {
+			sortByBarycenter(order[k], order[k-1], links)
+		}
+		for k := len(order) - 2; k >= 0; k-- {
+			sortByBarycenter(order[k], order[k+1], links)
+		}
+		if n := crossings(order, links); n < fewest {
+			fewest = n
+			for k, row := range order {
+				best[k] = slices.Clone(row)
+			}
+		}
+	}
+	return best
+}
+
+// sortByBarycenter orders row by the average place in fixed of the nodes
+// each is linked to; nodes linked to none of them keep their place.
+func sortByBarycenter(row, fixed []string, links [][2]string) {
+	place := map[string]int{}
+	for i, name := range fixed {
+		place[name] = i
+	}
+	center := map[string]float64{}
+	for i, name := range row {
+		sum, n := 0.0, 0
+		for _, l := range links {
+			other := ""
+			switch name {
+			case l[0]:
+				other = l[1]
+			case l[1]:
+				other = l[0]
+			}
+			if p, ok := place[other]; ok {
+				sum += float64(p)
+				n++
+			}
+		}
+		if n == 0 {
+			// in the same proportion of the way along as now
+			center[name] = float64(i) * float64(max(len(fixed)-1, 0)) / float64(max(len(row)-1, 1))
+			continue
+		}
+		center[name] = sum / float64(n)
+	}
+	slices.SortStableFunc(row, func(a, b string) int {
+		switch {
+		case center[a] < center[b]:
+			return -1
+		case center[a] > center[b]:
+			return 1
+		}
+		return 0
+	})
+}
+
+// crossings counts the pairs of links between neighbouring rows that cross.
+func crossings(rows [][]string, links [][2]string) int {
+	row, place := map[string]int{}, map[string]int{}
+	for k, r := range rows {
+		for i, name := range r {
+			row[name], place[name] = k, i
+		}
+	}
+	// each link as its places in row k and row k+1
+	between := map[int][][2]int{}
+	for _, l := range links {
+		a, okA := row[l[0]]
+		b, okB := row[l[1]]
+		if !okA || !okB {
+			continue
+		}
+		switch b - a {
+		case 1:
+			between[a] = append(between[a], [2]int{place[l[0]], place[l[1]]})
+		case -1:
+			between[b] = append(between[b], [2]int{place[l[1]], place[l[0]]})
+		}
+	}
+	n := 0
+	for _, ls := range between {
+		for i := range ls {
+			for j := i + 1; j < len(ls); j++ {
+				if (ls[i][0]-ls[j][0])*(ls[i][1]-ls[j][1]) < 0 {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// edgeLinks are the pairs of nodes joined by the edges of s, other than
+// self-loops.
+func edgeLinks(s Scenario) [][2]string {
+	var links [][2]string
+	for _, e := range s.Edges {
+		if e.From != e.To {
+			links = append(links, [2]string{e.From, e.To})
+		}
+	}
+	return links
+}
@@ -0,0 +1,103 @@
+package interactions
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// WithLayoutDebug draws over each panel, in translucent colours, what its
+// layout works to: the rows the nodes are placed in, the padding text
+// keeps from the border and the area the node centres stay within, each
+// node's bounding box and the baseline of each line of text. It is for
+// working on layouts and finding out why text overflows.
+func WithLayoutDebug() Option {
+	return func(o *options) { o.debugLayout = true }
+}
+
+// The colours of WithLayoutDebug.
+var (
+	debugRowColor      = color.NRGBA{R: 0xd0, G: 0x00, B: 0xd0, A: 0x80}
+	debugPaddingColor  = color.NRGBA{R: 0x00, G: 0x80, B: 0xff, A: 0x80}
+	debugNodeColor     = color.NRGBA{R: 0x00, G: 0xa0, B: 0x00, A: 0x80}
+	debugBaselineColor = color.NRGBA{R: 0xff, G: 0x30, B: 0x00, A: 0x80}
+)
+
+// drawLayoutDebug draws the WithLayoutDebug overlay of the panel of s at
+// rect, captioned by c and laid out as layout.
+func (o options) drawLayoutDebug(img *image.RGBA, rect image.Rectangle, s Scenario, geo geometry, c panelCaption, layout panelLayout) {
+	text, titleY, subtitleY, shift := c.text(s, rect, geo)
+	area := layoutArea(rect, shift, geo)
+
+	// the rows, as far apart as the two of the layered layout, down to the
+	// lowest
+	for y := area.Min.Y; y <= layout.lowerY && area.Dy() > 0; y += area.Dy() {
+		debugRect(img, image.Rect(rect.Min.X+1, y, rect.Max.X-1, y+1), debugRowColor)
+	}
+	debugBox(img, rect.Inset(textPadding), debugPaddingColor)
+	debugBox(img, area, debugPaddingColor)
+
+	for _, name := range s.Nodes {
+		pt, ok := layout.positions[name]
+		if !ok {
+			continue
+		}
+		sh := layout.shape(name)
+		if sh.box() {
+			debugBox(img, image.Rect(pt.X-sh.halfW, pt.Y-sh.halfH, pt.X+sh.halfW+1, pt.Y+sh.halfH+1), debugNodeColor)
+		} else {
+			r := int(sh.rim(1, 0))
+			debugBox(img, image.Rect(pt.X-r, pt.Y-r, pt.X+r+1, pt.Y+r+1), debugNodeColor)
+		}
+		for _, l := range o.nodeLabel(name, sh, pt) {
+			debugBaseline(img, l.text, l.x, l.y)
+		}
+	}
+
+	room := lineRoom(rect.Dx())
+	debugPanelLines(img, o.fitLines(text.title, room), rightToLeft(s.Title), rect, titleY)
+	debugPanelLines(img, o.fitLines(text.subtitle, room), rightToLeft(s.Subtitle), rect, subtitleY)
+	debugPanelLines(img, o.fitLines(wrapDescription(s, rect.Dx()), room), rightToLeft(s.Description), rect, descriptionY(rect, geo))
+	if c.Code && s.Code != "" {
+		code := o.fit(s.Code, codeRoom(rect.Dx()))
+		x, y := codePos(rect, code)
+		debugBaseline(img, code, x, y)
+	}
+	if c.Index {
+		x, y := indexPos(rect)
+		debugBaseline(img, ListNumber(c.number), x, y)
+	}
+	if c.badge != "" {
+		x, y := badgePos(rect, c.badge)
+		debugBaseline(img, c.badge, x, y)
+	}
+}
+
+// debugPanelLines marks the baselines of lines of panel text drawn as
+// drawPanelLines draws them.
+func debugPanelLines(img *image.RGBA, lines []string, rtl bool, rect image.Rectangle, y int) {
+	for i, l := range lines {
+		debugBaseline(img, l, panelLineX(l, rtl, rect), y+i*lineHeight)
+	}
+}
+
+// debugBaseline marks the baseline of text drawn from (x, y).
+func debugBaseline(img *image.RGBA, text string, x, y int) {
+	debugRect(img, image.Rect(x, y, x+max(textWidth(text), 1), y+1), debugBaselineColor)
+}
+
+// debugBox outlines r translucently.
+func debugBox(img *image.RGBA, r image.Rectangle, c color.Color) {
+	if r.Empty() {
+		return
+	}
+	debugRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), c)
+	debugRect(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), c)
+	debugRect(img, image.Rect(r.Min.X, r.Min.Y+1, r.Min.X+1, r.Max.Y-1), c)
+	debugRect(img, image.Rect(r.Max.X-1, r.Min.Y+1, r.Max.X, r.Max.Y-1), c)
+}
+
+// debugRect fills r translucently, blending c over what is there.
+func debugRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Over)
+}
@@ -0,0 +1,102 @@
+package interactions
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Describe returns a plain English description of what the panel of s
+// shows, for alt text and screen readers: a sentence for each edge, then
+// the order the nodes happen in, as in "A influences B. C influences A. C
+// comes before A and B."
+func Describe(s Scenario) string {
+	var sentences []string
+	linked := map[string]bool{}
+	for _, e := range s.Edges {
+		sentences = append(sentences, describeEdge(e))
+		linked[e.From], linked[e.To] = true, true
+	}
+	for _, n := range s.Nodes {
+		if !linked[n] {
+			sentences = append(sentences, n+" is not linked to anything.")
+		}
+	}
+
+	early, late, processes := chronology(s)
+	// nodes without edges sit in the upper row too, but happen at no time
+	// in particular
+	early = slices.DeleteFunc(early, func(n string) bool { return !linked[n] })
+	if len(early) > 0 && len(late) > 0 {
+		verb := "comes"
+		if len(early) > 1 {
+			verb = "come"
+		}
+		sentences = append(sentences, fmt.Sprintf("%s %s before %s.", joinNames(early), verb, joinNames(late)))
+	}
+	for _, n := range processes {
+		sp := s.Spans[n]
+		sentences = append(sentences, fmt.Sprintf("%s is a process running from %s to %s of the time axis.", n, percent(sp.Start), percent(sp.End)))
+	}
+	return strings.Join(sentences, " ")
+}
+
+// describeEdge is a sentence saying what e means.
+func describeEdge(e Edge) string {
+	verb, mutual := "influences", "influence"
+	switch e.Kind {
+	case Inhibition:
+		verb, mutual = "inhibits", "inhibit"
+	case Predation:
+		verb = "preys on"
+	}
+
+	var s string
+	switch {
+	case e.From == e.To && e.Kind == Influence:
+		s = e.From + " reinforces itself"
+	case e.From == e.To:
+		s = e.From + " " + verb + " itself"
+	case e.Bidirectional && e.Kind != Predation:
+		s = e.From + " and " + e.To + " " + mutual + " each other"
+	default:
+		s = e.From + " " + verb + " " + e.To
+	}
+
+	var notes []string
+	if e.Weight != 0 {
+		notes = append(notes, "weight "+strconv.FormatFloat(e.Weight, 'g', -1, 64))
+	}
+	if e.Style == Dashed {
+		notes = append(notes, "after a delay")
+	}
+	switch {
+	case e.Conditional:
+		notes = append(notes, "only under some condition")
+	case e.uncertain():
+		notes = append(notes, "with probability "+strconv.FormatFloat(e.Probability, 'g', -1, 64))
+	case e.Style == Dotted:
+		notes = append(notes, "uncertain")
+	}
+	if e.Polarity != "" {
+		notes = append(notes, "ecological signs "+e.Polarity)
+	}
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return s + "."
+}
+
+// joinNames lists names in English, as in "A, B and C".
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// percent writes a point on the time axis as a percentage.
+func percent(t float64) string {
+	return strconv.FormatFloat(t*100, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,114 @@
+package interactions
+
+import (
+	"maps"
+	"slices"
+)
+
+// ChangeKind says how a scenario differs between two sets.
+type ChangeKind int
+
+const (
+	// Added is a scenario only in the new set.
+	Added ChangeKind = iota
+	// Removed is a scenario only in the old set.
+	Removed
+	// Changed is a scenario in both sets that differs between them.
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+// ScenarioChange is one difference found by DiffScenarios.
+type ScenarioChange struct {
+	Kind ChangeKind
+	// Old and New are the scenario in each set; Old is nil for an added
+	// scenario and New for a removed one.
+	Old, New *Scenario
+	// Fields names what differs in a changed scenario: title, subtitle,
+	// nodes, edges, spans or dimensions.
+	Fields []string
+}
+
+// DiffScenarios compares two sets of scenarios, matching them by code or,
+// for scenarios without one, by title. Added and changed scenarios come in
+// the order of the new set, followed by the removed ones in the order of
+// the old.
+func DiffScenarios(old, new []Scenario) []ScenarioChange {
+	oldByKey := map[string]int{}
+	for i, s := range old {
+		if _, dup := oldByKey[diffKey(s)]; !dup {
+			oldByKey[diffKey(s)] = i
+		}
+	}
+
+	var changes []ScenarioChange
+	seen := map[string]bool{}
+	for i := range new {
+		key := diffKey(new[i])
+		seen[key] = true
+		j, ok := oldByKey[key]
+		if !ok {
+			changes = append(changes, ScenarioChange{Kind: Added, New: &new[i]})
+			continue
+		}
+		if fields := changedFields(old[j], new[i]); fields != nil {
+			changes = append(changes, ScenarioChange{Kind: Changed, Old: &old[j], New: &new[i], Fields: fields})
+		}
+	}
+	for i := range old {
+		if !seen[diffKey(old[i])] {
+			changes = append(changes, ScenarioChange{Kind: Removed, Old: &old[i]})
+		}
+	}
+	return changes
+}
+
+// diffKey identifies a scenario across sets: its code, in canonical form,
+// or its title.
+func diffKey(s Scenario) string {
+	if c, err := ParseCode(s.Code); err == nil {
+		return "code:" + c.String()
+	}
+	return "title:" + s.Title
+}
+
+// changedFields lists the fields that differ between a and b, or nil when
+// they are the same.
+func changedFields(a, b Scenario) []string {
+	var fields []string
+	if a.Title != b.Title {
+		fields = append(fields, "title")
+	}
+	if a.Subtitle != b.Subtitle {
+		fields = append(fields, "subtitle")
+	}
+	if a.Description != b.Description {
+		fields = append(fields, "description")
+	}
+	if !slices.Equal(a.Nodes, b.Nodes) {
+		fields = append(fields, "nodes")
+	}
+	if !slices.Equal(a.Edges, b.Edges) {
+		fields = append(fields, "edges")
+	}
+	if !maps.Equal(a.Spans, b.Spans) {
+		fields = append(fields, "spans")
+	}
+	if !maps.Equal(a.Sizes, b.Sizes) {
+		fields = append(fields, "sizes")
+	}
+	if !maps.Equal(a.Dimensions, b.Dimensions) {
+		fields = append(fields, "dimensions")
+	}
+	return fields
+}
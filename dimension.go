@@ -0,0 +1,97 @@
+package interactions
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"strings"
+)
+
+// Dimension is a dimension of the taxonomy declared in a scenario file,
+// which Scenarios crosses with the built-in ones so that every scenario
+// comes in a variant for each of its values:
+//
+//	dimensions:
+//	  - name: season
+//	    code: SE
+//	    values:
+//	      - name: summer
+//	      - name: winter
+//	        title: in winter
+//	        edges: [{from: W, to: A, kind: inhibition}]
+//
+// The first value is the default, left out of the codes as the built-in
+// optional dimensions are, so a file of dimensions keeps the codes of the
+// scenarios it adds to.
+type Dimension struct {
+	// Name is the field the dimension records on scenarios for queries,
+	// as in "season=winter".
+	Name string `yaml:"name" json:"name"`
+	// Code is the segment of scenario codes naming the dimension, in
+	// upper-case letters; the name in upper case by default.
+	Code   string           `yaml:"code,omitempty" json:"code,omitempty"`
+	Values []DimensionValue `yaml:"values" json:"values"`
+}
+
+// DimensionValue is one value of a Dimension and what it adds to a
+// scenario.
+type DimensionValue struct {
+	// Name is the value recorded on scenarios for queries.
+	Name string `yaml:"name" json:"name"`
+	// Title is added to the scenario's title after a comma, as the
+	// built-in optional dimensions qualify it, unless it is empty.
+	Title string          `yaml:"title,omitempty" json:"title,omitempty"`
+	Nodes []string        `yaml:"nodes,omitempty" json:"nodes,omitempty"`
+	Edges []Edge          `yaml:"edges,omitempty" json:"edges,omitempty"`
+	Spans map[string]Span `yaml:"spans,omitempty" json:"spans,omitempty"`
+}
+
+// code is the code segment of d.
+func (d Dimension) code() string {
+	if d.Code != "" {
+		return strings.ToUpper(d.Code)
+	}
+	return strings.ToUpper(d.Name)
+}
+
+// dimension is d as a dimension of the generated taxonomy.
+func (d Dimension) dimension() dimension {
+	names := make([]string, len(d.Values))
+	for i, v := range d.Values {
+		names[i] = v.Name
+	}
+	return dimension{code: d.code(), field: d.Name, names: names, values: len(d.Values), optional: true,
+		build: func(dr *draft, v int) {
+			value := d.Values[v]
+			for _, name := range append(append([]string(nil), value.Nodes...), edgeNodes(value.Edges)...) {
+				dr.addNode(name)
+			}
+			dr.edges = append(dr.edges, value.Edges...)
+			if len(value.Spans) > 0 {
+				if dr.spans == nil {
+					dr.spans = map[string]Span{}
+				}
+				maps.Copy(dr.spans, value.Spans)
+			}
+			dr.qualify(value.Title)
+		}}
+}
+
+// LoadDimensionsFile reads the dimensions declared in a scenario file,
+// for the Dimensions GenOption. The file may have scenarios and sets as
+// well, which are not read.
+func LoadDimensionsFile(path string) ([]Dimension, error) {
+	return LoadDimensionsFileFS(nil, path)
+}
+
+// LoadDimensionsFileFS is LoadDimensionsFile reading the file name of fsys.
+func LoadDimensionsFileFS(fsys fs.FS, name string) ([]Dimension, error) {
+	doc, _, err := readScenarioFile(fsys, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Dimensions) == 0 {
+		return nil, fmt.Errorf("%s: no dimensions", name)
+	}
+	return doc.Dimensions, nil
+}
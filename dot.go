@@ -0,0 +1,613 @@
+package interactions
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseDOT reads the directed graphs of a Graphviz DOT document as
+// scenarios, one per graph, so sketches made for Graphviz can be drawn in
+// this package's style. It understands a subset of DOT:
+//
+//	digraph supply {
+//	  label = "Supply chain"; subtitle = "The supplier drives both plants"
+//	  S [label = "Supplier"]
+//	  A [shape = box, start = 0.2, end = 0.8]
+//	  S -> {A B}
+//	  A -> B [arrowhead = tee, style = dashed]
+//	  {rank = same; A; B}
+//	}
+//
+// The graph's label (or else its name) is the title, and the non-standard
+// subtitle, description and code attributes fill in the rest of the
+// scenario. A node's label replaces its name. Box-shaped nodes are
+// processes, running from their start to their end attribute (0 to 1 when
+// missing); other shapes are events. Edges take their style (solid, dashed
+// or dotted), arrowhead=tee for an inhibition, dir=both or dir=back, and a
+// kind naming any edge kind.
+// Rank attributes order the nodes: rank=min and source nodes come first,
+// max and sink nodes last, and nodes of a rank=same subgraph together.
+// Other attributes and ports are ignored; undirected graphs are an error.
+func ParseDOT(src []byte) ([]Scenario, error) {
+	toks, err := tokenizeDOT(string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{toks: toks}
+	var scenarios []Scenario
+	for p.peek().kind != dotEOF {
+		g, err := p.parseGraph()
+		if err != nil {
+			return nil, err
+		}
+		s, err := g.scenario()
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, s)
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("dot: no graphs")
+	}
+	return scenarios, nil
+}
+
+// LoadDOTFile reads the scenarios in a DOT file; see ParseDOT.
+func LoadDOTFile(path string) ([]Scenario, error) {
+	return LoadDOTFileFS(nil, path)
+}
+
+// LoadDOTFileFS is LoadDOTFile reading the file name of fsys.
+func LoadDOTFileFS(fsys fs.FS, name string) ([]Scenario, error) {
+	data, err := readFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	scenarios, err := ParseDOT(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return scenarios, nil
+}
+
+type dotTokenKind int
+
+const (
+	dotEOF dotTokenKind = iota
+	// dotID is a name, number, quoted string or HTML string
+	dotID
+	dotEdgeOp
+	dotPunct
+)
+
+type dotToken struct {
+	kind dotTokenKind
+	text string
+	// quoted IDs are never keywords
+	quoted bool
+	line   int
+}
+
+func (t dotToken) String() string {
+	if t.kind == dotEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// tokenizeDOT splits DOT source into IDs, edge operators and punctuation,
+// dropping comments and preprocessor lines.
+func tokenizeDOT(src string) ([]dotToken, error) {
+	var toks []dotToken
+	line := 1
+	atLineStart := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			atLineStart = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == '#' && atLineStart:
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		atLineStart = false
+
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("dot: line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(src[i:], "->") || strings.HasPrefix(src[i:], "--"):
+			toks = append(toks, dotToken{kind: dotEdgeOp, text: src[i : i+2], line: line})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:", rune(c)):
+			toks = append(toks, dotToken{kind: dotPunct, text: string(c), line: line})
+			i++
+		case c == '"':
+			start := line
+			var sb strings.Builder
+			i++
+			for ; i < len(src) && src[i] != '"'; i++ {
+				switch {
+				case src[i] == '\\' && i+1 < len(src) && src[i+1] == '"':
+					sb.WriteByte('"')
+					i++
+				case src[i] == '\\' && i+1 < len(src) && src[i+1] == '\n':
+					// line continuation
+					line++
+					i++
+				default:
+					if src[i] == '\n' {
+						line++
+					}
+					sb.WriteByte(src[i])
+				}
+			}
+			if i == len(src) {
+				return nil, fmt.Errorf("dot: line %d: unterminated string", start)
+			}
+			i++
+			// "a" + "b" joins strings
+			if n := len(toks); n >= 2 && toks[n-1].kind == dotPunct && toks[n-1].text == "+" && toks[n-2].quoted {
+				toks[n-2].text += sb.String()
+				toks = toks[:n-1]
+				continue
+			}
+			toks = append(toks, dotToken{kind: dotID, text: sb.String(), quoted: true, line: start})
+		case c == '+':
+			toks = append(toks, dotToken{kind: dotPunct, text: "+", line: line})
+			i++
+		case c == '<':
+			start := line
+			depth := 0
+			j := i
+			for ; j < len(src); j++ {
+				if src[j] == '<' {
+					depth++
+				} else if src[j] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				} else if src[j] == '\n' {
+					line++
+				}
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("dot: line %d: unterminated HTML string", start)
+			}
+			toks = append(toks, dotToken{kind: dotID, text: src[i+1 : j], quoted: true, line: start})
+			i = j + 1
+		default:
+			start := i
+			for i < len(src) && isDOTIDByte(src[i], i == start) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("dot: line %d: unexpected %q", line, c)
+			}
+			toks = append(toks, dotToken{kind: dotID, text: src[start:i], line: line})
+		}
+	}
+	return append(toks, dotToken{kind: dotEOF, line: line}), nil
+}
+
+// isDOTIDByte reports whether c can be part of an unquoted ID: a name of
+// letters, digits and underscores, or a number. Bytes from 0x80 up are the
+// parts of UTF-8 letters.
+func isDOTIDByte(c byte, first bool) bool {
+	switch {
+	case c == '_' || c == '.' || c >= 0x80:
+		return true
+	case c == '-':
+		return first
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return false
+}
+
+// dotGraph is a parsed graph, before it becomes a scenario.
+type dotGraph struct {
+	name  string
+	attrs map[string]string
+	// nodes are the node IDs in order of first appearance
+	nodes     []string
+	nodeAttrs map[string]map[string]string
+	edges     []dotEdge
+	// ranks holds the rank subgraphs in order
+	ranks []dotRank
+	line  int
+}
+
+type dotEdge struct {
+	from, to string
+	attrs    map[string]string
+}
+
+type dotRank struct {
+	rank  string
+	nodes []string
+}
+
+// dotScope is the default attributes in force within a graph or subgraph.
+type dotScope struct {
+	node, edge map[string]string
+}
+
+type dotParser struct {
+	toks   []dotToken
+	pos    int
+	graph  *dotGraph
+	strict bool
+}
+
+func (p *dotParser) peek() dotToken { return p.toks[p.pos] }
+
+func (p *dotParser) next() dotToken {
+	t := p.toks[p.pos]
+	if t.kind != dotEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the unquoted keyword kw.
+func (p *dotParser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == dotID && !t.quoted && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// punct consumes the next token if it is the punctuation s.
+func (p *dotParser) punct(s string) bool {
+	if t := p.peek(); t.kind == dotPunct && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *dotParser) expect(s string) error {
+	if t := p.next(); t.kind != dotPunct || t.text != s {
+		return fmt.Errorf("dot: line %d: expected %q, found %s", t.line, s, t)
+	}
+	return nil
+}
+
+func (p *dotParser) parseGraph() (*dotGraph, error) {
+	start := p.peek()
+	p.strict = p.keyword("strict")
+	switch {
+	case p.keyword("digraph"):
+	case p.keyword("graph"):
+		return nil, fmt.Errorf("dot: line %d: undirected graphs are not supported; use digraph", start.line)
+	default:
+		return nil, fmt.Errorf("dot: line %d: expected digraph, found %s", start.line, p.peek())
+	}
+
+	p.graph = &dotGraph{attrs: map[string]string{}, nodeAttrs: map[string]map[string]string{}, line: start.line}
+	if t := p.peek(); t.kind == dotID {
+		p.graph.name = p.next().text
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	scope := dotScope{node: map[string]string{}, edge: map[string]string{}}
+	if _, err := p.parseStatements(scope, p.graph.attrs); err != nil {
+		return nil, err
+	}
+	return p.graph, nil
+}
+
+// parseStatements parses statements up to the closing brace of a graph or
+// subgraph, setting graph attributes in attrs. It returns the nodes the
+// statements mention.
+func (p *dotParser) parseStatements(scope dotScope, attrs map[string]string) ([]string, error) {
+	var members []string
+	for !p.punct("}") {
+		t := p.peek()
+		switch {
+		case t.kind == dotEOF:
+			return nil, fmt.Errorf("dot: line %d: missing '}'", t.line)
+		case p.punct(";"):
+			continue
+		case p.keyword("graph"):
+			a, err := p.parseAttrList()
+			if err != nil {
+				return nil, err
+			}
+			maps.Copy(attrs, a)
+		case p.keyword("node"):
+			a, err := p.parseAttrList()
+			if err != nil {
+				return nil, err
+			}
+			maps.Copy(scope.node, a)
+		case p.keyword("edge"):
+			a, err := p.parseAttrList()
+			if err != nil {
+				return nil, err
+			}
+			maps.Copy(scope.edge, a)
+		case t.kind == dotID && p.toks[p.pos+1].kind == dotPunct && p.toks[p.pos+1].text == "=":
+			p.pos += 2
+			v := p.next()
+			if v.kind != dotID {
+				return nil, fmt.Errorf("dot: line %d: expected a value for %s, found %s", v.line, t.text, v)
+			}
+			attrs[t.text] = v.text
+		default:
+			nodes, err := p.parseNodeOrEdge(scope)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, nodes...)
+		}
+	}
+	return members, nil
+}
+
+// parseNodeOrEdge parses a node statement, an edge statement or a
+// subgraph, returning the nodes it mentions.
+func (p *dotParser) parseNodeOrEdge(scope dotScope) ([]string, error) {
+	first, subgraph, err := p.parseEndpoint(scope)
+	if err != nil {
+		return nil, err
+	}
+	ends := [][]string{first}
+	for p.peek().kind == dotEdgeOp {
+		op := p.next()
+		if op.text == "--" {
+			return nil, fmt.Errorf("dot: line %d: undirected edge in a digraph", op.line)
+		}
+		end, _, err := p.parseEndpoint(scope)
+		if err != nil {
+			return nil, err
+		}
+		ends = append(ends, end)
+	}
+
+	var attrs map[string]string
+	if p.peek().kind == dotPunct && p.peek().text == "[" {
+		if attrs, err = p.parseAttrList(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ends) == 1 {
+		if !subgraph {
+			maps.Copy(p.graph.nodeAttrs[first[0]], attrs)
+		}
+		return first, nil
+	}
+
+	edgeAttrs := maps.Clone(scope.edge)
+	maps.Copy(edgeAttrs, attrs)
+	var all []string
+	for i := range len(ends) - 1 {
+		for _, from := range ends[i] {
+			for _, to := range ends[i+1] {
+				p.addEdge(from, to, edgeAttrs)
+			}
+		}
+	}
+	for _, end := range ends {
+		all = append(all, end...)
+	}
+	return all, nil
+}
+
+func (p *dotParser) addEdge(from, to string, attrs map[string]string) {
+	if p.strict {
+		for _, e := range p.graph.edges {
+			if e.from == from && e.to == to {
+				maps.Copy(e.attrs, attrs)
+				return
+			}
+		}
+	}
+	p.graph.edges = append(p.graph.edges, dotEdge{from: from, to: to, attrs: maps.Clone(attrs)})
+}
+
+// parseEndpoint parses a node ID, with an optional port, or a subgraph,
+// and returns the nodes it stands for.
+func (p *dotParser) parseEndpoint(scope dotScope) (nodes []string, subgraph bool, err error) {
+	t := p.peek()
+	if p.keyword("subgraph") || (t.kind == dotPunct && t.text == "{") {
+		if p.peek().kind == dotID {
+			p.next()
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, false, err
+		}
+		inner := dotScope{node: maps.Clone(scope.node), edge: maps.Clone(scope.edge)}
+		attrs := map[string]string{}
+		if nodes, err = p.parseStatements(inner, attrs); err != nil {
+			return nil, false, err
+		}
+		if rank := attrs["rank"]; rank != "" {
+			p.graph.ranks = append(p.graph.ranks, dotRank{rank: rank, nodes: nodes})
+		}
+		return nodes, true, nil
+	}
+
+	if t.kind != dotID {
+		return nil, false, fmt.Errorf("dot: line %d: expected a node or subgraph, found %s", t.line, t)
+	}
+	p.next()
+	// ports, as in A:n or A:port:n, do not affect the drawing
+	for p.punct(":") {
+		if port := p.next(); port.kind != dotID {
+			return nil, false, fmt.Errorf("dot: line %d: expected a port after ':', found %s", port.line, port)
+		}
+	}
+	if _, ok := p.graph.nodeAttrs[t.text]; !ok {
+		p.graph.nodes = append(p.graph.nodes, t.text)
+		p.graph.nodeAttrs[t.text] = maps.Clone(scope.node)
+	}
+	return []string{t.text}, false, nil
+}
+
+// parseAttrList parses one or more bracketed attribute lists.
+func (p *dotParser) parseAttrList() (map[string]string, error) {
+	attrs := map[string]string{}
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	for {
+		for !p.punct("]") {
+			key := p.next()
+			if key.kind != dotID {
+				return nil, fmt.Errorf("dot: line %d: expected an attribute name, found %s", key.line, key)
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value := p.next()
+			if value.kind != dotID {
+				return nil, fmt.Errorf("dot: line %d: expected a value for %s, found %s", value.line, key.text, value)
+			}
+			attrs[key.text] = value.text
+			if !p.punct(",") {
+				p.punct(";")
+			}
+		}
+		if !p.punct("[") {
+			return attrs, nil
+		}
+	}
+}
+
+// processShapes are the node shapes drawn as process boxes.
+var processShapes = []string{"box", "rect", "rectangle", "square"}
+
+// scenario converts g to a scenario.
+func (g *dotGraph) scenario() (Scenario, error) {
+	s := Scenario{
+		Title:       cmp.Or(g.attrs["label"], g.name),
+		Subtitle:    g.attrs["subtitle"],
+		Description: g.attrs["description"],
+		Code:        g.attrs["code"],
+	}
+	fail := func(format string, args ...any) (Scenario, error) {
+		return Scenario{}, fmt.Errorf("dot: graph at line %d: %s", g.line, fmt.Sprintf(format, args...))
+	}
+
+	name := func(id string) string {
+		return cmp.Or(g.nodeAttrs[id]["label"], id)
+	}
+	for _, id := range g.orderedNodes() {
+		s.Nodes = append(s.Nodes, name(id))
+		attrs := g.nodeAttrs[id]
+		if !slices.Contains(processShapes, strings.ToLower(attrs["shape"])) {
+			continue
+		}
+		span := Span{Start: 0, End: 1}
+		// in a fixed order, so a node with two bad times always reports the
+		// same one
+		for _, t := range []struct {
+			key string
+			v   *float64
+		}{{"start", &span.Start}, {"end", &span.End}} {
+			if attrs[t.key] == "" {
+				continue
+			}
+			f, err := strconv.ParseFloat(attrs[t.key], 64)
+			if err != nil {
+				return fail("node %s: bad %s %q", id, t.key, attrs[t.key])
+			}
+			*t.v = f
+		}
+		if s.Spans == nil {
+			s.Spans = map[string]Span{}
+		}
+		s.Spans[name(id)] = span
+	}
+
+	for _, de := range g.edges {
+		e := Edge{From: name(de.from), To: name(de.to)}
+		switch strings.ToLower(de.attrs["dir"]) {
+		case "both":
+			e.Bidirectional = true
+		case "back":
+			e.From, e.To = e.To, e.From
+		}
+		if de.attrs["arrowhead"] == "tee" {
+			e.Kind = Inhibition
+		}
+		if k := de.attrs["kind"]; k != "" {
+			if err := e.Kind.UnmarshalText([]byte(k)); err != nil {
+				return fail("edge %s -> %s: %v", de.from, de.to, err)
+			}
+		}
+		// styles such as bold or invis have no equivalent and draw solid
+		switch strings.ToLower(de.attrs["style"]) {
+		case "dashed":
+			e.Style = Dashed
+		case "dotted":
+			e.Style = Dotted
+		}
+		s.Edges = append(s.Edges, e)
+	}
+	return s, nil
+}
+
+// orderedNodes returns the node IDs in the order rank attributes give them:
+// min and source ranks first, then the rest in order of appearance with the
+// members of each same rank together, then max and sink ranks.
+func (g *dotGraph) orderedNodes() []string {
+	type place struct{ band, group, index int }
+	places := map[string]place{}
+	for i, id := range g.nodes {
+		places[id] = place{band: 1, group: i, index: i}
+	}
+	for _, r := range g.ranks {
+		band := 1
+		switch strings.ToLower(r.rank) {
+		case "min", "source":
+			band = 0
+		case "max", "sink":
+			band = 2
+		case "same":
+		default:
+			continue
+		}
+		group := len(g.nodes)
+		for _, id := range r.nodes {
+			group = min(group, places[id].group)
+		}
+		for _, id := range r.nodes {
+			places[id] = place{band: band, group: group, index: places[id].index}
+		}
+	}
+
+	nodes := slices.Clone(g.nodes)
+	slices.SortStableFunc(nodes, func(a, b string) int {
+		pa, pb := places[a], places[b]
+		return cmp.Or(cmp.Compare(pa.band, pb.band), cmp.Compare(pa.group, pb.group), cmp.Compare(pa.index, pb.index))
+	})
+	return nodes
+}
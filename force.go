@@ -0,0 +1,108 @@
+package interactions
+
+import (
+	"cmp"
+	"image"
+	"math"
+	"math/rand/v2"
+)
+
+// Force is a force-directed Layout for scenarios with many nodes, where the
+// two rows of the layered layout give long edges crossing each other:
+// every pair of nodes pushes apart, each edge pulls its ends together like
+// a spring, and the nodes settle where the forces balance. The nodes start
+// at random places, so the layout depends on Seed; the same seed always
+// gives the same layout.
+type Force struct {
+	Seed uint64
+	// Iterations is how many steps the nodes take towards balance, 300
+	// when zero.
+	Iterations int
+}
+
+// defaultForceIterations is the number of steps of a Force with no
+// Iterations.
+const defaultForceIterations = 300
+
+// Place places the nodes of s by the Fruchterman-Reingold method, keeping
+// them within area.
+func (f Force) Place(s Scenario, area image.Rectangle) map[string]image.Point {
+	n := len(s.Nodes)
+	if n == 0 {
+		return nil
+	}
+	w, h := float64(area.Dx()), float64(area.Dy())
+	index := map[string]int{}
+	x, y := make([]float64, n), make([]float64, n)
+	r := rand.New(rand.NewPCG(f.Seed, 0))
+	for i, name := range s.Nodes {
+		index[name] = i
+		x[i], y[i] = r.Float64()*w, r.Float64()*h
+	}
+	var springs [][2]int
+	for _, e := range s.Edges {
+		from, okFrom := index[e.From]
+		to, okTo := index[e.To]
+		if okFrom && okTo && from != to {
+			springs = append(springs, [2]int{from, to})
+		}
+	}
+
+	// k is the distance at which the push and pull of an edge balance
+	k := math.Sqrt(w * h / float64(n))
+	iterations := cmp.Or(f.Iterations, defaultForceIterations)
+	dx, dy := make([]float64, n), make([]float64, n)
+	for step := range iterations {
+		clear(dx)
+		clear(dy)
+		for i := range n {
+			for j := i + 1; j < n; j++ {
+				ux, uy, d := apart(x[i]-x[j], y[i]-y[j], i, j)
+				push := k * k / d
+				dx[i] += ux * push
+				dy[i] += uy * push
+				dx[j] -= ux * push
+				dy[j] -= uy * push
+			}
+		}
+		for _, sp := range springs {
+			i, j := sp[0], sp[1]
+			ux, uy, d := apart(x[i]-x[j], y[i]-y[j], i, j)
+			pull := d * d / k
+			dx[i] -= ux * pull
+			dy[i] -= uy * pull
+			dx[j] += ux * pull
+			dy[j] += uy * pull
+		}
+		// each step moves the nodes less, from a tenth of the area down
+		// to nothing
+		limit := math.Max(w, h) / 10 * float64(iterations-step) / float64(iterations)
+		for i := range n {
+			length := math.Hypot(dx[i], dy[i])
+			if length == 0 {
+				continue
+			}
+			move := math.Min(length, limit)
+			x[i] = math.Min(w, math.Max(0, x[i]+dx[i]/length*move))
+			y[i] = math.Min(h, math.Max(0, y[i]+dy[i]/length*move))
+		}
+	}
+
+	positions := map[string]image.Point{}
+	for i, name := range s.Nodes {
+		positions[name] = image.Pt(area.Min.X+int(math.Round(x[i])), area.Min.Y+int(math.Round(y[i])))
+	}
+	return positions
+}
+
+// apart returns the direction and distance from node j to node i, dx and
+// dy apart, nudging nodes in the same place apart in a direction fixed by
+// their indexes.
+func apart(dx, dy float64, i, j int) (ux, uy, d float64) {
+	d = math.Hypot(dx, dy)
+	if d < 0.01 {
+		angle := float64(i*7+j*13) * 0.7
+		return math.Cos(angle), math.Sin(angle), 0.01
+	}
+	return dx / d, dy / d, d
+}
@@ -0,0 +1,77 @@
+package interactions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// GeneratorOptions are the generation flags of the command line, such as
+// --ecology and --externals, as given to a Generator. A generator may
+// ignore those that mean nothing to its taxonomy.
+type GeneratorOptions struct {
+	// Strengths adds weak and strong variants of every A-B edge.
+	Strengths bool
+	// Delays adds a delayed (dashed) variant of every A-B edge.
+	Delays bool
+	// Uncertain adds variants of every A-B edge that only sometimes
+	// happen: by chance, or under some condition.
+	Uncertain bool
+	// Feedback adds self-reinforcement loops on A, B, or both.
+	Feedback bool
+	// Ecology adds competition and predation A-B patterns and labels every
+	// A-B edge with the signs of its ecological relation.
+	Ecology bool
+	// Timing adds A and B as processes related by meets, overlaps or
+	// contains.
+	Timing bool
+	// Chains adds indirect influence through a mediating actor, named after
+	// the last external one: chains through it and it as a common effect
+	// or common cause of A and B.
+	Chains bool
+	// Actors is the number of core actors, named from A onwards, with a
+	// pattern for each pair of them.
+	Actors int
+	// Externals is the number of external actors influencing the core
+	// actors, named after them: from C onwards with A and B alone.
+	Externals int
+	// Lang is the language of the titles and subtitles, one of Languages.
+	Lang string
+	// Drop leaves whole dimensions out of the taxonomy, named by the field
+	// each records on the scenarios for queries, such as "time" or "d", or
+	// "externals" for every external actor; see the Drop GenOption.
+	Drop []string
+	// Custom are dimensions of the taxonomy of a scenario file, crossed
+	// with the built-in ones after them; see Dimension.
+	Custom []Dimension
+}
+
+// Generator generates a taxonomy of scenarios, in the order they are drawn.
+type Generator func(GeneratorOptions) []Scenario
+
+// generators are the generators selectable by name.
+var generators = map[string]Generator{}
+
+// RegisterGenerator makes g selectable by GeneratorNamed as name, replacing
+// any generator of that name, so that a taxonomy from another package,
+// registered from its init function, can be drawn with this package and
+// its command line.
+func RegisterGenerator(name string, g Generator) {
+	generators[name] = g
+}
+
+// GeneratorNamed looks up a generator registered with RegisterGenerator.
+func GeneratorNamed(name string) (Generator, error) {
+	g, ok := generators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown generator %q (want %s)", name, strings.Join(GeneratorNames(), ", "))
+	}
+	return g, nil
+}
+
+// GeneratorNames returns the names of the generators GeneratorNamed knows,
+// in order.
+func GeneratorNames() []string {
+	return slices.Sorted(maps.Keys(generators))
+}
@@ -3,6 +3,14 @@ module github.com/arran4/interactions
 go 1.25.4
 
 require (
-	golang.org/x/image v0.33.0 // indirect
-	golang.org/x/text v0.31.0 // indirect
+	github.com/gen2brain/webp v0.6.4
+	golang.org/x/image v0.33.0
+	golang.org/x/term v0.45.0
+	gonum.org/v1/gonum v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/ebitengine/purego v0.10.1 // indirect
+	golang.org/x/sys v0.47.0 // indirect
 )
@@ -0,0 +1,198 @@
+// Package gonumgraph adapts scenarios to the graph interfaces of
+// gonum.org/v1/gonum/graph, so its algorithms run on scenarios directly:
+//
+//	g := gonumgraph.New(s)
+//	order, err := topo.Sort(g)            // chronological order, or the cycles
+//	reach := topo.PathExistsIn(g, a, b)   // does a influence b at all?
+//
+// FromGraph goes the other way, building a scenario from any directed graph.
+package gonumgraph
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/arran4/interactions"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/iterator"
+)
+
+// Node is a node of a scenario. Its ID is the node's index in the
+// scenario's Nodes.
+type Node struct {
+	Index int64
+	Name  string
+}
+
+// ID returns the node's index.
+func (n Node) ID() int64 { return n.Index }
+
+func (n Node) String() string { return n.Name }
+
+// DOTID names the node when the graph is written with gonum's DOT encoder.
+func (n Node) DOTID() string { return n.Name }
+
+// Edge is an influence from one node of a scenario to another. The
+// scenario edge it comes from runs the other way when it is the reverse
+// half of an edge with a head at both ends.
+type Edge struct {
+	F, T Node
+	interactions.Edge
+}
+
+func (e Edge) From() graph.Node { return e.F }
+
+func (e Edge) To() graph.Node { return e.T }
+
+// ReversedEdge returns e running the other way, with the same scenario
+// edge.
+func (e Edge) ReversedEdge() graph.Edge {
+	e.F, e.T = e.T, e.F
+	return e
+}
+
+// Graph is a scenario as a gonum directed graph. Every edge runs from its
+// From node to its To node; bidirectional and predation edges, which have
+// a head at both ends, run both ways. Where a scenario has several edges
+// between the same two nodes, Edge returns the first. Edges naming nodes the
+// scenario does not have are left out.
+type Graph struct {
+	nodes []graph.Node
+	edges map[[2]int64]Edge
+	from  map[int64][]graph.Node
+	to    map[int64][]graph.Node
+}
+
+var _ graph.Directed = (*Graph)(nil)
+
+// New returns s as a directed graph.
+func New(s interactions.Scenario) *Graph {
+	g := &Graph{
+		edges: map[[2]int64]Edge{},
+		from:  map[int64][]graph.Node{},
+		to:    map[int64][]graph.Node{},
+	}
+	byName := map[string]Node{}
+	for i, name := range s.Nodes {
+		n := Node{Index: int64(i), Name: name}
+		g.nodes = append(g.nodes, n)
+		byName[name] = n
+	}
+	for _, e := range s.Edges {
+		from, ok1 := byName[e.From]
+		to, ok2 := byName[e.To]
+		if !ok1 || !ok2 {
+			continue
+		}
+		g.add(Edge{F: from, T: to, Edge: e})
+		if e.Bidirectional || e.Kind == interactions.Predation {
+			g.add(Edge{F: to, T: from, Edge: e})
+		}
+	}
+	return g
+}
+
+func (g *Graph) add(e Edge) {
+	key := [2]int64{e.F.Index, e.T.Index}
+	if _, ok := g.edges[key]; ok {
+		return
+	}
+	g.edges[key] = e
+	g.from[e.F.Index] = append(g.from[e.F.Index], e.T)
+	g.to[e.T.Index] = append(g.to[e.T.Index], e.F)
+}
+
+// Node returns the node with the given ID, or nil if there is none.
+func (g *Graph) Node(id int64) graph.Node {
+	if id < 0 || id >= int64(len(g.nodes)) {
+		return nil
+	}
+	return g.nodes[id]
+}
+
+// Nodes returns the nodes in the scenario's order.
+func (g *Graph) Nodes() graph.Nodes { return iterator.NewOrderedNodes(g.nodes) }
+
+// From returns the nodes id influences directly.
+func (g *Graph) From(id int64) graph.Nodes { return iterator.NewOrderedNodes(g.from[id]) }
+
+// To returns the nodes that influence id directly.
+func (g *Graph) To(id int64) graph.Nodes { return iterator.NewOrderedNodes(g.to[id]) }
+
+func (g *Graph) HasEdgeBetween(xid, yid int64) bool {
+	return g.HasEdgeFromTo(xid, yid) || g.HasEdgeFromTo(yid, xid)
+}
+
+func (g *Graph) HasEdgeFromTo(uid, vid int64) bool {
+	_, ok := g.edges[[2]int64{uid, vid}]
+	return ok
+}
+
+// Edge returns the edge from uid to vid, or nil if there is none. The
+// result is an Edge.
+func (g *Graph) Edge(uid, vid int64) graph.Edge {
+	e, ok := g.edges[[2]int64{uid, vid}]
+	if !ok {
+		return nil
+	}
+	return e
+}
+
+// FromGraph builds a scenario with the nodes and edges of g, in order of
+// node ID. Nodes are named by their DOTID or String method if they have
+// one, and otherwise by their ID. Edges that are an Edge keep their kind,
+// style and other attributes; a pair of plain edges running both ways
+// becomes one bidirectional edge.
+func FromGraph(g graph.Directed) interactions.Scenario {
+	nodes := graph.NodesOf(g.Nodes())
+	slices.SortFunc(nodes, byID)
+
+	var s interactions.Scenario
+	names := map[int64]string{}
+	for _, n := range nodes {
+		names[n.ID()] = nodeName(n)
+		s.Nodes = append(s.Nodes, names[n.ID()])
+	}
+
+	// done holds the plain edges already merged into a bidirectional one
+	done := map[[2]int64]bool{}
+	for _, u := range nodes {
+		to := graph.NodesOf(g.From(u.ID()))
+		slices.SortFunc(to, byID)
+		for _, v := range to {
+			uid, vid := u.ID(), v.ID()
+			if done[[2]int64{uid, vid}] {
+				continue
+			}
+			e := interactions.Edge{From: names[uid], To: names[vid]}
+			if ge, ok := g.Edge(uid, vid).(Edge); ok {
+				if ge.Edge.From != ge.F.Name {
+					// the reverse half of an edge running both ways
+					continue
+				}
+				e = ge.Edge
+				e.From, e.To = names[uid], names[vid]
+			} else if uid != vid && g.HasEdgeFromTo(vid, uid) {
+				e.Bidirectional = true
+				done[[2]int64{vid, uid}] = true
+			}
+			s.Edges = append(s.Edges, e)
+		}
+	}
+	return s
+}
+
+func byID(a, b graph.Node) int { return cmp.Compare(a.ID(), b.ID()) }
+
+// nodeName names n after its DOTID or String method, or else its ID.
+func nodeName(n graph.Node) string {
+	switch n := n.(type) {
+	case interface{ DOTID() string }:
+		return n.DOTID()
+	case fmt.Stringer:
+		return n.String()
+	}
+	return strconv.FormatInt(n.ID(), 10)
+}
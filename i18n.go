@@ -0,0 +1,48 @@
+package interactions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// catalogs translate the English text drawn on images, and the titles and
+// subtitles of the generated taxonomy, into other languages. Each maps the
+// English message, which may be a fmt format, to its translation.
+var catalogs = map[string]map[string]string{
+	"de": catalogDE,
+	"es": catalogES,
+}
+
+// Languages returns the codes of the languages text can be translated
+// into: "en", the language messages are written in, then the rest in
+// order.
+func Languages() []string {
+	return append([]string{"en"}, slices.Sorted(maps.Keys(catalogs))...)
+}
+
+// Translate returns the English message msg in the language lang, falling
+// back to msg itself for English, unknown languages and messages without a
+// translation. A message with args is a fmt format, translated before the
+// args are substituted into it.
+func Translate(lang, msg string, args ...any) string {
+	if t, ok := catalogs[lang][msg]; ok {
+		msg = t
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// WithLanguage draws the grid title and the built-in legend in the language
+// lang, one of Languages. Scenario titles are drawn as they are; translate
+// them when building the scenarios.
+func WithLanguage(lang string) Option {
+	return func(o *options) { o.lang = lang }
+}
+
+// tr translates msg into the language chosen WithLanguage.
+func (o options) tr(msg string, args ...any) string {
+	return Translate(o.lang, msg, args...)
+}
@@ -0,0 +1,175 @@
+// Package imagetest pins the output of the interactions renderer in tests by
+// comparing rendered images against golden PNG files.
+//
+//	func TestSupplyChain(t *testing.T) {
+//		got := imagetest.RenderPanel(supplyChain)
+//		imagetest.AssertGolden(t, "testdata/supply-chain.png", got, imagetest.Options{})
+//	}
+//
+// Run the tests with INTERACTIONS_UPDATE_GOLDEN=1 to write the golden files
+// from the current output.
+package imagetest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arran4/interactions"
+)
+
+// UpdateEnv is the environment variable that makes AssertGolden write the
+// golden file instead of comparing against it.
+const UpdateEnv = "INTERACTIONS_UPDATE_GOLDEN"
+
+// RenderPanel draws a single scenario with the light theme. The renderer
+// uses a built-in bitmap font and no anti-aliasing, so the result is the
+// same on every platform.
+func RenderPanel(s interactions.Scenario) *image.RGBA {
+	return interactions.DrawPanel(s, lightTheme())
+}
+
+// RenderGrid draws scenarios as a grid with the light theme.
+func RenderGrid(scenarios []interactions.Scenario, columns int) *image.RGBA {
+	return interactions.DrawGrid(scenarios, columns, lightTheme())
+}
+
+func lightTheme() interactions.Theme {
+	th, err := interactions.ThemeNamed("light")
+	if err != nil {
+		panic(err)
+	}
+	return th
+}
+
+// Options tunes how strictly AssertGolden compares images.
+type Options struct {
+	// Tolerance is the largest difference in any colour channel, from 0 to
+	// 255, for two pixels to count as the same.
+	Tolerance uint8
+	// MaxDiffPixels is how many pixels may differ beyond Tolerance before
+	// the images count as different.
+	MaxDiffPixels int
+	// Update writes the golden file instead of comparing, as does setting
+	// the UpdateEnv environment variable.
+	Update bool
+}
+
+// AssertGolden compares got with the PNG at golden and fails t if they
+// differ in size or in more than MaxDiffPixels pixels. On a mismatch it
+// writes the rendered image and a diff image, which marks differing pixels
+// in red over a faded copy of the golden image, next to the golden file as
+// NAME.got.png and NAME.diff.png.
+func AssertGolden(t testing.TB, golden string, got image.Image, opts Options) {
+	t.Helper()
+
+	if opts.Update || os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := WritePNG(golden, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ReadPNG(golden)
+	if err != nil {
+		t.Fatalf("%v (set %s=1 to create it)", err, UpdateEnv)
+	}
+	n, diff := Compare(want, got, opts.Tolerance)
+	wantSize, gotSize := want.Bounds().Size(), got.Bounds().Size()
+	if n <= opts.MaxDiffPixels && wantSize == gotSize {
+		return
+	}
+
+	base := strings.TrimSuffix(golden, filepath.Ext(golden))
+	for path, img := range map[string]image.Image{base + ".got.png": got, base + ".diff.png": diff} {
+		if err := WritePNG(path, img); err != nil {
+			t.Errorf("writing %s: %v", path, err)
+		}
+	}
+	if wantSize != gotSize {
+		t.Errorf("%s: got a %dx%d image, want %dx%d; see %s.diff.png", golden, gotSize.X, gotSize.Y, wantSize.X, wantSize.Y, base)
+		return
+	}
+	t.Errorf("%s: %d pixels differ (allowed %d); see %s.diff.png", golden, n, opts.MaxDiffPixels, base)
+}
+
+// Compare counts the pixels of got that differ from want by more than
+// tolerance in any channel, and returns a diff image with those pixels in
+// red. The images are compared from their top left corners, wherever their
+// bounds start, so a SubImage compares as its pixels do; pixels in one
+// image but beyond the size of the other all count as different.
+func Compare(want, got image.Image, tolerance uint8) (int, *image.RGBA) {
+	wb, gb := want.Bounds(), got.Bounds()
+	size := image.Rect(0, 0, max(wb.Dx(), gb.Dx()), max(wb.Dy(), gb.Dy()))
+	diff := image.NewRGBA(size)
+	red := color.RGBA{255, 0, 0, 255}
+
+	n := 0
+	for y := 0; y < size.Max.Y; y++ {
+		for x := 0; x < size.Max.X; x++ {
+			if x >= wb.Dx() || y >= wb.Dy() || x >= gb.Dx() || y >= gb.Dy() {
+				diff.SetRGBA(x, y, red)
+				n++
+				continue
+			}
+			w := color.RGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.RGBA)
+			g := color.RGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.RGBA)
+			if channelDiff(w.R, g.R) > tolerance || channelDiff(w.G, g.G) > tolerance ||
+				channelDiff(w.B, g.B) > tolerance || channelDiff(w.A, g.A) > tolerance {
+				diff.SetRGBA(x, y, red)
+				n++
+				continue
+			}
+			diff.SetRGBA(x, y, fade(w))
+		}
+	}
+	return n, diff
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// fade blends c three quarters of the way to white so differences stand out.
+func fade(c color.RGBA) color.RGBA {
+	f := func(v uint8) uint8 { return 255 - (255-v)/4 }
+	return color.RGBA{f(c.R), f(c.G), f(c.B), 255}
+}
+
+// ReadPNG decodes the PNG file at path.
+func ReadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// WritePNG encodes img as a PNG file at path.
+func WritePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,90 @@
+package imagetest_test
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/arran4/interactions/imagetest"
+)
+
+// checker returns a w by h image at min with a pattern no shift of it
+// matches.
+func checker(min image.Point, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{min, min.Add(image.Pt(w, h))})
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(min.X+x, min.Y+y, color.RGBA{uint8(x * 16), uint8(y * 16), uint8(x ^ y), 255})
+		}
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	want := checker(image.Point{}, 8, 6)
+	shifted := checker(image.Pt(-3, 5), 8, 6)
+	sub := checker(image.Point{}, 12, 10)
+	for y := range 6 {
+		for x := range 8 {
+			sub.SetRGBA(2+x, 3+y, want.RGBAAt(x, y))
+		}
+	}
+	changed := checker(image.Point{}, 8, 6)
+	changed.SetRGBA(4, 2, color.RGBA{A: 255})
+
+	tests := []struct {
+		name string
+		got  image.Image
+		n    int
+	}{
+		{"same image", checker(image.Point{}, 8, 6), 0},
+		{"offset bounds", shifted, 0},
+		{"offset sub-image", sub.SubImage(image.Rect(2, 3, 10, 9)), 0},
+		{"one pixel changed", changed, 1},
+		{"narrower", checker(image.Point{}, 7, 6), 6},
+		{"taller", checker(image.Point{}, 8, 7), 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, diff := imagetest.Compare(want, tt.got, 0)
+			if n != tt.n {
+				t.Errorf("Compare = %d pixels, want %d", n, tt.n)
+			}
+			wb, gb := want.Bounds(), tt.got.Bounds()
+			if size := image.Pt(max(wb.Dx(), gb.Dx()), max(wb.Dy(), gb.Dy())); diff.Bounds().Size() != size {
+				t.Errorf("diff is %v, want %v", diff.Bounds().Size(), size)
+			}
+		})
+	}
+}
+
+// recorder records whether a test failed without failing the test running
+// it.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Errorf(string, ...any) { r.failed = true }
+func (r *recorder) Fatalf(string, ...any) { r.failed = true }
+
+func TestAssertGoldenSize(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden.png")
+	if err := imagetest.WritePNG(golden, checker(image.Point{}, 8, 6)); err != nil {
+		t.Fatal(err)
+	}
+	opts := imagetest.Options{MaxDiffPixels: 100}
+
+	r := &recorder{TB: t}
+	imagetest.AssertGolden(r, golden, checker(image.Pt(4, 4), 8, 6), opts)
+	if r.failed {
+		t.Error("AssertGolden failed an offset image of the same pixels")
+	}
+
+	r = &recorder{TB: t}
+	imagetest.AssertGolden(r, golden, checker(image.Point{}, 8, 5), opts)
+	if !r.failed {
+		t.Error("AssertGolden passed an image of another size within MaxDiffPixels")
+	}
+}
@@ -0,0 +1,67 @@
+// Package cliflags holds what the interactions command and its
+// WebAssembly build, cmd/interactions-wasm, both take from their users:
+// the generation flags and the limit on scale, so the two cannot drift
+// apart.
+package cliflags
+
+import (
+	"flag"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// MaxScale caps the factor an image may be enlarged by, so a single
+// request cannot ask for an enormous one.
+const MaxScale = 8
+
+// AddGeneratorFlags adds the flags setting opts to fs: the optional
+// dimensions, the numbers of actors, the language, and the flags dropping
+// dimensions.
+func AddGeneratorFlags(fs *flag.FlagSet, opts *interactions.GeneratorOptions) {
+	fs.BoolVar(&opts.Strengths, "strengths", false, "add weak and strong variants of each A-B influence")
+	fs.BoolVar(&opts.Delays, "delays", false, "add a delayed (dashed) variant of each A-B influence")
+	fs.BoolVar(&opts.Uncertain, "uncertain", false, "add variants of each A-B influence that happen only sometimes or conditionally")
+	fs.BoolVar(&opts.Feedback, "feedback", false, "add A and/or B self-reinforcement loops")
+	fs.BoolVar(&opts.Ecology, "ecology", false, "add competition and predation and label A-B edges with ecological signs")
+	fs.BoolVar(&opts.Timing, "timing", false, "add process variants where A meets, overlaps or contains B")
+	fs.BoolVar(&opts.Chains, "chains", false, "add indirect influence through a mediator: chains, common effects and common causes")
+	fs.IntVar(&opts.Actors, "actors", 2, "number of core actors (A, B, C, ...), with a pattern for each pair of them")
+	fs.IntVar(&opts.Externals, "externals", 2, "number of external actors (C, D, E, ... after the core actors) influencing them")
+	fs.Var(dropFlag{&opts.Drop, "externals"}, "no-externals", "drop every external actor, for grids of the core actors alone")
+	fs.Var(dropFlag{&opts.Drop, "time"}, "no-time", "drop the timing dimension, even with --timing")
+	fs.Var(dropFlag{&opts.Drop, "type"}, "no-type", "drop the event or process type, drawing every actor as an event; the same as --no-time")
+	fs.Var(dropFlag{&opts.Drop, "d"}, "no-d", "drop the external actor D, keeping the others")
+	fs.StringVar(&opts.Lang, "lang", "en", "language of the titles, subtitles and legend: "+strings.Join(interactions.Languages(), ", "))
+}
+
+// dropFlag is a boolean flag, such as --no-time, that adds a dimension to
+// interactions.GeneratorOptions.Drop when set and takes it out again when
+// set to false.
+type dropFlag struct {
+	drop *[]string
+	name string
+}
+
+func (f dropFlag) String() string {
+	if f.drop == nil {
+		return "false"
+	}
+	return strconv.FormatBool(slices.Contains(*f.drop, f.name))
+}
+
+func (f dropFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*f.drop = slices.DeleteFunc(*f.drop, func(name string) bool { return name == f.name })
+	if on {
+		*f.drop = append(*f.drop, f.name)
+	}
+	return nil
+}
+
+func (f dropFlag) IsBoolFlag() bool { return true }
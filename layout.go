@@ -0,0 +1,81 @@
+package interactions
+
+import (
+	"fmt"
+	"image"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Layout places the nodes of a scenario within a panel. Place returns the
+// centre of each node, within area: the part of the panel the centres of
+// the layered layout span, from its upper row to its lower row, with room
+// around it for the nodes, self-loops and labels. Nodes left out go in the
+// middle of area.
+//
+// Event nodes are drawn as circles and processes as boxes as tall as their
+// span of area's height.
+type Layout interface {
+	Place(s Scenario, area image.Rectangle) map[string]image.Point
+}
+
+// Layered is the default Layout: events with no incoming edges in an upper
+// row along the top of area, the rest in a lower row along the bottom, and
+// processes between them by their spans.
+type Layered struct{}
+
+// Place places the nodes as in a panel of the default size.
+func (Layered) Place(s Scenario, area image.Rectangle) map[string]image.Point {
+	stagger := rowStagger * area.Dy() / (lowerRowY - upperRowY)
+	positions, _ := placeLayered(s, area, nodeRadius, stagger)
+	return positions
+}
+
+// layouts are the layouts selectable by name.
+var layouts = map[string]Layout{
+	"layered":  Layered{},
+	"force":    Force{Seed: 1},
+	"sugiyama": Sugiyama{},
+}
+
+// RegisterLayout makes l selectable by LayoutNamed as name, replacing any
+// layout of that name.
+func RegisterLayout(name string, l Layout) {
+	layouts[name] = l
+}
+
+// LayoutNamed looks up a layout registered with RegisterLayout, or a
+// built-in one.
+func LayoutNamed(name string) (Layout, error) {
+	l, ok := layouts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown layout %q (want %s)", name, strings.Join(LayoutNames(), ", "))
+	}
+	return l, nil
+}
+
+// LayoutNames returns the names of the layouts LayoutNamed knows, in
+// order.
+func LayoutNames() []string {
+	return slices.Sorted(maps.Keys(layouts))
+}
+
+// WithLayout places the nodes of every panel with l instead of the
+// layered layout.
+func WithLayout(l Layout) Option {
+	return func(o *options) { o.layout = l }
+}
+
+// processBoxes are the shapes of the processes of s placed by a Layout
+// other than the layered one: as wide as an event node of radius r and as
+// tall as their span of area, but no shorter than r.
+func processBoxes(s Scenario, area image.Rectangle, r int) map[string]nodeShape {
+	shapes := map[string]nodeShape{}
+	for name, span := range s.Spans {
+		h := max(r, int(math.Round((span.End-span.Start)*float64(area.Dy()))))
+		shapes[name] = nodeShape{halfW: r, halfH: h / 2}
+	}
+	return shapes
+}
@@ -0,0 +1,262 @@
+package interactions
+
+import (
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+)
+
+// WithoutLegend leaves the legend out of the grid, moving the panels up
+// into its place. DrawLegend can draw it as an image of its own.
+func WithoutLegend() Option {
+	return func(o *options) { o.hideLegend = true }
+}
+
+// WithLegendEntries replaces the built-in legend, which explains the edge
+// kinds the scenarios use, with the given entries.
+func WithLegendEntries(entries ...LegendEntry) Option {
+	return func(o *options) { o.legendEntries = entries }
+}
+
+// LegendEntry is a custom legend entry: a sample edge with a heading above
+// and an explanation beside it.
+type LegendEntry struct {
+	Heading string
+	// Sample is the edge drawn as an example; its From and To are ignored.
+	// With no sample the entry is text only.
+	Sample *Edge
+	Text   string
+}
+
+// The legend is a box of entries under a "Legend" heading. Entries are
+// measured and flow left to right, wrapping onto new rows, so the legend
+// fits any image width.
+const (
+	legendPadding = 10
+	// legendTitleHeight is the room above the entries for the heading
+	legendTitleHeight = 22
+	// legendGap is the space between entries side by side
+	legendGap = 30
+	// legendRowHeight is the height of an entry of one sample edge
+	legendRowHeight = 40
+)
+
+// legendBlock is an entry of the legend.
+type legendBlock struct {
+	width, height int
+	// draw draws the entry with its top left corner at x, y.
+	draw func(img *image.RGBA, x, y int, th Theme)
+}
+
+// legendHeight returns the height of the legend for scenarios when it is
+// width wide, or 0 when it is hidden.
+func (o options) legendHeight(scenarios []Scenario, width int) int {
+	if o.hideLegend {
+		return 0
+	}
+	_, h := flowLegend(o.legendBlocks(scenarios), width-2*legendPadding)
+	return 2*legendPadding + legendTitleHeight + h
+}
+
+// drawLegendFor draws the legend for scenarios into rect.
+func (o options) drawLegendFor(img *image.RGBA, rect image.Rectangle, scenarios []Scenario, th Theme) {
+	fillRect(img, rect, th.Panel)
+	drawRectBorder(img, rect, th.LegendBorder, o.strokeWidth())
+
+	x0 := rect.Min.X + legendPadding
+	y0 := rect.Min.Y + legendPadding
+	drawLabel(img, o.tr("Legend"), x0, y0+12, th.Title)
+
+	blocks := o.legendBlocks(scenarios)
+	points, _ := flowLegend(blocks, rect.Dx()-2*legendPadding)
+	for i, b := range blocks {
+		b.draw(img, x0+points[i].X, y0+legendTitleHeight+points[i].Y, th)
+	}
+}
+
+// flowLegend places blocks left to right, starting a new row when the next
+// would run past width, and returns the top left corner of each and the
+// height of all the rows.
+func flowLegend(blocks []legendBlock, width int) ([]image.Point, int) {
+	points := make([]image.Point, len(blocks))
+	x, y, rowHeight := 0, 0, 0
+	for i, b := range blocks {
+		if x > 0 && x+b.width > width {
+			x, y, rowHeight = 0, y+rowHeight, 0
+		}
+		points[i] = image.Point{x, y}
+		x += b.width + legendGap
+		rowHeight = max(rowHeight, b.height)
+	}
+	return points, y + rowHeight
+}
+
+// legendBlocks returns the custom legend entries if there are any, and
+// otherwise the built-in legend: the edge kinds, with entries for optional
+// edge styles only when the scenarios use them, the chronology of a panel
+// and the WithEdgeColors key.
+func (o options) legendBlocks(scenarios []Scenario) []legendBlock {
+	if o.legendEntries != nil {
+		blocks := make([]legendBlock, len(o.legendEntries))
+		for i, entry := range o.legendEntries {
+			blocks[i] = o.entryBlock(entry)
+		}
+		return blocks
+	}
+
+	blocks := []legendBlock{
+		sampleBlock(o.tr("Influence"), o.tr("Single arrow: influence (e.g. C → A)"), o.edgeSample(Edge{})),
+		sampleBlock(o.tr("Inhibition"), o.tr("Tee head: inhibition (A ⊣ B)"), o.edgeSample(Edge{Kind: Inhibition})),
+		o.mutualismBlock(),
+	}
+	if usesSelfLoop(scenarios) {
+		// a miniature node with its loop
+		blocks = append(blocks, sampleBlock(o.tr("Feedback"), o.tr("Loop: self-reinforcement (A → A)"), func(img *image.RGBA, x, y int, th Theme) {
+			drawNode(img, x+30, y+10, 6, o.strokeWidth(), th.NodeFill, th.NodeBorder)
+			drawSelfLoop(img, x+30, y+10, 0, -1, 6, Edge{}, o.strokeWidth(), th.Edge)
+		}))
+	}
+	if usesStyle(scenarios, Dashed) {
+		blocks = append(blocks, sampleBlock(o.tr("Delay"), o.tr("Dashed arrow: delayed influence"), o.edgeSample(Edge{Style: Dashed})))
+	}
+	if usesStyle(scenarios, Dotted) {
+		blocks = append(blocks, sampleBlock(o.tr("Uncertain"), o.tr("Dotted arrow: p=0.5 chance, ? conditional"), o.edgeSample(Edge{Style: Dotted})))
+	}
+	if usesPolarity(scenarios) {
+		blocks = append(blocks, notesBlock(o.tr("Ecological signs (effect on each party: + gain, - loss, 0 none)"), "", []string{
+			o.tr("++ mutualism, -- competition, +- predation"),
+			o.tr("+0 commensalism, -0 amensalism, 00 neutralism"),
+		}, func(th Theme) color.RGBA { return th.Accent }))
+	}
+	chronology := []string{
+		o.tr("Upper row = earlier (no incoming arrows)"),
+		o.tr("Lower row = later (influenced by others)"),
+	}
+	if usesSpans(scenarios) {
+		chronology = append(chronology, o.tr("Boxes = processes, top to bottom = start to end"))
+	}
+	blocks = append(blocks, notesBlock(o.tr("Chronology"), o.tr("Within each panel:"), chronology, func(th Theme) color.RGBA { return th.MutedText }))
+	if len(o.edgeColors) > 0 {
+		blocks = append(blocks, o.edgeColorKey())
+	}
+	return blocks
+}
+
+// sampleBlock is an entry with a sample edge, drawn by sample 60 pixels
+// long from x, y, under a heading and explained beside it.
+func sampleBlock(heading, text string, sample func(img *image.RGBA, x, y int, th Theme)) legendBlock {
+	return legendBlock{
+		width:  max(textWidth(heading), 80+textWidth(text)),
+		height: legendRowHeight,
+		draw: func(img *image.RGBA, x, y int, th Theme) {
+			drawLabel(img, heading, x, y+10, th.Title)
+			sample(img, x+10, y+18, th)
+			drawLabel(img, text, x+80, y+22, th.Text)
+		},
+	}
+}
+
+// edgeSample draws e as the sample of a sampleBlock.
+func (o options) edgeSample(e Edge) func(img *image.RGBA, x, y int, th Theme) {
+	return func(img *image.RGBA, x, y int, th Theme) {
+		drawEdge(img, x, y, x+60, y, e, o.pen(), th.Edge)
+	}
+}
+
+// mutualismBlock is the legend's entry for mutualism, in the glyph set by
+// WithMutualism.
+func (o options) mutualismBlock() legendBlock {
+	mutual := Edge{Bidirectional: true}
+	switch o.mutualism {
+	case MutualismDoubleHeaded:
+		return sampleBlock(o.tr("Mutualism"), o.tr("Double arrow: mutualism (A ↔ B)"), o.edgeSample(mutual))
+	case MutualismParallel:
+		return sampleBlock(o.tr("Mutualism"), o.tr("Double line: mutualism (A = B)"), o.edgeSample(mutual))
+	}
+	return sampleBlock(o.tr("Mutualism"), o.tr("Double arrow: mutualism (A ↔ B)"), func(img *image.RGBA, x, y int, th Theme) {
+		drawArrow(img, x, y-3, x+60, y-3, o.strokeWidth(), th.Edge)
+		drawArrow(img, x+60, y+3, x, y+3, o.strokeWidth(), th.Edge)
+	})
+}
+
+// notesBlock is an entry of notes under a heading, in the colour col picks
+// from the theme, introduced by intro in the text colour unless it is
+// empty.
+func notesBlock(heading, intro string, notes []string, col func(Theme) color.RGBA) legendBlock {
+	lines := notes
+	if intro != "" {
+		lines = append([]string{intro}, notes...)
+	}
+	width := textWidth(heading)
+	for _, l := range lines {
+		width = max(width, 10+textWidth(l))
+	}
+	return legendBlock{
+		width:  width,
+		height: 16 + len(lines)*16,
+		draw: func(img *image.RGBA, x, y int, th Theme) {
+			drawLabel(img, heading, x, y+10, th.Title)
+			for i, l := range lines {
+				c := col(th)
+				if intro != "" && i == 0 {
+					c = th.Text
+				}
+				drawLabel(img, l, x+10, y+28+i*16, c)
+			}
+		},
+	}
+}
+
+// entryBlock is a WithLegendEntries entry.
+func (o options) entryBlock(entry LegendEntry) legendBlock {
+	if entry.Sample != nil {
+		return sampleBlock(entry.Heading, entry.Text, o.edgeSample(*entry.Sample))
+	}
+	return legendBlock{
+		width:  max(textWidth(entry.Heading), 10+textWidth(entry.Text)),
+		height: legendRowHeight,
+		draw: func(img *image.RGBA, x, y int, th Theme) {
+			drawLabel(img, entry.Heading, x, y+10, th.Title)
+			drawLabel(img, entry.Text, x+10, y+22, th.Text)
+		},
+	}
+}
+
+// edgeColorKeyWidth is the width of each entry of the edge colour key.
+const edgeColorKeyWidth = 140
+
+// edgeColorKey is the entry WithEdgeColors adds to the built-in legend: a
+// sample edge in each colour, labelled with its node.
+func (o options) edgeColorKey() legendBlock {
+	names := slices.Sorted(maps.Keys(o.edgeColors))
+	heading := o.tr("Edge colour by source")
+	return legendBlock{
+		width:  max(textWidth(heading), 10+len(names)*edgeColorKeyWidth-legendGap),
+		height: legendRowHeight,
+		draw: func(img *image.RGBA, x, y int, th Theme) {
+			drawLabel(img, heading, x, y+10, th.Title)
+			for i, name := range names {
+				ex := x + 10 + i*edgeColorKeyWidth
+				drawEdge(img, ex, y+18, ex+60, y+18, Edge{}, o.pen(), o.edgeColors[name])
+				drawLabel(img, o.tr("from %s", name), ex+70, y+22, th.Text)
+			}
+		},
+	}
+}
+
+// DrawLegend draws the legend DrawGrid would draw for scenarios on its own,
+// as wide as a grid of the given number of columns, for use alongside a
+// grid drawn WithoutLegend.
+func DrawLegend(scenarios []Scenario, columns int, th Theme, opts ...Option) *image.RGBA {
+	o := collectOptions(opts)
+	o.hideLegend = false
+	geo := o.fitGeometry(scenarios)
+	m := geo.margin
+	width := columns*geo.panelW + (columns+1)*m
+	canvas := newCanvas(image.Rect(0, 0, width, o.legendHeight(scenarios, width-2*m)+2*m))
+	fillRect(canvas, canvas.Bounds(), th.Background)
+	rect := image.Rect(m, m, width-m, canvas.Bounds().Max.Y-m)
+	o.drawLegendFor(canvas, rect, scenarios, th)
+	return o.finish(canvas)
+}
@@ -0,0 +1,173 @@
+package interactions
+
+import (
+	"image"
+	"math"
+)
+
+// DrawMatrix draws scenarios in a grid whose axes mean something: each row
+// holds the scenarios with one value of the dimension rowDim and each
+// column one value of colDim, labelled with the values in query syntax such
+// as ab=mutualism. Scenarios that share a cell, differing in the other
+// dimensions, are drawn together as a small grid within it. Values appear
+// in the order the scenarios first use them.
+func DrawMatrix(scenarios []Scenario, rowDim, colDim string, th Theme, opts ...Option) *image.RGBA {
+	m := newMatrixLayout(scenarios, rowDim, colDim, collectOptions(opts))
+	canvas := newCanvas(m.bounds())
+	m.draw(canvas, th)
+	return m.opts.finish(canvas)
+}
+
+// MatrixBounds returns the bounds of the image DrawMatrix would draw,
+// without drawing it.
+func MatrixBounds(scenarios []Scenario, rowDim, colDim string, opts ...Option) image.Rectangle {
+	return newMatrixLayout(scenarios, rowDim, colDim, collectOptions(opts)).bounds()
+}
+
+// MatrixPanelRects returns where DrawMatrix draws the panel of each
+// scenario, in the order of scenarios.
+func MatrixPanelRects(scenarios []Scenario, rowDim, colDim string, opts ...Option) []image.Rectangle {
+	m := newMatrixLayout(scenarios, rowDim, colDim, collectOptions(opts))
+	rects := make([]image.Rectangle, len(scenarios))
+	for r, row := range m.cells {
+		for c, indexes := range row {
+			for k, i := range indexes {
+				rects[i] = m.panelRect(r, c, k)
+			}
+		}
+	}
+	return rects
+}
+
+// matrixHeaderHeight is the height of the row of column headers.
+const matrixHeaderHeight = 16
+
+// matrixLayout is the geometry of a DrawMatrix image.
+type matrixLayout struct {
+	scenarios            []Scenario
+	rowLabels, colLabels []string
+	// cells holds the indexes of the scenarios in each cell, by row and
+	// then column
+	cells         [][][]int
+	subColumns    int
+	cellW, cellH  int
+	panelHeight   int
+	rowHeaderW    int
+	legendHeight  int
+	width, height int
+	geo           geometry
+	opts          options
+}
+
+func newMatrixLayout(scenarios []Scenario, rowDim, colDim string, opts options) matrixLayout {
+	rowIndex := map[string]int{}
+	colIndex := map[string]int{}
+	m := matrixLayout{scenarios: scenarios, geo: opts.fitGeometry(scenarios), opts: opts}
+	gap := m.geo.margin
+	for i, s := range scenarios {
+		rv, cv := Field(s, rowDim), Field(s, colDim)
+		r, ok := rowIndex[rv]
+		if !ok {
+			r = len(m.rowLabels)
+			rowIndex[rv] = r
+			m.rowLabels = append(m.rowLabels, axisLabel(rowDim, rv))
+			m.cells = append(m.cells, make([][]int, len(m.colLabels)))
+		}
+		c, ok := colIndex[cv]
+		if !ok {
+			c = len(m.colLabels)
+			colIndex[cv] = c
+			m.colLabels = append(m.colLabels, axisLabel(colDim, cv))
+			for r := range m.cells {
+				m.cells[r] = append(m.cells[r], nil)
+			}
+		}
+		m.cells[r][c] = append(m.cells[r][c], i)
+	}
+
+	// every cell is laid out as a square-ish grid big enough for the
+	// fullest one
+	most := 1
+	for _, row := range m.cells {
+		for _, cell := range row {
+			most = max(most, len(cell))
+		}
+	}
+	m.subColumns = int(math.Ceil(math.Sqrt(float64(most))))
+	subRows := (most + m.subColumns - 1) / m.subColumns
+	m.cellW = m.subColumns*m.geo.panelW + (m.subColumns-1)*gap
+	m.panelHeight = panelHeightFor(scenarios, m.geo)
+	m.cellH = subRows*m.panelHeight + (subRows-1)*gap
+
+	for _, label := range m.rowLabels {
+		m.rowHeaderW = max(m.rowHeaderW, textWidth(label))
+	}
+	// cells run across the same whatever the height of the legend above
+	m.width = m.cellRect(0, len(m.colLabels)).Min.X - gap
+	m.legendHeight = opts.legendHeight(scenarios, m.width-2*gap)
+	m.height = m.cellRect(len(m.rowLabels), 0).Min.Y - gap + opts.footerHeight()
+	return m
+}
+
+// axisLabel is the header of a row or column, as a query comparison.
+func axisLabel(dim, value string) string {
+	if value == "" {
+		return dim + `=""`
+	}
+	return dim + "=" + value
+}
+
+func (m matrixLayout) bounds() image.Rectangle {
+	return image.Rect(0, 0, m.width, m.height)
+}
+
+func (m matrixLayout) legendRect() image.Rectangle {
+	gap := m.geo.margin
+	top := gap + headerHeight
+	return image.Rect(gap, top, m.width-gap, top+m.legendHeight)
+}
+
+// cellRect is the area of the panels in a cell. Cells are twice the grid
+// margin apart, so they stand out from the panels within them.
+func (m matrixLayout) cellRect(row, col int) image.Rectangle {
+	gap := m.geo.margin
+	x := 2*gap + m.rowHeaderW + gap + col*(m.cellW+2*gap)
+	y := m.legendRect().Max.Y + 2*gap + matrixHeaderHeight + gap + row*(m.cellH+2*gap)
+	return image.Rect(x, y, x+m.cellW, y+m.cellH)
+}
+
+// panelRect is the area of the k'th panel in a cell.
+func (m matrixLayout) panelRect(row, col, k int) image.Rectangle {
+	cell := m.cellRect(row, col)
+	gap := m.geo.margin
+	x := cell.Min.X + (k%m.subColumns)*(m.geo.panelW+gap)
+	y := cell.Min.Y + (k/m.subColumns)*(m.panelHeight+gap)
+	return image.Rect(x, y, x+m.geo.panelW, y+m.panelHeight)
+}
+
+func (m matrixLayout) draw(canvas *image.RGBA, th Theme) {
+	fillRect(canvas, canvas.Bounds(), th.Background)
+	drawHeader(canvas, m.width, m.legendRect(), m.scenarios, th, m.opts)
+	gap := m.geo.margin
+
+	for c, label := range m.colLabels {
+		cell := m.cellRect(0, c)
+		drawCenteredLabel(canvas, label, (cell.Min.X+cell.Max.X)/2, cell.Min.Y-gap-4, th.Title)
+	}
+	done := 0
+	for r, label := range m.rowLabels {
+		cell := m.cellRect(r, 0)
+		drawLabel(canvas, label, gap, cell.Min.Y+m.geo.panelH/2, th.Title)
+		for c, indexes := range m.cells[r] {
+			cell := m.cellRect(r, c)
+			drawRectBorder(canvas, cell.Inset(-gap/2), th.PanelBorder, m.opts.strokeWidth())
+			for k, i := range indexes {
+				drawScenario(canvas, m.panelRect(r, c, k), m.scenarios[i], th, m.opts, m.geo, i)
+				m.opts.checkText(m.scenarios[i], m.geo, i)
+				done++
+				m.opts.panelDone(done, len(m.scenarios))
+			}
+		}
+	}
+	m.opts.drawStamps(canvas, m.bounds(), th)
+}
@@ -0,0 +1,77 @@
+package interactions
+
+import "math"
+
+// Mutualism is the glyph mutual influence, an Influence edge that is
+// Bidirectional, is drawn with in panels and the legend. Small panels can
+// make the legend's two opposing arrows look like a rendering artifact, so
+// the alternatives draw the same glyph in both.
+type Mutualism int
+
+const (
+	// MutualismArrows draws the edge as a line with an arrowhead at each
+	// end, and the legend's sample as two opposing arrows side by side.
+	MutualismArrows Mutualism = iota
+	// MutualismDoubleHeaded draws the legend's sample as the same single
+	// double-headed arrow as the edges.
+	MutualismDoubleHeaded
+	// MutualismParallel draws the edge, and the legend's sample, as two
+	// parallel lines without heads, like a chemical double bond.
+	MutualismParallel
+)
+
+// WithMutualism sets the glyph of mutual influence; the default is
+// MutualismArrows. Competition, mutual inhibition, keeps its tee heads.
+func WithMutualism(m Mutualism) Option {
+	return func(o *options) { o.mutualism = m }
+}
+
+// mutual reports whether e is mutual influence.
+func (e Edge) mutual() bool {
+	return e.Bidirectional && e.Kind == Influence
+}
+
+// pen is how the edges of a panel are drawn: stroke is the width of their
+// lines before weights thicken them.
+type pen struct {
+	stroke    int
+	mutualism Mutualism
+}
+
+func (o options) pen() pen {
+	return pen{stroke: o.strokeWidth(), mutualism: o.mutualism}
+}
+
+// parallel reports whether e is drawn as two parallel lines without heads.
+func (p pen) parallel(e Edge) bool {
+	return p.mutualism == MutualismParallel && e.mutual()
+}
+
+// edgeLines are the lines e is drawn along line with: line itself, or the
+// two either side of it of a parallel mutualism, far enough apart that a
+// gap shows between lines width pixels wide.
+func (p pen) edgeLines(line [][2]float64, e Edge) [][][2]float64 {
+	if !p.parallel(e) {
+		return [][][2]float64{line}
+	}
+	d := float64(e.strokeWidth(p.stroke) + 1)
+	sides := make([][][2]float64, 2)
+	for i, pt := range line {
+		// each point moves square to the segments either side of it
+		var ux, uy float64
+		if i > 0 {
+			x, y, _ := unitBetween(line[i-1], pt)
+			ux, uy = ux+x, uy+y
+		}
+		if i < len(line)-1 {
+			x, y, _ := unitBetween(pt, line[i+1])
+			ux, uy = ux+x, uy+y
+		}
+		if l := math.Hypot(ux, uy); l > 0 {
+			ux, uy = ux/l, uy/l
+		}
+		sides[0] = append(sides[0], [2]float64{pt[0] - uy*d, pt[1] + ux*d})
+		sides[1] = append(sides[1], [2]float64{pt[0] + uy*d, pt[1] - ux*d})
+	}
+	return sides
+}
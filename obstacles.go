@@ -0,0 +1,150 @@
+package interactions
+
+import (
+	"image"
+	"math"
+)
+
+// obstacleGap is how far clear of a node an edge curved around it passes.
+const obstacleGap = 4
+
+// curveSteps is how many straight pieces an edge curved around a node is
+// drawn with.
+const curveSteps = 8
+
+// avoidObstacles curves the straight edges of s that would pass through a
+// node they do not join, as when C, D and A share the upper row and an
+// edge from C to B runs under A, bending them round the node with bends
+// along a gentle curve. Edges that no curve keeps clear of every node, such
+// as those crossing a wide process box, stay straight.
+func avoidObstacles(s Scenario, layout *panelLayout) {
+	for k, e := range s.Edges {
+		if e.From == e.To || len(layout.bends[k]) > 0 {
+			continue
+		}
+		from, ok1 := layout.positions[e.From]
+		to, ok2 := layout.positions[e.To]
+		if !ok1 || !ok2 || from == to {
+			continue
+		}
+		bends, ok := curveAround(s, layout, e, from, to)
+		if !ok {
+			continue
+		}
+		if layout.bends == nil {
+			layout.bends = map[int][]image.Point{}
+		}
+		layout.bends[k] = bends
+	}
+}
+
+// curveAround returns the bends of a curve from from to to clear of the
+// nodes other than e's ends, or ok false when the straight line is clear
+// already or no curve is.
+func curveAround(s Scenario, layout *panelLayout, e Edge, from, to image.Point) (bends []image.Point, ok bool) {
+	p0 := [2]float64{float64(from.X), float64(from.Y)}
+	p1 := [2]float64{float64(to.X), float64(to.Y)}
+	ux, uy, _ := unitBetween(p0, p1)
+	nx, ny := -uy, ux
+	length := math.Hypot(p1[0]-p0[0], p1[1]-p0[1])
+
+	// the node the line cuts deepest into decides the curve
+	worst, depth := "", 0.0
+	var at, side float64
+	for _, name := range s.Nodes {
+		if name == e.From || name == e.To {
+			continue
+		}
+		pt, ok := layout.positions[name]
+		if !ok {
+			continue
+		}
+		dx, dy := float64(pt.X)-p0[0], float64(pt.Y)-p0[1]
+		t := (dx*ux + dy*uy) / length
+		if t <= 0 || t >= 1 {
+			continue
+		}
+		off := dx*nx + dy*ny
+		clearance := layout.shape(name).rim(nx, ny) + obstacleGap
+		if d := clearance - math.Abs(off); d > depth {
+			worst, depth, at, side = name, d, t, off
+		}
+	}
+	if worst == "" {
+		return nil, false
+	}
+
+	// pass the node on the side of it the line runs on already, else on
+	// the other
+	at = math.Min(math.Max(at, 0.15), 0.85)
+	clearance := layout.shape(worst).rim(nx, ny) + obstacleGap
+	sign := -1.0
+	if side < 0 {
+		sign = 1
+	}
+	for _, dir := range []float64{sign, -sign} {
+		shift := side + dir*clearance
+		// a quadratic curve is 2t(1-t) of the way to its control point
+		h := shift / (2 * at * (1 - at))
+		bends = curveBends(p0, p1, nx*h, ny*h)
+		if clearOf(s, layout, e, from, bends, to) {
+			return bends, true
+		}
+	}
+	return nil, false
+}
+
+// curveBends are the points along the quadratic curve from p0 to p1 whose
+// control point is (hx, hy) from their midpoint, between its ends.
+func curveBends(p0, p1 [2]float64, hx, hy float64) []image.Point {
+	cx, cy := (p0[0]+p1[0])/2+hx, (p0[1]+p1[1])/2+hy
+	bends := make([]image.Point, 0, curveSteps-1)
+	for i := 1; i < curveSteps; i++ {
+		t := float64(i) / curveSteps
+		a, b, c := (1-t)*(1-t), 2*t*(1-t), t*t
+		bends = append(bends, image.Pt(
+			int(math.Round(a*p0[0]+b*cx+c*p1[0])),
+			int(math.Round(a*p0[1]+b*cy+c*p1[1]))))
+	}
+	return bends
+}
+
+// clearOf reports whether the line from from through bends to to passes
+// clear of every node but e's ends, with at least half the obstacleGap to
+// spare, so a curve squeezing between two nodes is turned the other way.
+func clearOf(s Scenario, layout *panelLayout, e Edge, from image.Point, bends []image.Point, to image.Point) bool {
+	points := append(append([]image.Point{from}, bends...), to)
+	for _, name := range s.Nodes {
+		if name == e.From || name == e.To {
+			continue
+		}
+		pt, ok := layout.positions[name]
+		if !ok {
+			continue
+		}
+		sh := layout.shape(name)
+		for i := 1; i < len(points); i++ {
+			if segmentNear(points[i-1], points[i], pt, sh, obstacleGap/2) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// segmentNear reports whether the segment from a to b passes within gap
+// of the outline of the node of shape sh at pt.
+func segmentNear(a, b, pt image.Point, sh nodeShape, gap float64) bool {
+	ax, ay := float64(a.X), float64(a.Y)
+	dx, dy := float64(b.X)-ax, float64(b.Y)-ay
+	t := 0.0
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = math.Min(math.Max(((float64(pt.X)-ax)*dx+(float64(pt.Y)-ay)*dy)/l2, 0), 1)
+	}
+	cx, cy := ax+t*dx-float64(pt.X), ay+t*dy-float64(pt.Y)
+	d := math.Hypot(cx, cy)
+	if d == 0 {
+		return true
+	}
+	return d < sh.rim(cx/d, cy/d)+gap
+}
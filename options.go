@@ -0,0 +1,254 @@
+package interactions
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// Option changes how scenarios are drawn by DrawGrid, WriteTiledGrid,
+// DrawPanel, WritePanelSVG and DrawLegend.
+type Option func(*options)
+
+type options struct {
+	hideLegend    bool
+	legendEntries []LegendEntry
+	captions      *Captions
+	numbers       []int
+	// page and pages number the grid among several pages
+	page, pages int
+	// edgeColors colours edges by the node they come from
+	edgeColors map[string]color.RGBA
+	// highlight is the node drawn in the accent colour, with the rest faded
+	highlight string
+	// lang is the language of the grid title and legend
+	lang string
+	// badges are notes drawn on each panel
+	badges []string
+	// progress is told of each panel drawn
+	progress func(done, total int)
+	// panelWidth and panelHeight are set by WithPanelSize, zero for the
+	// defaults
+	panelWidth, panelHeight int
+	// margin is set by WithMargin, nil for the default
+	margin *int
+	// overflow is what happens to text too wide for its place
+	overflow Overflow
+	// warn is told of each Warning
+	warn func(Warning)
+	// layout places the nodes of each panel, nil for the layered layout
+	layout Layout
+	// watermark, footer and logo are stamped on grids, matrices and
+	// posters
+	watermark, footer string
+	logo              image.Image
+	// postProcess are run on each finished image
+	postProcess []func(draw.Image) error
+	// stroke is set by WithStrokeWidth, zero for hairlines
+	stroke    int
+	mutualism Mutualism
+	// bundle is set by WithBundledEdges
+	bundle bool
+	// debugLayout is set by WithLayoutDebug
+	debugLayout bool
+	// cache keeps drawn panels, nil for none
+	cache PanelCache
+}
+
+func collectOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Captions selects the text drawn on each panel.
+type Captions struct {
+	// Title is the title and subtitle.
+	Title bool
+	// TitleBelow moves the title and subtitle under the diagram.
+	TitleBelow bool
+	// Code is the scenario code, in the bottom right corner.
+	Code bool
+	// Index is the scenario's number as list numbers it, such as "417.",
+	// in the bottom left corner; see ListNumber.
+	Index bool
+}
+
+// DefaultCaptions are the captions drawn without WithCaptions: the title
+// above the diagram and the code.
+var DefaultCaptions = Captions{Title: true, Code: true}
+
+// ParseCaptions reads captions written as the command's --captions flag
+// takes them: a comma-separated list of title, title-below, code and
+// index, or none.
+func ParseCaptions(value string) (Captions, error) {
+	var c Captions
+	for _, part := range strings.Split(value, ",") {
+		switch strings.TrimSpace(part) {
+		case "title":
+			c.Title = true
+		case "title-below":
+			c.Title, c.TitleBelow = true, true
+		case "code":
+			c.Code = true
+		case "index":
+			c.Index = true
+		case "none":
+		default:
+			return c, fmt.Errorf("unknown caption %q (want title, title-below, code, index or none)", part)
+		}
+	}
+	return c, nil
+}
+
+// WithCaptions selects the text drawn on each panel.
+func WithCaptions(c Captions) Option {
+	return func(o *options) { o.captions = &c }
+}
+
+// WithNumbers sets the numbers shown by Captions.Index, one per scenario.
+// Without it scenarios are numbered from 1 in the order drawn, which only
+// matches the list output when the full list is drawn.
+func WithNumbers(numbers []int) Option {
+	return func(o *options) { o.numbers = numbers }
+}
+
+// caption returns the caption of the i'th scenario drawn.
+func (o options) caption(i int) panelCaption {
+	c := panelCaption{Captions: DefaultCaptions, number: i + 1}
+	if o.captions != nil {
+		c.Captions = *o.captions
+	}
+	if i < len(o.numbers) {
+		c.number = o.numbers[i]
+	}
+	if i < len(o.badges) {
+		c.badge = o.badges[i]
+	}
+	return c
+}
+
+// WithPanelSize draws panels width by height pixels instead of the default
+// 360 by 220. The rows of nodes spread to fill the panel and the node
+// circles scale with it, while text stays the size of the pixel font, so
+// panels much under 180 pixels high crowd the diagram. Panels still grow
+// taller to fit a Description. A width or height of zero keeps its
+// default.
+func WithPanelSize(width, height int) Option {
+	return func(o *options) { o.panelWidth, o.panelHeight = width, height }
+}
+
+// WithMargin sets the space between panels, and around the edge of the
+// image, to margin pixels instead of the default 20.
+func WithMargin(margin int) Option {
+	return func(o *options) { o.margin = &margin }
+}
+
+// WithStrokeWidth draws every line width pixels wide instead of one: the
+// edges, the node outlines and the borders of panels and the legend, so
+// they survive the grid being scaled down. Weighted edges are drawn that
+// many times thicker again.
+func WithStrokeWidth(width int) Option {
+	return func(o *options) { o.stroke = width }
+}
+
+// strokeWidth is the width of lines drawn with o, at least 1.
+func (o options) strokeWidth() int {
+	return max(o.stroke, 1)
+}
+
+// WithBadges draws a short note, such as "×2 (symmetric)", centred at the
+// bottom of each panel, one per scenario in the order drawn. Empty notes
+// draw nothing.
+func WithBadges(badges []string) Option {
+	return func(o *options) { o.badges = badges }
+}
+
+// WithPage marks a grid as page page of pages, for output split across
+// several images, and adds the page number to its title.
+func WithPage(page, pages int) Option {
+	return func(o *options) { o.page, o.pages = page, pages }
+}
+
+// WithEdgeColors draws the edges from each node named in colors in its
+// colour instead of the theme's, and adds a legend row naming them, so the
+// edges of busy panels can be told apart by where they come from.
+func WithEdgeColors(colors map[string]color.RGBA) Option {
+	return func(o *options) { o.edgeColors = colors }
+}
+
+// WithHighlight draws the node named name and its edges in the theme's
+// accent colour and fades everything else, focusing each panel on what
+// happens to that node. Panels without the node are drawn entirely faded.
+func WithHighlight(name string) Option {
+	return func(o *options) { o.highlight = name }
+}
+
+// WithProgress calls progress after each panel of a grid or matrix is
+// drawn, with the number of panels drawn so far and the number in all, so
+// long renders can show how far they have got.
+func WithProgress(progress func(done, total int)) Option {
+	return func(o *options) { o.progress = progress }
+}
+
+// panelDone reports that done of total panels have been drawn.
+func (o options) panelDone(done, total int) {
+	if o.progress != nil {
+		o.progress(done, total)
+	}
+}
+
+// fadedEdge reports whether WithHighlight fades e.
+func (o options) fadedEdge(e Edge) bool {
+	return o.highlight != "" && e.From != o.highlight && e.To != o.highlight
+}
+
+// edgeColor is the colour e is drawn in.
+func (o options) edgeColor(e Edge, th Theme) color.RGBA {
+	col := th.Edge
+	if c, ok := o.edgeColors[e.From]; ok {
+		col = c
+	}
+	switch {
+	case o.highlight == "":
+	case o.fadedEdge(e):
+		col = fade(col, th.Panel)
+	default:
+		col = th.Accent
+	}
+	return col
+}
+
+// signColor is the colour of e's ecological signs.
+func (o options) signColor(e Edge, th Theme) color.RGBA {
+	if o.fadedEdge(e) {
+		return fade(th.Accent, th.Panel)
+	}
+	return th.Accent
+}
+
+// nodeColors are the fill, border and label colours of the node named name.
+func (o options) nodeColors(name string, th Theme) (fill, border, label color.RGBA) {
+	switch o.highlight {
+	case "":
+		return th.NodeFill, th.NodeBorder, th.Text
+	case name:
+		return th.NodeFill, th.Accent, th.Accent
+	}
+	return fade(th.NodeFill, th.Panel), fade(th.NodeBorder, th.Panel), fade(th.Text, th.Panel)
+}
+
+// fadeWeight is how much of the original colour fade keeps, out of 100.
+const fadeWeight = 30
+
+// fade mixes c into bg, leaving a faint trace of c.
+func fade(c, bg color.RGBA) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8((int(a)*fadeWeight + int(b)*(100-fadeWeight)) / 100)
+	}
+	return color.RGBA{mix(c.R, bg.R), mix(c.G, bg.G), mix(c.B, bg.B), 255}
+}
@@ -0,0 +1,225 @@
+package interactions
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Overflow is what happens to text too wide for its place on a panel even
+// after wrapping, such as a word longer than the panel is wide or a node
+// name wider than its circle. The pixel font has only one size, so text
+// cannot shrink to fit.
+type Overflow int
+
+const (
+	// OverflowDraw draws the text in full, running past its place.
+	OverflowDraw Overflow = iota
+	// OverflowEllipsis cuts the text short to fit, ending it with an
+	// ellipsis.
+	OverflowEllipsis
+)
+
+// WithOverflow sets what happens to text too wide for its place; the
+// default is OverflowDraw.
+func WithOverflow(ov Overflow) Option {
+	return func(o *options) { o.overflow = ov }
+}
+
+// Warning is something on a panel that could not be drawn as intended, such
+// as text too wide for its place, or on the image as a whole, with no
+// Number or Code, such as a WithPostProcess hook failing.
+type Warning struct {
+	// Number is the number of the panel, as shown by Captions.Index.
+	Number int
+	Code   string
+	// Path locates the offending text in the scenario, as in Problem, e.g.
+	// "title" or "nodes[2]".
+	Path    string
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Number == 0 && w.Code == "" {
+		return fmt.Sprintf("image: %s: %s", w.Path, w.Message)
+	}
+	return fmt.Sprintf("panel %s: %s: %s", cmp.Or(w.Code, strconv.Itoa(w.Number)), w.Path, w.Message)
+}
+
+// WithWarnings calls warn with each Warning found while drawing, once for
+// each panel drawn.
+func WithWarnings(warn func(Warning)) Option {
+	return func(o *options) { o.warn = warn }
+}
+
+// Warnings are the Warning found while drawing, in the order they were
+// found.
+type Warnings []Warning
+
+// CollectWarnings adds each Warning found while drawing to ws, once however
+// many times its panel is drawn, in place of WithWarnings.
+func CollectWarnings(ws *Warnings) Option {
+	return WithWarnings(func(w Warning) {
+		if !slices.Contains(*ws, w) {
+			*ws = append(*ws, w)
+		}
+	})
+}
+
+// RenderWithWarnings is RenderContext, also returning the Warnings found
+// while drawing: text too wide for its place, nodes drawn over each other
+// and edges drawn through nodes they do not join.
+func RenderWithWarnings(ctx context.Context, scenarios []Scenario, columns int, th Theme, opts ...Option) (*image.RGBA, Warnings, error) {
+	var ws Warnings
+	img, err := RenderContext(ctx, scenarios, columns, th, append(slices.Clip(opts), CollectWarnings(&ws))...)
+	return img, ws, err
+}
+
+// ellipsis ends text cut short by OverflowEllipsis.
+const ellipsis = "…"
+
+// fit returns text to draw in room pixels: cut short with an ellipsis if
+// it is too wide and WithOverflow asks for that, keeping at least its
+// first character.
+func (o options) fit(text string, room int) string {
+	if o.overflow != OverflowEllipsis || textWidth(text) <= room {
+		return text
+	}
+	runes := []rune(text)
+	n := len(runes) - 1
+	for n > 1 && textWidth(string(runes[:n])+ellipsis) > room {
+		n--
+	}
+	return string(runes[:n]) + ellipsis
+}
+
+// fitLines is fit for each of lines.
+func (o options) fitLines(lines []string, room int) []string {
+	fitted := make([]string, len(lines))
+	for i, l := range lines {
+		fitted[i] = o.fit(l, room)
+	}
+	return fitted
+}
+
+// lineRoom is the width of the lines of text across a panel width wide.
+func lineRoom(width int) int {
+	return width - 20
+}
+
+// codeRoom is the width of the code in the bottom corner of a panel width
+// wide.
+func codeRoom(width int) int {
+	return width - 16
+}
+
+// labelRoom is the width of a line of the name of a node drawn as sh,
+// centred in it, to just inside its outline on either side.
+func labelRoom(sh nodeShape) int {
+	return 2*int(sh.rim(1, 0)) - 4
+}
+
+// checkText reports through WithWarnings the text of s, drawn as the i'th
+// panel in geometry geo, that is too wide for its place.
+func (o options) checkText(s Scenario, geo geometry, i int) {
+	if o.warn == nil {
+		return
+	}
+	c := o.caption(i)
+	check := func(path, text string, room int) {
+		over := textWidth(text) - room
+		if over <= 0 {
+			return
+		}
+		msg := fmt.Sprintf("%q is %d pixels too wide", text, over)
+		if o.overflow == OverflowEllipsis {
+			msg += ", cut short"
+		}
+		o.warn(Warning{Number: c.number, Code: s.Code, Path: path, Message: msg})
+	}
+
+	rect := image.Rect(0, 0, geo.panelW, geo.panelH)
+	room := lineRoom(geo.panelW)
+	text, _, _, shift := c.text(s, rect, geo)
+	for _, l := range text.title {
+		check("title", l, room)
+	}
+	for _, l := range text.subtitle {
+		check("subtitle", l, room)
+	}
+	for _, l := range wrapDescription(s, geo.panelW) {
+		check("description", l, room)
+	}
+	if c.Code && s.Code != "" {
+		check("code", s.Code, codeRoom(geo.panelW))
+	}
+	layout := o.layoutScenario(s, rect, shift, geo)
+	for k, name := range s.Nodes {
+		room := labelRoom(layout.shape(name))
+		for _, l := range nodeLabelLines(name, room) {
+			check(fmt.Sprintf("nodes[%d]", k), l, room)
+		}
+	}
+	o.checkOverlaps(s, layout, c.number)
+	o.checkEdges(s, layout, c.number)
+}
+
+// checkOverlaps reports through WithWarnings each node of s drawn over an
+// earlier one in layout, on the panel numbered number, as happens when a
+// row has more nodes than fit across the panel even once it is widened and
+// the nodes shrunk.
+func (o options) checkOverlaps(s Scenario, layout panelLayout, number int) {
+	for k, name := range s.Nodes {
+		pt, ok := layout.positions[name]
+		if !ok {
+			continue
+		}
+		for _, other := range s.Nodes[:k] {
+			opt, ok := layout.positions[other]
+			if !ok || other == name {
+				continue
+			}
+			dx, dy := float64(pt.X-opt.X), float64(pt.Y-opt.Y)
+			d := math.Hypot(dx, dy)
+			if d > 0 && d >= layout.shape(name).rim(-dx/d, -dy/d)+layout.shape(other).rim(dx/d, dy/d) {
+				continue
+			}
+			msg := fmt.Sprintf("%q overlaps %q, with no room left across the panel to space them apart", name, other)
+			o.warn(Warning{Number: number, Code: s.Code, Path: fmt.Sprintf("nodes[%d]", k), Message: msg})
+			break
+		}
+	}
+}
+
+// checkEdges reports through WithWarnings each edge of s that layout draws
+// through a node it does not join, on the panel numbered number, as happens
+// when no curve round the node is clear of the others.
+func (o options) checkEdges(s Scenario, layout panelLayout, number int) {
+	for k, e := range s.Edges {
+		from, ok1 := layout.positions[e.From]
+		to, ok2 := layout.positions[e.To]
+		if !ok1 || !ok2 || e.From == e.To {
+			continue
+		}
+		points := append(append([]image.Point{from}, layout.bends[k]...), to)
+		for _, name := range s.Nodes {
+			pt, ok := layout.positions[name]
+			if !ok || name == e.From || name == e.To {
+				continue
+			}
+			through := false
+			for i := 1; i < len(points) && !through; i++ {
+				through = segmentNear(points[i-1], points[i], pt, layout.shape(name), 0)
+			}
+			if through {
+				msg := fmt.Sprintf("the edge from %q to %q passes through %q", e.From, e.To, name)
+				o.warn(Warning{Number: number, Code: s.Code, Path: fmt.Sprintf("edges[%d]", k), Message: msg})
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package interactions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextChunk is a keyword and text stored in a PNG file.
+type TextChunk struct {
+	Keyword string
+	Text    string
+}
+
+// pngEncoder has its settings pinned rather than left to the defaults, so
+// the same image always encodes to the same bytes.
+var pngEncoder = png.Encoder{CompressionLevel: png.DefaultCompression}
+
+// EncodePNG writes img to w as a PNG, encoded the same way every time.
+func EncodePNG(w io.Writer, img image.Image) error {
+	return pngEncoder.Encode(w, img)
+}
+
+// pngHeaderLen is the length of the PNG signature and the IHDR chunk, which
+// must come first: an 8 byte signature, then the chunk's length, type, 13
+// bytes of data and CRC.
+const pngHeaderLen = 8 + 4 + 4 + 13 + 4
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// NewPNGTextWriter returns a writer that passes a PNG stream through to w,
+// adding chunks as text chunks after the header. Text that is not Latin-1
+// goes in an iTXt chunk as UTF-8, the rest in plain tEXt chunks.
+func NewPNGTextWriter(w io.Writer, chunks []TextChunk) io.Writer {
+	return &pngTextWriter{w: w, chunks: chunks}
+}
+
+type pngTextWriter struct {
+	w      io.Writer
+	chunks []TextChunk
+	header []byte
+	done   bool
+}
+
+func (t *pngTextWriter) Write(p []byte) (int, error) {
+	if t.done {
+		return t.w.Write(p)
+	}
+
+	n := min(len(p), pngHeaderLen-len(t.header))
+	t.header = append(t.header, p[:n]...)
+	if len(t.header) < pngHeaderLen {
+		return len(p), nil
+	}
+	if !bytes.HasPrefix(t.header, pngSignature) || string(t.header[12:16]) != "IHDR" {
+		return 0, errors.New("png text: stream does not start with a PNG header")
+	}
+
+	t.done = true
+	if _, err := t.w.Write(t.header); err != nil {
+		return 0, err
+	}
+	for _, c := range t.chunks {
+		kind, data, err := textChunkData(c)
+		if err != nil {
+			return 0, err
+		}
+		if err := writeChunk(t.w, kind, data); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := t.w.Write(p[n:]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// textChunkData encodes c as the type and data of a tEXt or iTXt chunk.
+func textChunkData(c TextChunk) (string, []byte, error) {
+	if len(c.Keyword) < 1 || len(c.Keyword) > 79 || strings.IndexFunc(c.Keyword, func(r rune) bool { return r < ' ' || r > '~' }) >= 0 {
+		return "", nil, fmt.Errorf("png text: bad keyword %q (want 1 to 79 printable ASCII characters)", c.Keyword)
+	}
+	if isLatin1(c.Text) {
+		data := append([]byte(c.Keyword), 0)
+		for _, r := range c.Text {
+			data = append(data, byte(r))
+		}
+		return "tEXt", data, nil
+	}
+	// keyword, null, uncompressed, no language tag or translated keyword
+	data := append([]byte(c.Keyword), 0, 0, 0, 0, 0)
+	return "iTXt", append(data, c.Text...), nil
+}
+
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(kind)
+	buf.Write(data)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()[4:]))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadPNGText returns the uncompressed tEXt and iTXt chunks of a PNG
+// stream, in the order they appear.
+func ReadPNGText(r io.Reader) ([]TextChunk, error) {
+	br := bufio.NewReader(r)
+	sig := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(br, sig); err != nil || !bytes.Equal(sig, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	var chunks []TextChunk
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(br, head[:]); err != nil {
+			return nil, fmt.Errorf("reading PNG chunk: %w", err)
+		}
+		length := binary.BigEndian.Uint32(head[:4])
+		kind := string(head[4:])
+		switch kind {
+		case "tEXt", "iTXt":
+			data := make([]byte, length)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, fmt.Errorf("reading %s chunk: %w", kind, err)
+			}
+			if c, ok := parseTextChunk(kind, data); ok {
+				chunks = append(chunks, c)
+			}
+		case "IEND":
+			return chunks, nil
+		default:
+			if _, err := br.Discard(int(length)); err != nil {
+				return nil, fmt.Errorf("reading %s chunk: %w", kind, err)
+			}
+		}
+		// CRC
+		if _, err := br.Discard(4); err != nil {
+			return nil, fmt.Errorf("reading %s chunk: %w", kind, err)
+		}
+	}
+}
+
+// parseTextChunk decodes tEXt and uncompressed iTXt chunk data.
+func parseTextChunk(kind string, data []byte) (TextChunk, bool) {
+	keyword, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return TextChunk{}, false
+	}
+	if kind == "tEXt" {
+		return TextChunk{Keyword: latin1String(keyword), Text: latin1String(rest)}, true
+	}
+
+	// compression flag and method, then language tag and translated keyword
+	if len(rest) < 2 || rest[0] != 0 {
+		return TextChunk{}, false
+	}
+	_, rest, ok = bytes.Cut(rest[2:], []byte{0})
+	if !ok {
+		return TextChunk{}, false
+	}
+	_, text, ok := bytes.Cut(rest, []byte{0})
+	if !ok || !utf8.Valid(text) {
+		return TextChunk{}, false
+	}
+	return TextChunk{Keyword: latin1String(keyword), Text: string(text)}, true
+}
+
+func latin1String(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
@@ -0,0 +1,49 @@
+package interactions
+
+import (
+	"image"
+	"sync"
+)
+
+// canvases holds the pixels of images given back with ReleaseImage, for
+// the next image drawn to reuse instead of allocating its own. A grid of
+// the whole taxonomy runs to tens of megabytes of pixels, so servers and
+// other programs drawing one image after another would otherwise spend
+// most of their allocation, and garbage collection, on canvases.
+var canvases sync.Pool
+
+// newCanvas is image.NewRGBA for r, reusing the pixels of a released image
+// when there is one big enough and not more than twice the size, so a
+// small panel does not keep a whole grid's pixels alive.
+func newCanvas(r image.Rectangle) *image.RGBA {
+	n := 4 * r.Dx() * r.Dy()
+	if n == 0 {
+		return image.NewRGBA(r)
+	}
+	if pix, ok := canvases.Get().(*[]uint8); ok {
+		if c := cap(*pix); c >= n && c <= 2*n {
+			p := (*pix)[:n]
+			clear(p)
+			return &image.RGBA{Pix: p, Stride: 4 * r.Dx(), Rect: r}
+		}
+		canvases.Put(pix)
+	}
+	return image.NewRGBA(r)
+}
+
+// ReleaseImage gives the pixels of img, drawn by DrawGrid, RenderContext,
+// DrawPanel or the other Draw functions, or enlarged by ScaleImage, back
+// for images drawn later to reuse, once it has been encoded or is
+// otherwise done with. img is left empty and must not be drawn on or
+// read from again; releasing it twice does nothing, so an image and the
+// result of ScaleImage with a factor of 1, which is the same image, can
+// both be released.
+func ReleaseImage(img *image.RGBA) {
+	if img == nil || cap(img.Pix) == 0 {
+		return
+	}
+	pix := img.Pix[:0]
+	img.Pix = nil
+	img.Rect = image.Rectangle{}
+	canvases.Put(&pix)
+}
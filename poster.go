@@ -0,0 +1,108 @@
+package interactions
+
+import (
+	"image"
+	"strings"
+)
+
+// DrawPoster draws scenarios one above another in a single column, under
+// the title and legend, with a band across the poster heading each run of
+// scenarios that share a value of the dimension dim, as in "AB:
+// mutualism", so the structure of the taxonomy shows.
+func DrawPoster(scenarios []Scenario, dim string, th Theme, opts ...Option) *image.RGBA {
+	p := newPosterLayout(scenarios, dim, collectOptions(opts))
+	canvas := newCanvas(p.bounds())
+	p.draw(canvas, th)
+	return p.opts.finish(canvas)
+}
+
+// PosterBounds returns the bounds of the image DrawPoster would draw,
+// without drawing it.
+func PosterBounds(scenarios []Scenario, dim string, opts ...Option) image.Rectangle {
+	return newPosterLayout(scenarios, dim, collectOptions(opts)).bounds()
+}
+
+// PosterPanelRects returns where DrawPoster draws the panel of each
+// scenario.
+func PosterPanelRects(scenarios []Scenario, dim string, opts ...Option) []image.Rectangle {
+	return newPosterLayout(scenarios, dim, collectOptions(opts)).panels
+}
+
+// sectionHeight is the height of a poster's section heading bands.
+const sectionHeight = 30
+
+// posterLayout is the geometry of a DrawPoster image.
+type posterLayout struct {
+	scenarios     []Scenario
+	legendHeight  int
+	width, height int
+	// panels are the areas of the scenario panels, and sections the bands
+	// heading each section with their headings
+	panels   []image.Rectangle
+	sections []image.Rectangle
+	headings []string
+	geo      geometry
+	opts     options
+}
+
+func newPosterLayout(scenarios []Scenario, dim string, opts options) posterLayout {
+	geo := opts.fitGeometry(scenarios)
+	p := posterLayout{scenarios: scenarios, geo: geo, opts: opts}
+	m := geo.margin
+
+	// wide enough for the title over the column of panels
+	p.width = max(geo.panelW, textWidth(opts.gridTitle(scenarios))) + 2*m
+	p.legendHeight = opts.legendHeight(scenarios, p.width-2*m)
+	panelHeight := panelHeightFor(scenarios, geo)
+
+	x := (p.width - geo.panelW) / 2
+	y := p.legendRect().Max.Y + m
+	for i, s := range scenarios {
+		value := Field(s, dim)
+		if i == 0 || value != Field(scenarios[i-1], dim) {
+			p.sections = append(p.sections, image.Rect(m, y, p.width-m, y+sectionHeight))
+			p.headings = append(p.headings, opts.sectionHeading(dim, value))
+			y += sectionHeight + m
+		}
+		p.panels = append(p.panels, image.Rect(x, y, x+geo.panelW, y+panelHeight))
+		y += panelHeight + m
+	}
+	p.height = y + opts.footerHeight()
+	return p
+}
+
+// sectionHeading is the heading of the poster section of scenarios with
+// value for dim, e.g. "AB: mutualism".
+func (o options) sectionHeading(dim, value string) string {
+	if value == "" {
+		return o.tr("%s: unset", strings.ToUpper(dim))
+	}
+	return strings.ToUpper(dim) + ": " + value
+}
+
+func (p posterLayout) bounds() image.Rectangle {
+	return image.Rect(0, 0, p.width, p.height)
+}
+
+func (p posterLayout) legendRect() image.Rectangle {
+	m := p.geo.margin
+	top := m + headerHeight
+	return image.Rect(m, top, p.width-m, top+p.legendHeight)
+}
+
+func (p posterLayout) draw(canvas *image.RGBA, th Theme) {
+	fillRect(canvas, canvas.Bounds(), th.Background)
+	drawHeader(canvas, p.width, p.legendRect(), p.scenarios, th, p.opts)
+
+	for i, band := range p.sections {
+		fillRect(canvas, band, th.Panel)
+		drawRectBorder(canvas, band, th.LegendBorder, p.opts.strokeWidth())
+		drawCenteredLabel(canvas, p.headings[i], (band.Min.X+band.Max.X)/2, band.Min.Y+sectionHeight/2+4, th.Title)
+	}
+	for i, s := range p.scenarios {
+		drawScenario(canvas, p.panels[i], s, th, p.opts, p.geo, i)
+		p.opts.checkText(s, p.geo, i)
+		p.opts.panelDone(i+1, len(p.scenarios))
+	}
+	p.opts.drawStamps(canvas, p.bounds(), th)
+}
@@ -0,0 +1,270 @@
+package interactions
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Query selects scenarios by their dimensions. The syntax is a boolean
+// expression over comparisons:
+//
+//	ab=mutualism and c!=none and type=process*
+//	(c=a or c=both) and not feedback=none
+//
+// A comparison is a field, = or !=, and a value. Fields are dimension names
+// plus title, subtitle and description; see QueryFields. Values compare without regard to case and may
+// use the wildcards * and ?; quote them ("...") to include spaces. not binds
+// tightest, then and, then or.
+type Query struct {
+	src   string
+	match func(Scenario) bool
+	// fields lists the fields the query compares, in order of appearance.
+	fields []string
+}
+
+// ParseQuery compiles a query.
+func ParseQuery(src string) (*Query, error) {
+	toks, err := tokenizeQuery(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("query: unexpected %s at offset %d", t, t.pos)
+	}
+	return &Query{src: src, match: match, fields: p.fields}, nil
+}
+
+// Match reports whether s satisfies the query.
+func (q *Query) Match(s Scenario) bool {
+	return q.match(s)
+}
+
+// Fields returns the fields the query compares.
+func (q *Query) Fields() []string {
+	return q.fields
+}
+
+func (q *Query) String() string {
+	return q.src
+}
+
+// textFields are the query fields holding a scenario's text rather than a
+// dimension.
+var textFields = []string{"title", "subtitle", "description"}
+
+// QueryFields returns the fields a query can compare of scenarios, sorted:
+// title, subtitle and description, and every dimension any of them has.
+func QueryFields(scenarios []Scenario) []string {
+	known := map[string]bool{}
+	for _, name := range textFields {
+		known[name] = true
+	}
+	for _, s := range scenarios {
+		for name := range s.Dimensions {
+			known[name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(known))
+}
+
+// Field returns the value of a query field for s: its title, subtitle or
+// description, or a dimension. Scenarios without the dimension have the
+// empty value.
+func Field(s Scenario, name string) string {
+	switch name {
+	case "title":
+		return s.Title
+	case "subtitle":
+		return s.Subtitle
+	case "description":
+		return s.Description
+	}
+	return s.Dimensions[name]
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokEq
+	tokNe
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// tokenizeQuery splits a query into words, quoted strings, comparison
+// operators and parentheses.
+func tokenizeQuery(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '=':
+			toks = append(toks, token{tokEq, "=", i})
+			i++
+		case c == '!' && i+1 < len(src) && src[i+1] == '=':
+			toks = append(toks, token{tokNe, "!=", i})
+			i += 2
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("query: unterminated string at offset %d", i)
+			}
+			toks = append(toks, token{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		default:
+			start := i
+			for i < len(src) && !strings.ContainsRune(" \t\n()=!\"", rune(src[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("query: unexpected %q at offset %d", c, i)
+			}
+			toks = append(toks, token{tokWord, src[start:i], start})
+		}
+	}
+	return append(toks, token{tokEOF, "", len(src)}), nil
+}
+
+// queryParser is a recursive descent parser that compiles as it goes, each
+// rule returning a predicate.
+type queryParser struct {
+	toks   []token
+	pos    int
+	fields []string
+}
+
+func (p *queryParser) peek() token { return p.toks[p.pos] }
+
+func (p *queryParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the keyword kw.
+func (p *queryParser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokWord && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (func(Scenario) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s Scenario) bool { return l(s) || right(s) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (func(Scenario) bool, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s Scenario) bool { return l(s) && right(s) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (func(Scenario) bool, error) {
+	if p.keyword("not") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(s Scenario) bool { return !inner(s) }, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("query: expected ')' at offset %d, found %s", t.pos, t)
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (func(Scenario) bool, error) {
+	name := p.next()
+	if name.kind != tokWord {
+		return nil, fmt.Errorf("query: expected a field name at offset %d, found %s", name.pos, name)
+	}
+	op := p.next()
+	if op.kind != tokEq && op.kind != tokNe {
+		return nil, fmt.Errorf("query: expected = or != after %s at offset %d, found %s", name, op.pos, op)
+	}
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, fmt.Errorf("query: expected a value after %s%s at offset %d, found %s", name.text, op.text, value.pos, value)
+	}
+
+	fieldName := strings.ToLower(name.text)
+	pattern := strings.ToLower(value.text)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("query: bad pattern %s at offset %d", value, value.pos)
+	}
+	p.fields = append(p.fields, fieldName)
+
+	want := op.kind == tokEq
+	return func(s Scenario) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(Field(s, fieldName)))
+		return ok == want
+	}, nil
+}
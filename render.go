@@ -0,0 +1,1911 @@
+package interactions
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ----------------------------------------------------------------------
+// Rendering
+// ----------------------------------------------------------------------
+
+// Theme is the colour scheme of a rendered image.
+type Theme struct {
+	Background   color.RGBA // behind the panels
+	Panel        color.RGBA // panel and legend fill
+	PanelBorder  color.RGBA
+	LegendBorder color.RGBA
+	Title        color.RGBA // titles and headings
+	Text         color.RGBA // legend entries and node labels
+	MutedText    color.RGBA // subtitles and explanatory notes
+	Edge         color.RGBA
+	NodeFill     color.RGBA
+	NodeBorder   color.RGBA
+	Accent       color.RGBA // ecological signs
+}
+
+// themes are the built-in colour schemes, selectable by name.
+var themes = map[string]Theme{
+	"light": {
+		Background:   color.RGBA{240, 240, 240, 255},
+		Panel:        color.RGBA{255, 255, 255, 255},
+		PanelBorder:  color.RGBA{180, 180, 180, 255},
+		LegendBorder: color.RGBA{120, 120, 120, 255},
+		Title:        color.RGBA{20, 20, 20, 255},
+		Text:         color.RGBA{0, 0, 0, 255},
+		MutedText:    color.RGBA{70, 70, 70, 255},
+		Edge:         color.RGBA{0, 0, 0, 255},
+		NodeFill:     color.RGBA{220, 235, 250, 255},
+		NodeBorder:   color.RGBA{20, 40, 120, 255},
+		Accent:       color.RGBA{150, 40, 40, 255},
+	},
+	"dark": {
+		Background:   color.RGBA{28, 28, 30, 255},
+		Panel:        color.RGBA{44, 44, 48, 255},
+		PanelBorder:  color.RGBA{90, 90, 96, 255},
+		LegendBorder: color.RGBA{120, 120, 128, 255},
+		Title:        color.RGBA{235, 235, 235, 255},
+		Text:         color.RGBA{220, 220, 220, 255},
+		MutedText:    color.RGBA{160, 160, 165, 255},
+		Edge:         color.RGBA{220, 220, 220, 255},
+		NodeFill:     color.RGBA{40, 70, 110, 255},
+		NodeBorder:   color.RGBA{140, 180, 240, 255},
+		Accent:       color.RGBA{240, 120, 120, 255},
+	},
+}
+
+// ThemeNamed looks up a built-in theme.
+func ThemeNamed(name string) (Theme, error) {
+	th, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (want light or dark)", name)
+	}
+	return th, nil
+}
+
+// Grid geometry in pixels, unless changed by WithPanelSize and WithMargin.
+const (
+	panelW       = 360
+	panelH       = 220
+	gridMargin   = 20
+	headerHeight = 50
+)
+
+// geometry is the size of the panels and the space between them, with the
+// parts of the diagram that scale with the panel.
+type geometry struct {
+	panelW, panelH, margin int
+	// upperRowY and lowerRowY are the heights of the rows of nodes within
+	// a panel
+	upperRowY, lowerRowY int
+	rowStagger           int
+	nodeRadius           int
+}
+
+// minNodeRadius keeps a node's label inside its circle in small panels.
+const minNodeRadius = 9
+
+// geometry returns the geometry set by WithPanelSize and WithMargin. Text
+// keeps the size of the pixel font, so the room for the title above the
+// upper row of nodes and for self-loops and the code below the lower row
+// stay the same; the rows spread apart, and the node circles grow with the
+// panel.
+func (o options) geometry() geometry {
+	g := geometry{
+		panelW: cmp.Or(o.panelWidth, panelW),
+		panelH: cmp.Or(o.panelHeight, panelH),
+		margin: gridMargin,
+	}
+	if o.margin != nil {
+		g.margin = *o.margin
+	}
+	sx, sy := float64(g.panelW)/panelW, float64(g.panelH)/panelH
+	g.nodeRadius = max(int(math.Round(nodeRadius*math.Min(sx, sy))), minNodeRadius)
+	g.upperRowY = upperRowY - nodeRadius + g.nodeRadius
+	g.lowerRowY = g.panelH - (panelH - lowerRowY)
+	g.rowStagger = rowStagger * (g.lowerRowY - g.upperRowY) / (lowerRowY - upperRowY)
+	return g
+}
+
+// fitGeometry returns the geometry of WithPanelSize and WithMargin with the
+// panels widened, or their nodes shrunk, to fit the widest row of nodes
+// among scenarios drawn with options o, and the panel height grown, if need
+// be, to fit the tallest content: titles and subtitles wrapped onto several
+// lines push the diagram down, and a title below the diagram takes room
+// under it. Panels share the size so the rows of a grid line up.
+func (o options) fitGeometry(scenarios []Scenario) geometry {
+	geo := o.geometry().fitRow(o.widestRow(scenarios), largestNode(scenarios))
+	h := geo.panelH
+	for i, s := range scenarios {
+		h = max(h, o.contentHeight(i, s, geo))
+	}
+	geo.panelH = h
+	return geo
+}
+
+// minNodeGap is the least space left between neighbouring nodes of a row.
+const minNodeGap = 8
+
+// maxWidening is how many times as wide as set panels grow to fit their
+// widest row of nodes before the nodes shrink instead.
+const maxWidening = 2
+
+// fitRow returns g with room across a panel for a row of nodes span gaps
+// wide, each gap at least as wide as a node scale times the usual size and
+// minNodeGap: the panel grows up to maxWidening times as wide, and then the
+// nodes shrink, down to minNodeRadius. Past that the nodes overlap, which
+// checkText warns of.
+func (g geometry) fitRow(span int, scale float64) geometry {
+	// the node centres run from two radii in from either side
+	need := func(r int) int { return 4*r + span*(int(math.Ceil(2*float64(r)*scale))+minNodeGap) }
+	if span == 0 || need(g.nodeRadius) <= g.panelW {
+		return g
+	}
+	g.panelW = min(need(g.nodeRadius), maxWidening*g.panelW)
+	fit := float64(g.panelW-span*minNodeGap) / (4 + 2*float64(span)*scale)
+	r := max(min(g.nodeRadius, int(fit)), minNodeRadius)
+	// the upper row stays a node's width from the title
+	g.upperRowY -= g.nodeRadius - r
+	g.nodeRadius = r
+	g.rowStagger = rowStagger * (g.lowerRowY - g.upperRowY) / (lowerRowY - upperRowY)
+	return g
+}
+
+// widestRow is the most gaps between neighbouring nodes of a row of the
+// layered or Sugiyama layout of any of scenarios: one fewer than the
+// events of the row, or as many as the processes side by side in the band
+// between the rows, with the events of a lower row when they share its
+// width. Other layouts place nodes as they see fit, and have none.
+func (o options) widestRow(scenarios []Scenario) int {
+	span := 0
+	for _, s := range scenarios {
+		var rows [][]string
+		var processes []string
+		switch o.layout.(type) {
+		case nil, Layered:
+			early, late, p := chronology(s)
+			rows, processes = [][]string{early, late}, p
+		case Sugiyama:
+			rows, processes, _ = sugiyamaRows(s)
+		default:
+			continue
+		}
+		span = max(span, len(processes))
+		for k, row := range rows {
+			span = max(span, len(row)-1)
+			if k > 0 && len(processes) > 0 {
+				span = max(span, len(row)+len(processes))
+			}
+		}
+	}
+	return span
+}
+
+// largestNode is how many times the usual size the largest node of Sizes
+// in scenarios is, and 1 when none are larger.
+func largestNode(scenarios []Scenario) float64 {
+	scale := 1.0
+	for _, s := range scenarios {
+		for _, size := range s.Sizes {
+			scale = math.Max(scale, math.Max(float64(size.Radius)/nodeRadius, float64(size.Width)/(2*nodeRadius)))
+		}
+	}
+	return scale
+}
+
+// contentHeight is how tall a panel must be, above any description, to fit
+// the title and diagram of s, drawn as the i'th panel, leaving the room the
+// default panel leaves below the lower row of nodes for self-loops and the
+// code.
+func (o options) contentHeight(i int, s Scenario, geo geometry) int {
+	c := o.caption(i)
+	rect := image.Rect(0, 0, geo.panelW, geo.panelH)
+	text, _, subtitleY, shift := c.text(s, rect, geo)
+	footer := geo.panelH - geo.lowerRowY - geo.nodeRadius
+	h := o.layoutScenario(s, rect, shift, geo).bottom() + footer
+	if c.Title && c.TitleBelow {
+		// the last line clears the code and number under it
+		last := subtitleY + (len(text.subtitle)-1)*lineHeight
+		h = max(h, last+lineHeight+12)
+	}
+	return h
+}
+
+// DrawGrid draws the title, legend and every scenario panel into a new
+// image, columns panels wide.
+func DrawGrid(scenarios []Scenario, columns int, th Theme, opts ...Option) *image.RGBA {
+	g := newGridLayout(scenarios, columns, collectOptions(opts))
+	canvas := newCanvas(g.bounds())
+	g.draw(canvas, th)
+	return g.opts.finish(canvas)
+}
+
+// RenderContext draws the grid as DrawGrid does, but checks ctx between
+// panels and gives up with its error once it is done, so a server can stop
+// drawing for a client that has gone away. It then runs any
+// WithPostProcess hooks on the finished image.
+func RenderContext(ctx context.Context, scenarios []Scenario, columns int, th Theme, opts ...Option) (*image.RGBA, error) {
+	g := newGridLayout(scenarios, columns, collectOptions(opts))
+	canvas := newCanvas(g.bounds())
+	if err := g.drawContext(ctx, canvas, th); err != nil {
+		return nil, err
+	}
+	if err := g.opts.runPostProcess(canvas); err != nil {
+		return nil, err
+	}
+	return canvas, nil
+}
+
+// LayoutGrid works out the geometry of the grid and where the nodes of
+// every panel go, as DrawGrid does, without drawing anything, and returns
+// the grid's bounds. It is there to time layout apart from drawing.
+func LayoutGrid(scenarios []Scenario, columns int, opts ...Option) image.Rectangle {
+	g := newGridLayout(scenarios, columns, collectOptions(opts))
+	for i, s := range scenarios {
+		rect := g.panelRect(i)
+		_, _, _, shift := g.opts.caption(i).text(s, rect, g.geo)
+		g.opts.layoutScenario(s, rect, shift, g.geo)
+	}
+	return g.bounds()
+}
+
+// PanelRects returns where DrawGrid and WriteTiledGrid draw the panel of
+// each scenario, for making the panels of the image clickable.
+func PanelRects(scenarios []Scenario, columns int, opts ...Option) []image.Rectangle {
+	g := newGridLayout(scenarios, columns, collectOptions(opts))
+	rects := make([]image.Rectangle, len(scenarios))
+	for i := range rects {
+		rects[i] = g.panelRect(i)
+	}
+	return rects
+}
+
+// GridBounds returns the bounds of the image DrawGrid would draw, without
+// laying out or drawing any panel, so callers can refuse grids too large to
+// draw.
+func GridBounds(scenarios []Scenario, columns int, opts ...Option) image.Rectangle {
+	return newGridLayout(scenarios, columns, collectOptions(opts)).bounds()
+}
+
+// gridLayout is the geometry of the full grid image.
+type gridLayout struct {
+	scenarios     []Scenario
+	columns, rows int
+	legendHeight  int
+	panelHeight   int
+	width, height int
+	geo           geometry
+	opts          options
+}
+
+func newGridLayout(scenarios []Scenario, columns int, opts options) gridLayout {
+	geo := opts.fitGeometry(scenarios)
+	g := gridLayout{
+		scenarios:   scenarios,
+		columns:     columns,
+		rows:        (len(scenarios) + columns - 1) / columns,
+		panelHeight: panelHeightFor(scenarios, geo),
+		geo:         geo,
+		opts:        opts,
+	}
+	g.width = g.columns*geo.panelW + (g.columns+1)*geo.margin
+	g.legendHeight = opts.legendHeight(scenarios, g.width-2*geo.margin)
+	g.height = headerHeight + g.legendHeight + g.rows*g.panelHeight + (g.rows+2)*geo.margin + opts.footerHeight()
+	return g
+}
+
+func (g gridLayout) bounds() image.Rectangle {
+	return image.Rect(0, 0, g.width, g.height)
+}
+
+// legendRect is the legend area under the title, empty when the legend is
+// hidden.
+func (g gridLayout) legendRect() image.Rectangle {
+	m := g.geo.margin
+	top := m + headerHeight
+	return image.Rect(m, top, g.width-m, top+g.legendHeight)
+}
+
+// panelRect is the area of the i'th scenario panel.
+func (g gridLayout) panelRect(i int) image.Rectangle {
+	m := g.geo.margin
+	x := m + (i%g.columns)*(g.geo.panelW+m)
+	y := g.legendRect().Max.Y + m + (i/g.columns)*(g.panelHeight+m)
+	return image.Rect(x, y, x+g.geo.panelW, y+g.panelHeight)
+}
+
+// draw draws the part of the grid that falls within canvas's bounds, which
+// need not cover the whole grid. Panels are drawn if they come within a
+// margin of the canvas so anything they draw past their own edge still
+// appears.
+func (g gridLayout) draw(canvas *image.RGBA, th Theme) {
+	_ = g.drawContext(context.Background(), canvas, th)
+}
+
+// drawContext is draw, stopping with ctx's error before the next panel
+// once ctx is done.
+func (g gridLayout) drawContext(ctx context.Context, canvas *image.RGBA, th Theme) error {
+	area := canvas.Bounds()
+	fillRect(canvas, area, th.Background)
+
+	legend := g.legendRect()
+	if area.Min.Y < legend.Max.Y {
+		drawHeader(canvas, g.width, legend, g.scenarios, th, g.opts)
+	}
+
+	// Panels below legend
+	for i, s := range g.scenarios {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		panel := g.panelRect(i)
+		if panel.Inset(-g.geo.margin).Overlaps(area) {
+			drawScenario(canvas, panel, s, th, g.opts, g.geo, i)
+		}
+		// a tiled grid draws a panel in the band above its own too, so
+		// count it only where it is drawn whole
+		if panel.In(area) {
+			g.opts.checkText(s, g.geo, i)
+			g.opts.panelDone(i+1, len(g.scenarios))
+		}
+	}
+	g.opts.drawStamps(canvas, g.bounds(), th)
+	return nil
+}
+
+// drawHeader draws the title and repo URL across the top of an image width
+// wide, and the legend into legend unless it is empty.
+func drawHeader(canvas *image.RGBA, width int, legend image.Rectangle, scenarios []Scenario, th Theme, o options) {
+	title := o.gridTitle(scenarios)
+	if o.pages > 1 {
+		title += o.tr(" (page %d of %d)", o.page, o.pages)
+	}
+	drawTitle(canvas, width, title, th, o)
+	if !legend.Empty() {
+		o.drawLegendFor(canvas, legend, scenarios, th)
+	}
+}
+
+// drawTitle draws title and the source line under it across the top of an
+// image width wide.
+func drawTitle(canvas *image.RGBA, width int, title string, th Theme, o options) {
+	m := o.geometry().margin
+	drawCenteredLabel(canvas, title, width/2, m+18, th.Title)
+	drawCenteredLabel(canvas, o.tr("Source: github.com/arran4/interactions"), width/2, m+36, th.MutedText)
+}
+
+// DrawPanel draws a single scenario panel, framed by the grid margin, into a
+// new image.
+func DrawPanel(s Scenario, th Theme, opts ...Option) *image.RGBA {
+	o := collectOptions(opts)
+	geo, rect, bounds := o.panelFrame(s)
+	canvas := newCanvas(bounds)
+	fillRect(canvas, canvas.Bounds(), th.Background)
+	drawScenario(canvas, rect, s, th, o, geo, 0)
+	o.checkText(s, geo, 0)
+	return o.finish(canvas)
+}
+
+// panelFrame returns the geometry of a single panel of s, where it goes,
+// and the bounds of the image framing it by the margin.
+func (o options) panelFrame(s Scenario) (geo geometry, rect, bounds image.Rectangle) {
+	geo = o.fitGeometry([]Scenario{s})
+	h := panelHeightFor([]Scenario{s}, geo)
+	m := geo.margin
+	return geo, image.Rect(m, m, m+geo.panelW, m+h), image.Rect(0, 0, geo.panelW+2*m, h+2*m)
+}
+
+// ScaleImage enlarges img by an integer factor using nearest-neighbour
+// sampling, which keeps the pixel-font text crisp.
+func ScaleImage(img *image.RGBA, factor int) *image.RGBA {
+	if factor <= 1 {
+		return img
+	}
+	b := img.Bounds()
+	out := newCanvas(image.Rect(0, 0, b.Dx()*factor, b.Dy()*factor))
+	for y := 0; y < out.Bounds().Dy(); y++ {
+		for x := 0; x < out.Bounds().Dx(); x++ {
+			out.SetRGBA(x, y, img.RGBAAt(b.Min.X+x/factor, b.Min.Y+y/factor))
+		}
+	}
+	return out
+}
+
+// gridTitle is the heading of the grid. The generated taxonomy is described
+// by its actors; scenario files, which need not feature A and B, get a
+// generic heading.
+func (o options) gridTitle(scenarios []Scenario) string {
+	for _, s := range scenarios {
+		if !slices.Contains(s.Nodes, "A") || !slices.Contains(s.Nodes, "B") {
+			return o.tr("Interaction patterns")
+		}
+	}
+	core := coreActors(scenarios)
+	externals := o.nameList(otherActors(scenarios, core))
+	if len(core) > 2 {
+		if externals != "" {
+			return o.tr("Interaction patterns of %s with %s (all basic combinations)", o.nameList(core), externals)
+		}
+		return o.tr("Interaction patterns of %s (all basic combinations)", o.nameList(core))
+	}
+	if externals != "" {
+		return o.tr("Interaction patterns of A and B with %s (all basic combinations)", externals)
+	}
+	return o.tr("Interaction patterns of A and B (all basic combinations)")
+}
+
+// coreActors returns A, B and the other core actors of a taxonomy
+// generated with more than two: those every scenario records the pattern
+// of A with, as dimension "ac" does for C.
+func coreActors(scenarios []Scenario) []string {
+	core := []string{"A", "B"}
+	for c := 'C'; c <= 'Z'; c++ {
+		for _, s := range scenarios {
+			if _, ok := s.Dimensions["a"+strings.ToLower(string(c))]; !ok {
+				return core
+			}
+		}
+		core = append(core, string(c))
+	}
+	return core
+}
+
+// otherActors returns the nodes of scenarios other than core, in order.
+func otherActors(scenarios []Scenario, core []string) []string {
+	seen := map[string]bool{}
+	for _, name := range core {
+		seen[name] = true
+	}
+	var names []string
+	for _, s := range scenarios {
+		for _, n := range s.Nodes {
+			if !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nameList lists names as in "C, D and E", or "" when there are none.
+func (o options) nameList(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	default:
+		return o.tr("%s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+}
+
+// panelText is the wrapped title and subtitle of a panel.
+type panelText struct {
+	title, subtitle []string
+}
+
+// wrapPanelText wraps the title and subtitle of s to fit a panel of the
+// given width.
+func wrapPanelText(s Scenario, width int) panelText {
+	maxTextWidth := lineRoom(width)
+	return panelText{
+		title:    wrapText(s.Title, maxTextWidth),
+		subtitle: wrapText(s.Subtitle, maxTextWidth),
+	}
+}
+
+// The description sits below the diagram, with the first baseline
+// descriptionTop below the usual bottom of the panel, clear of self-loops
+// on the lower row, and descriptionGap from the last line to the bottom of
+// the panel, where the code and number move to.
+const (
+	descriptionTop = 4
+	descriptionGap = 12
+)
+
+// wrapDescription wraps the description of s to fit a panel width wide.
+func wrapDescription(s Scenario, width int) []string {
+	return wrapText(s.Description, lineRoom(width))
+}
+
+// panelHeightFor is the height of the panels of scenarios: the panel
+// height of geo, grown to fit the longest description below the diagram.
+// Panels share a height so the rows of a grid line up.
+func panelHeightFor(scenarios []Scenario, geo geometry) int {
+	h := geo.panelH
+	for _, s := range scenarios {
+		if lines := wrapDescription(s, geo.panelW); len(lines) > 0 {
+			h = max(h, geo.panelH+descriptionTop+len(lines)*lineHeight+descriptionGap)
+		}
+	}
+	return h
+}
+
+// descriptionY is the baseline of the first line of a panel's description.
+func descriptionY(rect image.Rectangle, geo geometry) int {
+	return rect.Min.Y + geo.panelH + descriptionTop
+}
+
+// extraHeight is how much taller the text is than one line each of title
+// and subtitle; the diagram moves down by this much.
+func (t panelText) extraHeight() int {
+	extra := (len(t.title)-1)*lineHeight + (len(t.subtitle)-1)*lineHeight
+	if extra < 0 {
+		extra = 0
+	}
+	return extra
+}
+
+// subtitleY is the baseline of the first subtitle line, relative to the
+// panel top.
+func (t panelText) subtitleY() int {
+	return 22 + len(t.title)*lineHeight + 6
+}
+
+// panelCaption is the text drawn on one panel.
+type panelCaption struct {
+	Captions
+	number int
+	// badge is the WithBadges note
+	badge string
+}
+
+// captionlessShift moves the diagram up when there is no title above it,
+// keeping self-loops on the upper row inside the panel.
+const captionlessShift = -35
+
+// text returns the lines of s's title and subtitle to draw, their
+// baselines, and how far the diagram moves down to make room for them.
+func (c panelCaption) text(s Scenario, rect image.Rectangle, geo geometry) (text panelText, titleY, subtitleY, shift int) {
+	if !c.Title {
+		return panelText{}, 0, 0, captionlessShift
+	}
+	text = wrapPanelText(s, rect.Dx())
+	if !c.TitleBelow {
+		return text, rect.Min.Y + 22, rect.Min.Y + text.subtitleY(), text.extraHeight()
+	}
+	// below the lower row of nodes, which moves up to make room for any
+	// wrapped lines while the upper row stays a node's width from the top;
+	// past that the panel grows to fit them
+	shift = max(captionlessShift-text.extraHeight(), 2*geo.nodeRadius-geo.upperRowY)
+	titleY = rect.Min.Y + geo.lowerRowY + shift + geo.nodeRadius + 18
+	return text, titleY, titleY + len(text.title)*lineHeight + 6, shift
+}
+
+// codePos is the baseline start of a panel's code, in its bottom right
+// corner below the diagram.
+func codePos(rect image.Rectangle, code string) (x, y int) {
+	return rect.Max.X - 8 - textWidth(code), rect.Max.Y - 8
+}
+
+// badgePos is the baseline start of a panel's badge, centred at its
+// bottom between the number and the code.
+func badgePos(rect image.Rectangle, badge string) (x, y int) {
+	return (rect.Min.X+rect.Max.X)/2 - textWidth(badge)/2, rect.Max.Y - 8
+}
+
+// indexPos is the baseline start of a panel's number, in its bottom left
+// corner.
+func indexPos(rect image.Rectangle) (x, y int) {
+	return rect.Min.X + 8, rect.Max.Y - 8
+}
+
+// The usual heights of the rows of nodes within a panel of the default
+// size.
+const (
+	upperRowY = 90
+	lowerRowY = 170
+)
+
+// panelLayout is where the nodes of a scenario sit within a panel.
+type panelLayout struct {
+	positions map[string]image.Point
+	// shapes holds the process boxes and the event circles of Sizes;
+	// nodes missing from it are circles of radius
+	shapes map[string]nodeShape
+	// radius is the usual radius of the event nodes
+	radius int
+	// lowerY is the y of the lower (later) row
+	lowerY int
+	// bends are the points edges pass through on their way, by the edge's
+	// index in the scenario's Edges; edges missing from it are straight
+	bends map[int][]image.Point
+}
+
+// loopDir is the vertical direction a self-loop on the node at pt bulges
+// in: below lower-row nodes, clear of their incoming edges, otherwise
+// above.
+func (l panelLayout) loopDir(pt image.Point) float64 {
+	if pt.Y == l.lowerY {
+		return 1
+	}
+	return -1
+}
+
+// chronology splits the nodes of s into the earlier ones, with no incoming
+// arrows, drawn in the upper row, the later ones drawn in the lower row, and
+// the processes, which are placed by their spans instead.
+func chronology(s Scenario) (early, late, processes []string) {
+	// Compute incoming edge counts
+	incoming := map[string]int{}
+	for _, n := range s.Nodes {
+		incoming[n] = 0
+	}
+	for _, e := range s.Edges {
+		if e.From == e.To {
+			// self-loops say nothing about chronology
+			continue
+		}
+		incoming[e.To]++
+		if _, _, tail := e.heads(); tail {
+			// mutualism: treat as two directed edges for layering
+			incoming[e.From]++
+		}
+	}
+
+	// Processes are placed by their spans rather than by the rows
+	for _, n := range s.Nodes {
+		if _, ok := s.Spans[n]; ok {
+			processes = append(processes, n)
+		} else if incoming[n] == 0 {
+			early = append(early, n)
+		} else {
+			late = append(late, n)
+		}
+	}
+
+	// Fallback: if the graph is fully cyclic, the nodes with the fewest
+	// incoming edges go in the upper row, which is every node when they
+	// tie.
+	if len(early) == 0 && len(late) > 0 {
+		fewest := incoming[late[0]]
+		for _, n := range late {
+			fewest = min(fewest, incoming[n])
+		}
+		var rest []string
+		for _, n := range late {
+			if incoming[n] == fewest {
+				early = append(early, n)
+			} else {
+				rest = append(rest, n)
+			}
+		}
+		late = rest
+	}
+	return early, late, processes
+}
+
+// layoutScenario places the nodes of s in the panel rect with the layout
+// set by WithLayout, the layered one by default.
+//
+// shift moves the diagram down from its usual place, below one line each of
+// title and subtitle, or up when negative.
+func (o options) layoutScenario(s Scenario, rect image.Rectangle, shift int, geo geometry) panelLayout {
+	r := geo.nodeRadius
+	area := layoutArea(rect, shift, geo)
+	var layout panelLayout
+	switch l := o.layout.(type) {
+	case nil, Layered:
+		positions, shapes := placeLayered(s, area, r, geo.rowStagger)
+		layout = panelLayout{positions: positions, shapes: shapes, lowerY: area.Max.Y, radius: r}
+	case Sugiyama:
+		layout = placeSugiyama(s, area, r, geo.rowStagger)
+	default:
+		positions := maps.Clone(l.Place(s, area))
+		if positions == nil {
+			positions = map[string]image.Point{}
+		}
+		placeMissing(s, positions, area)
+		layout = panelLayout{positions: positions, shapes: processBoxes(s, area, r), lowerY: area.Max.Y, radius: r}
+	}
+	sizeNodes(s, layout.shapes, r)
+	if o.bundle {
+		o.bundleEdges(s, &layout)
+	}
+	avoidObstacles(s, &layout)
+	return layout
+}
+
+// layoutArea is where the node centres of a panel at rect go: between the
+// rows of the layered layout, and across the panel clear of its sides.
+func layoutArea(rect image.Rectangle, shift int, geo geometry) image.Rectangle {
+	r := geo.nodeRadius
+	return image.Rect(rect.Min.X+2*r, rect.Min.Y+geo.upperRowY+shift, rect.Max.X-2*r, rect.Min.Y+geo.lowerRowY+shift)
+}
+
+// sizeNodes reshapes the nodes of s given Sizes, scaling them as event
+// nodes of radius r are scaled from nodeRadius.
+func sizeNodes(s Scenario, shapes map[string]nodeShape, r int) {
+	scaled := func(n int) int {
+		return max(1, int(math.Round(float64(n*r)/nodeRadius)))
+	}
+	for name, size := range s.Sizes {
+		sh, box := shapes[name]
+		switch {
+		case box && size.Width > 0:
+			sh.halfW = scaled(size.Width) / 2
+		case !box && size.Radius > 0:
+			sh = nodeShape{radius: scaled(size.Radius)}
+		default:
+			continue
+		}
+		shapes[name] = sh
+	}
+}
+
+// Within a panel, we infer simple chronology from the graph:
+// - nodes with no incoming arrows are "earlier" (upper row)
+// - nodes with at least one incoming arrow are "later" (lower row)
+// This means A and B don't have to be simultaneous or last, and in
+// mutualism-only cases (A ↔ B) they appear on the same row.
+//
+// placeLayered puts the upper row along the top of area and the lower row
+// along the bottom, with event nodes of radius r and rows of more than two
+// nodes staggered by stagger.
+func placeLayered(s Scenario, area image.Rectangle, r, stagger int) (map[string]image.Point, map[string]nodeShape) {
+	early, late, processes := chronology(s)
+	rows := orderRows([][]string{early, late}, edgeLinks(s))
+	return placeRows(s, rows, processes, area, r, stagger)
+}
+
+// placeRows puts each of rows of event nodes in a row across area, the
+// first along its top and each of the rest area's height further down, and
+// processes by their spans in the band between the first two rows.
+func placeRows(s Scenario, rows [][]string, processes []string, area image.Rectangle, r, stagger int) (map[string]image.Point, map[string]nodeShape) {
+	left, right := area.Min.X, area.Max.X
+	topY := area.Min.Y // more recent
+	botY := area.Max.Y // later
+
+	positions := map[string]image.Point{}
+	for k, row := range rows {
+		y := topY + k*area.Dy()
+		if len(row) == 1 {
+			positions[row[0]] = image.Point{(left + right) / 2, y}
+		} else if len(row) > 1 {
+			for i, name := range row {
+				x := left + (right-left)*i/(len(row)-1)
+				positions[name] = image.Point{x, y}
+			}
+		}
+	}
+
+	// An edge between two nodes of a row that are not neighbours would run
+	// through the nodes between them, so those move toward the other row,
+	// or the row above
+	for k, row := range rows {
+		if k == 0 {
+			staggerRow(positions, row, s.Edges, stagger)
+		} else {
+			staggerRow(positions, row, s.Edges, -stagger)
+		}
+	}
+
+	// Position processes side by side in the band below the upper row,
+	// each box running from its start to its end on the time axis, and as
+	// wide as an event node
+	shapes := map[string]nodeShape{}
+	bandTop := topY + r + 4
+	bandBot := botY + r
+	for i, name := range processes {
+		span := s.Spans[name]
+		x := left + (right-left)*(2*i+1)/(2*len(processes))
+		y0 := bandTop + int(math.Round(span.Start*float64(bandBot-bandTop)))
+		y1 := bandTop + int(math.Round(span.End*float64(bandBot-bandTop)))
+		if y1-y0 < r {
+			y1 = y0 + r
+		}
+		positions[name] = image.Point{x, (y0 + y1) / 2}
+		shapes[name] = nodeShape{halfW: r, halfH: (y1 - y0) / 2}
+	}
+
+	// A row under the band whose nodes a process box would cover shares
+	// the width with the processes instead, its nodes either side of them
+	for _, row := range rows[min(1, len(rows)):] {
+		if !coversRow(positions, shapes, row, processes, r) {
+			continue
+		}
+		slots := slices.Concat(row[:len(row)/2], processes, row[len(row)/2:])
+		for i, name := range slots {
+			p := positions[name]
+			p.X = left + (right-left)*(2*i+1)/(2*len(slots))
+			positions[name] = p
+		}
+	}
+
+	placeMissing(s, positions, area)
+	return positions, shapes
+}
+
+// coversRow reports whether the box of any of processes overlaps any of the
+// event nodes of row, of radius r.
+func coversRow(positions map[string]image.Point, shapes map[string]nodeShape, row, processes []string, r int) bool {
+	for _, p := range processes {
+		box, sh := positions[p], shapes[p]
+		for _, name := range row {
+			pt := positions[name]
+			if abs(pt.X-box.X) < r+sh.halfW && abs(pt.Y-box.Y) < r+sh.halfH {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// placeMissing puts any node of s without a position in the middle of
+// area.
+func placeMissing(s Scenario, positions map[string]image.Point, area image.Rectangle) {
+	for _, name := range s.Nodes {
+		if _, ok := positions[name]; !ok {
+			positions[name] = image.Point{(area.Min.X + area.Max.X) / 2, (area.Min.Y + area.Max.Y) / 2}
+		}
+	}
+}
+
+// bottom is the y of the lowest edge of any node.
+func (l panelLayout) bottom() int {
+	b := 0
+	for name, pt := range l.positions {
+		b = max(b, pt.Y+int(l.shape(name).rim(0, 1)))
+	}
+	return b
+}
+
+// shape is the outline of the node name.
+func (l panelLayout) shape(name string) nodeShape {
+	if sh, ok := l.shapes[name]; ok {
+		return sh
+	}
+	return nodeShape{radius: l.radius}
+}
+
+// rowStagger is how far staggerRow moves nodes off their row in a panel of
+// the default size.
+const rowStagger = 30
+
+// staggerRow moves the nodes of row that lie between the ends of an edge
+// within the row down by dy.
+func staggerRow(positions map[string]image.Point, row []string, edges []Edge, dy int) {
+	index := map[string]int{}
+	for i, n := range row {
+		index[n] = i
+	}
+	moved := map[string]bool{}
+	for _, e := range edges {
+		i, ok := index[e.From]
+		j, ok2 := index[e.To]
+		if !ok || !ok2 {
+			continue
+		}
+		for k := min(i, j) + 1; k < max(i, j); k++ {
+			moved[row[k]] = true
+		}
+	}
+	for n := range moved {
+		p := positions[n]
+		p.Y += dy
+		positions[n] = p
+	}
+}
+
+// drawScenario draws s as the i'th panel drawn with options o, in a panel
+// of geometry geo.
+func drawScenario(img *image.RGBA, rect image.Rectangle, s Scenario, th Theme, o options, geo geometry, i int) {
+	if o.cache != nil {
+		key := o.panelKey(s, th, geo, rect, i)
+		if o.cachedPanel(img, rect, key) {
+			return
+		}
+		defer o.cachePanel(img, rect, s, geo, i, key)
+	}
+	c := o.caption(i)
+	fillRect(img, rect, th.Panel)
+	drawRectBorder(img, rect, th.PanelBorder, o.strokeWidth())
+
+	// Title & subtitle
+	text, titleY, subtitleY, shift := c.text(s, rect, geo)
+	room := lineRoom(rect.Dx())
+	drawPanelLines(img, o.fitLines(text.title, room), rightToLeft(s.Title), rect, titleY, th.Title)
+	drawPanelLines(img, o.fitLines(text.subtitle, room), rightToLeft(s.Subtitle), rect, subtitleY, th.MutedText)
+	if c.Code && s.Code != "" {
+		code := o.fit(s.Code, codeRoom(rect.Dx()))
+		x, y := codePos(rect, code)
+		drawLabel(img, code, x, y, th.MutedText)
+	}
+	if c.Index {
+		x, y := indexPos(rect)
+		drawLabel(img, ListNumber(c.number), x, y, th.MutedText)
+	}
+	if c.badge != "" {
+		x, y := badgePos(rect, c.badge)
+		drawLabel(img, c.badge, x, y, th.Accent)
+	}
+
+	drawPanelLines(img, o.fitLines(wrapDescription(s, rect.Dx()), room), rightToLeft(s.Description), rect, descriptionY(rect, geo), th.Text)
+
+	layout := o.layoutScenario(s, rect, shift, geo)
+
+	// Draw edges first
+	for k, e := range s.Edges {
+		from := layout.positions[e.From]
+		to := layout.positions[e.To]
+		if e.From == e.To {
+			dirY := layout.loopDir(from)
+			drawSelfLoop(img, from.X, from.Y, 0, dirY, layout.shape(e.From).rim(0, dirY), e, o.strokeWidth(), o.edgeColor(e, th))
+			continue
+		}
+		if bends := layout.bends[k]; len(bends) > 0 {
+			line := routedLine(from, bends, to, layout.shape(e.From), layout.shape(e.To))
+			drawRoutedEdge(img, line, e, o.pen(), o.edgeColor(e, th), o.signColor(e, th))
+			continue
+		}
+		drawEdgeBetween(img, from.X, from.Y, to.X, to.Y, layout.shape(e.From), layout.shape(e.To), e, o.pen(), o.edgeColor(e, th), o.signColor(e, th))
+	}
+
+	// Draw nodes on top
+	for _, name := range s.Nodes {
+		pt := layout.positions[name]
+		fill, border, label := o.nodeColors(name, th)
+		sh := layout.shape(name)
+		if sh.box() {
+			box := image.Rect(pt.X-sh.halfW, pt.Y-sh.halfH, pt.X+sh.halfW, pt.Y+sh.halfH)
+			fillRect(img, box, fill)
+			drawRectBorder(img, box, border, o.strokeWidth())
+		} else {
+			drawNode(img, pt.X, pt.Y, sh.radius, o.strokeWidth(), fill, border)
+		}
+		for _, l := range o.nodeLabel(name, sh, pt) {
+			drawLabel(img, l.text, l.x, l.y, label)
+		}
+	}
+	if o.debugLayout {
+		o.drawLayoutDebug(img, rect, s, geo, c, layout)
+	}
+}
+
+// labelLine is a line of a node's name and the baseline start it is drawn
+// at.
+type labelLine struct {
+	text string
+	x, y int
+}
+
+// nodeLabel lays out the name of a node drawn as sh at pt, centred in it:
+// on one line, or when that is too wide for the node and the name has
+// several words, on two lines broken between them. Each line is fitted to
+// the node as o fits text.
+func (o options) nodeLabel(name string, sh nodeShape, pt image.Point) []labelLine {
+	room := labelRoom(sh)
+	lines := nodeLabelLines(name, room)
+	// the baseline of one line sits 5 pixels below the centre, and two
+	// lines sit either side of it
+	y := pt.Y + 5 - (len(lines)-1)*lineHeight/2
+	laid := make([]labelLine, len(lines))
+	for i, l := range lines {
+		l = o.fit(l, room)
+		laid[i] = labelLine{text: l, x: pt.X - textWidth(l)/2, y: y + i*lineHeight}
+	}
+	return laid
+}
+
+// nodeLabelLines breaks a node's name into the lines of nodeLabel, for a
+// node room wide, as evenly as its words allow.
+func nodeLabelLines(name string, room int) []string {
+	if textWidth(name) <= room {
+		return []string{name}
+	}
+	// break between the words where the wider line is narrowest
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return []string{name}
+	}
+	var best []string
+	bestWidth := 0
+	for i := 1; i < len(words); i++ {
+		lines := []string{strings.Join(words[:i], " "), strings.Join(words[i:], " ")}
+		if w := max(textWidth(lines[0]), textWidth(lines[1])); best == nil || w < bestWidth {
+			best, bestWidth = lines, w
+		}
+	}
+	return best
+}
+
+// usesStyle reports whether any edge in scenarios is drawn with style.
+func usesStyle(scenarios []Scenario, style EdgeStyle) bool {
+	for _, s := range scenarios {
+		for _, e := range s.Edges {
+			if e.style() == style {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesPolarity reports whether any edge in scenarios carries ecological signs.
+func usesPolarity(scenarios []Scenario) bool {
+	for _, s := range scenarios {
+		for _, e := range s.Edges {
+			if e.Polarity != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesSpans reports whether any scenario draws a node as a process.
+func usesSpans(scenarios []Scenario) bool {
+	for _, s := range scenarios {
+		if len(s.Spans) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// usesSelfLoop reports whether any edge in scenarios starts and ends at the
+// same node.
+func usesSelfLoop(scenarios []Scenario) bool {
+	for _, s := range scenarios {
+		for _, e := range s.Edges {
+			if e.From == e.To {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ----------------------------------------------------------------------
+// Drawing helpers
+// ----------------------------------------------------------------------
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// drawRectBorder draws the border of r, width pixels deep inside it.
+func drawRectBorder(img *image.RGBA, r image.Rectangle, c color.Color, width int) {
+	for i := 0; i < max(width, 1) && !r.Empty(); i++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, r.Min.Y, c)
+			img.Set(x, r.Max.Y-1, c)
+		}
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			img.Set(r.Min.X, y, c)
+			img.Set(r.Max.X-1, y, c)
+		}
+		r = r.Inset(1)
+	}
+}
+
+// latinFold spells the accented letters of the translations, and the
+// multiplication sign, in ASCII, the only characters the pixel font has.
+var latinFold = strings.NewReplacer(
+	"×", "x",
+	"ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss",
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n", "Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ñ", "N",
+)
+
+// arrowGlyphs are the arrows of the titles and legend, and the ellipsis of
+// text cut short, which the pixel font lacks, as bitmaps one character
+// wide. The last row is just above the baseline.
+var arrowGlyphs = map[rune][]string{
+	'→': {
+		"....#..",
+		".....#.",
+		"#######",
+		".....#.",
+		"....#..",
+		".......",
+		".......",
+	},
+	'←': {
+		"..#....",
+		".#.....",
+		"#######",
+		".#.....",
+		"..#....",
+		".......",
+		".......",
+	},
+	'↔': {
+		"..#.#..",
+		".#...#.",
+		"#######",
+		".#...#.",
+		"..#.#..",
+		".......",
+		".......",
+	},
+	'⊣': {
+		".....#.",
+		".....#.",
+		"######.",
+		".....#.",
+		".....#.",
+		".....#.",
+		".......",
+	},
+	'⊢': {
+		".#.....",
+		".#.....",
+		".######",
+		".#.....",
+		".#.....",
+		".#.....",
+		".......",
+	},
+	'…': {
+		".......",
+		".......",
+		".......",
+		".......",
+		".......",
+		".......",
+		"#..#..#",
+	},
+}
+
+func hasArrowGlyph(r rune) bool {
+	_, ok := arrowGlyphs[r]
+	return ok
+}
+
+// labelFace is the font every label is drawn in, and measured with.
+var labelFace font.Face = basicfont.Face7x13
+
+func drawLabel(img *image.RGBA, text string, x, y int, col color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: labelFace,
+		Dot:  fixed.P(x, y),
+	}
+	text = latinFold.Replace(text)
+	for {
+		i := strings.IndexFunc(text, hasArrowGlyph)
+		if i < 0 {
+			d.DrawString(text)
+			return
+		}
+		d.DrawString(text[:i])
+		r, size := utf8.DecodeRuneInString(text[i:])
+		drawGlyph(img, arrowGlyphs[r], d.Dot.X.Round(), y, col)
+		d.Dot.X += fixed.I(glyphWidth)
+		text = text[i+size:]
+	}
+}
+
+// drawGlyph draws a bitmap from arrowGlyphs with its left edge at x and its
+// last row above the baseline y.
+func drawGlyph(img *image.RGBA, rows []string, x, y int, col color.Color) {
+	top := y - len(rows)
+	for dy, row := range rows {
+		for dx, c := range row {
+			if c == '#' {
+				img.Set(x+dx, top+dy, col)
+			}
+		}
+	}
+}
+
+const (
+	// glyphWidth is the advance of the bitmaps of arrowGlyphs, a
+	// character of labelFace wide.
+	glyphWidth = 7
+	lineHeight = 14
+)
+
+// drawWrappedLabel renders text within a maximum width, wrapping at word
+// boundaries. It returns the total height used so callers can adjust layouts.
+func drawWrappedLabel(img *image.RGBA, text string, x, y, maxWidth int, col color.Color) int {
+	lines := wrapText(text, maxWidth)
+	drawLines(img, lines, x, y, col)
+	return len(lines) * lineHeight
+}
+
+// drawLines draws lines of text one below the other, the first with its
+// baseline at y.
+func drawLines(img *image.RGBA, lines []string, x, y int, col color.Color) {
+	for i, l := range lines {
+		drawLabel(img, l, x, y+i*lineHeight, col)
+	}
+}
+
+// drawPanelLines draws lines of panel text one below the other, the first
+// with its baseline at y, against the left side of rect, or the right side
+// when the text is right to left.
+func drawPanelLines(img *image.RGBA, lines []string, rtl bool, rect image.Rectangle, y int, col color.Color) {
+	for i, l := range lines {
+		drawLabel(img, l, panelLineX(l, rtl, rect), y+i*lineHeight, col)
+	}
+}
+
+// textPadding is how far panel text keeps from the sides of the panel.
+const textPadding = 10
+
+// panelLineX is the x a line of panel text starts at: the left margin of
+// rect, or for right-to-left text, as far right as the line fits.
+func panelLineX(line string, rtl bool, rect image.Rectangle) int {
+	if rtl {
+		return rect.Max.X - textPadding - textWidth(line)
+	}
+	return rect.Min.X + textPadding
+}
+
+// rightToLeft reports whether text is written right to left, going by its
+// first letter: Hebrew, Arabic and the other right-to-left scripts are.
+func rightToLeft(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko)
+		}
+	}
+	return false
+}
+
+// textWidth is the width of text as drawLabel draws it, from the advances
+// of labelFace and of the arrow glyphs drawn in its place.
+func textWidth(text string) int {
+	text = latinFold.Replace(text)
+	var width fixed.Int26_6
+	for {
+		i := strings.IndexFunc(text, hasArrowGlyph)
+		if i < 0 {
+			return (width + font.MeasureString(labelFace, text)).Ceil()
+		}
+		width += font.MeasureString(labelFace, text[:i]) + fixed.I(glyphWidth)
+		_, size := utf8.DecodeRuneInString(text[i:])
+		text = text[i+size:]
+	}
+}
+
+// wrapText splits text into lines no wider than maxWidth, breaking at word
+// boundaries. Blank text has no lines.
+func wrapText(text string, maxWidth int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var lines []string
+	line := words[0]
+	for _, w := range words[1:] {
+		if textWidth(line+" "+w) <= maxWidth {
+			line += " " + w
+			continue
+		}
+		lines = append(lines, line)
+		line = w
+	}
+	return append(lines, line)
+}
+
+func drawCenteredLabel(img *image.RGBA, text string, centerX, y int, col color.Color) {
+	drawLabel(img, text, centerX-textWidth(text)/2, y, col)
+}
+
+// drawNode draws an event's circle of radius r, with an outline width
+// pixels deep inside it.
+func drawNode(img *image.RGBA, cx, cy, r, width int, fill, border color.Color) {
+	r2 := r * r
+	// the outline runs in from the rim, a hairline being the pixels
+	// within 2 of r2
+	inner := r2 - 2
+	if width > 1 {
+		inner = (r-width+1)*(r-width+1) - 2
+	}
+	// only the part of the square around the circle within img is scanned
+	b := img.Bounds()
+	x0, x1 := max(-r, b.Min.X-cx), min(r, b.Max.X-1-cx)
+	y0, y1 := max(-r, b.Min.Y-cy), min(r, b.Max.Y-1-cy)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if x*x+y*y <= r2 {
+				img.Set(cx+x, cy+y, fill)
+			}
+		}
+	}
+	// outline
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			d := x*x + y*y
+			if d >= inner && d <= r2+2 {
+				img.Set(cx+x, cy+y, border)
+			}
+		}
+	}
+}
+
+func drawArrow(img *image.RGBA, x0, y0, x1, y1, stroke int, col color.Color) {
+	drawEdge(img, x0, y0, x1, y1, Edge{}, pen{stroke: stroke}, col)
+}
+
+func drawBidirectionalArrow(img *image.RGBA, x0, y0, x1, y1, stroke int, col color.Color) {
+	drawEdge(img, x0, y0, x1, y1, Edge{Bidirectional: true}, pen{stroke: stroke}, col)
+}
+
+// nodeRadius is the radius of the circle drawn for each event node in a
+// panel of the default size. Process boxes are as wide as the circle, and
+// at least half as tall.
+const nodeRadius = 20
+
+// nodeShape is the outline of a drawn node. With no half extents it is an
+// event circle of radius, or nodeRadius when that is zero; otherwise it is
+// a process box with the given half extents.
+type nodeShape struct {
+	halfW, halfH int
+	radius       int
+}
+
+// box reports whether sh is a process box rather than a circle.
+func (sh nodeShape) box() bool {
+	return sh.halfW != 0 || sh.halfH != 0
+}
+
+// rim returns the distance from the node centre to its outline in the unit
+// direction (ux, uy).
+func (sh nodeShape) rim(ux, uy float64) float64 {
+	if !sh.box() {
+		return float64(cmp.Or(sh.radius, nodeRadius))
+	}
+	d := math.Inf(1)
+	if ux != 0 {
+		d = math.Min(d, float64(sh.halfW)/math.Abs(ux))
+	}
+	if uy != 0 {
+		d = math.Min(d, float64(sh.halfH)/math.Abs(uy))
+	}
+	return d
+}
+
+// edgeSegment returns the unit direction from (x0, y0) to (x1, y1) and the
+// line end points shortened so they meet the outlines of the from and to
+// nodes. ok is false when both points coincide.
+func edgeSegment(x0, y0, x1, y1 int, from, to nodeShape) (ux, uy, tailX, tailY, headX, headY float64, ok bool) {
+	dx := float64(x1 - x0)
+	dy := float64(y1 - y0)
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return 0, 0, 0, 0, 0, 0, false
+	}
+
+	ux = dx / dist
+	uy = dy / dist
+
+	tailInset := from.rim(ux, uy)
+	headInset := to.rim(ux, uy)
+	tailX = float64(x0) + ux*tailInset
+	tailY = float64(y0) + uy*tailInset
+	headX = float64(x1) - ux*headInset
+	headY = float64(y1) - uy*headInset
+	return ux, uy, tailX, tailY, headX, headY, true
+}
+
+// drawEdge draws e between two node centres with p. Only the drawing
+// attributes of e are used; From and To are ignored. Coincident centres
+// are drawn as a self-loop above the node.
+func drawEdge(img *image.RGBA, x0, y0, x1, y1 int, e Edge, p pen, col color.Color) {
+	drawEdgeBetween(img, x0, y0, x1, y1, nodeShape{}, nodeShape{}, e, p, col, col)
+}
+
+// drawEdgeBetween is drawEdge for nodes of any shape. Ecological signs are
+// drawn in the accent colour.
+func drawEdgeBetween(img *image.RGBA, x0, y0, x1, y1 int, from, to nodeShape, e Edge, p pen, col, accent color.Color) {
+	ux, uy, tailX, tailY, headX, headY, ok := edgeSegment(x0, y0, x1, y1, from, to)
+	if !ok {
+		drawSelfLoop(img, x0, y0, 0, -1, from.rim(0, -1), e, p.stroke, col)
+		return
+	}
+
+	drawEdgeLines(img, [][2]float64{{tailX, tailY}, {headX, headY}}, e, p, col)
+	toKind, fromKind, tail := e.heads()
+	if !p.parallel(e) {
+		drawHead(img, headX, headY, ux, uy, toKind, p.stroke, col)
+	}
+	if tail && !p.parallel(e) {
+		// second head at the tail, pointing away from the line
+		drawHead(img, tailX, tailY, -ux, -uy, fromKind, p.stroke, col)
+	}
+
+	if label := edgeLabel(e); label != "" {
+		x, y := edgeLabelPos(e, tailX, tailY, headX, headY, ux, uy)
+		drawLabel(img, label, x, y, col)
+	}
+	if e.Polarity != "" {
+		x, y := polarityLabelPos(e, tailX, tailY, headX, headY, ux, uy)
+		drawLabel(img, e.Polarity, x, y, accent)
+	}
+}
+
+// routedLine is the line of an edge from the node at from through bends to
+// the node at to, cut short where it meets the outlines of the two nodes.
+func routedLine(from image.Point, bends []image.Point, to image.Point, fromShape, toShape nodeShape) [][2]float64 {
+	points := append(append([]image.Point{from}, bends...), to)
+	line := make([][2]float64, len(points))
+	for i, p := range points {
+		line[i] = [2]float64{float64(p.X), float64(p.Y)}
+	}
+	n := len(line)
+	if ux, uy, ok := unitBetween(line[0], line[1]); ok {
+		inset := fromShape.rim(ux, uy)
+		line[0] = [2]float64{line[0][0] + ux*inset, line[0][1] + uy*inset}
+	}
+	if ux, uy, ok := unitBetween(line[n-2], line[n-1]); ok {
+		inset := toShape.rim(ux, uy)
+		line[n-1] = [2]float64{line[n-1][0] - ux*inset, line[n-1][1] - uy*inset}
+	}
+	return line
+}
+
+// unitBetween is the unit direction from a to b, or ok false when they are
+// the same point.
+func unitBetween(a, b [2]float64) (ux, uy float64, ok bool) {
+	d := math.Hypot(b[0]-a[0], b[1]-a[1])
+	if d == 0 {
+		return 0, 0, false
+	}
+	return (b[0] - a[0]) / d, (b[1] - a[1]) / d, true
+}
+
+// drawRoutedEdge draws e along line, from routedLine, with its heads at
+// the ends and its labels beside the middle segment.
+func drawRoutedEdge(img *image.RGBA, line [][2]float64, e Edge, p pen, col, accent color.Color) {
+	drawEdgeLines(img, line, e, p, col)
+	n := len(line)
+	toKind, fromKind, tail := e.heads()
+	if ux, uy, ok := unitBetween(line[n-2], line[n-1]); ok && !p.parallel(e) {
+		drawHead(img, line[n-1][0], line[n-1][1], ux, uy, toKind, p.stroke, col)
+	}
+	if ux, uy, ok := unitBetween(line[0], line[1]); ok && tail && !p.parallel(e) {
+		drawHead(img, line[0][0], line[0][1], -ux, -uy, fromKind, p.stroke, col)
+	}
+
+	m := (n - 1) / 2
+	a, b := line[m], line[m+1]
+	ux, uy, _ := unitBetween(a, b)
+	if label := edgeLabel(e); label != "" {
+		x, y := edgeLabelPos(e, a[0], a[1], b[0], b[1], ux, uy)
+		drawLabel(img, label, x, y, col)
+	}
+	if e.Polarity != "" {
+		x, y := polarityLabelPos(e, a[0], a[1], b[0], b[1], ux, uy)
+		drawLabel(img, e.Polarity, x, y, accent)
+	}
+}
+
+// edgeLabel is the text drawn beside e: its weight, then its probability
+// or a question mark if it is conditional.
+func edgeLabel(e Edge) string {
+	var parts []string
+	if e.Weight != 0 {
+		parts = append(parts, strconv.FormatFloat(e.Weight, 'g', 3, 64))
+	}
+	if e.Probability != 0 && e.Probability != 1 {
+		parts = append(parts, "p="+strconv.FormatFloat(e.Probability, 'g', 3, 64))
+	}
+	if e.Conditional {
+		parts = append(parts, "?")
+	}
+	return strings.Join(parts, " ")
+}
+
+// edgeLabelPos places the edge label beside the midpoint of a straight
+// edge, on the left of travel, shifted by its own width so it never sits on
+// the line. It returns the start of the label's baseline.
+func edgeLabelPos(e Edge, tailX, tailY, headX, headY, ux, uy float64) (x, y int) {
+	halfW := float64(textWidth(edgeLabel(e))) / 2
+	cx := (tailX+headX)/2 + uy*10
+	cy := (tailY+headY)/2 - ux*10
+	return int(cx - halfW + halfW*uy), int(cy + 4)
+}
+
+// polarityLabelPos places ecological signs on the right of travel, opposite
+// the edge label.
+func polarityLabelPos(e Edge, tailX, tailY, headX, headY, ux, uy float64) (x, y int) {
+	halfW := float64(textWidth(e.Polarity)) / 2
+	cx := (tailX+headX)/2 - uy*10
+	cy := (tailY+headY)/2 + ux*10
+	return int(cx - halfW - halfW*uy), int(cy + 4)
+}
+
+// drawEdgeLines draws the line of e along line, without its heads, doubled
+// for a parallel mutualism.
+func drawEdgeLines(img *image.RGBA, line [][2]float64, e Edge, p pen, col color.Color) {
+	for _, l := range p.edgeLines(line, e) {
+		for i := 1; i < len(l); i++ {
+			a, b := l[i-1], l[i]
+			drawStroke(img, int(a[0]), int(a[1]), int(b[0]), int(b[1]), e.strokeWidth(p.stroke), e.style(), col)
+		}
+	}
+}
+
+// drawSelfLoop draws e as a small loop leaving and re-entering the node of
+// radius r centred on (cx, cy). The loop bulges out in direction (dirX,
+// dirY), which must be a unit vector, and ends in a head on the node's rim.
+func drawSelfLoop(img *image.RGBA, cx, cy int, dirX, dirY float64, r float64, e Edge, stroke int, col color.Color) {
+	loop := selfLoopGeometry(cx, cy, dirX, dirY, r)
+
+	width := e.strokeWidth(stroke)
+	pattern := dashPatternFor(e.style())
+	travelled := 0.0
+	for i := 1; i < len(loop.points); i++ {
+		p, q := loop.points[i-1], loop.points[i]
+		if patternOn(pattern, width, travelled) {
+			drawThickLine(img, int(math.Round(p.x)), int(math.Round(p.y)), int(math.Round(q.x)), int(math.Round(q.y)), width, col)
+		}
+		travelled += math.Hypot(q.x-p.x, q.y-p.y)
+	}
+	end := loop.points[len(loop.points)-1]
+	drawHead(img, end.x, end.y, loop.endUX, loop.endUY, e.Kind, stroke, col)
+
+	if label := edgeLabel(e); label != "" {
+		halfW := float64(textWidth(label)) / 2
+		drawLabel(img, label, int(loop.labelX-halfW), int(loop.labelY+4), col)
+	}
+}
+
+// point is a position in floating-point pixel coordinates.
+type point struct {
+	x, y float64
+}
+
+// selfLoop is the geometry of a self-loop: points along the loop from where
+// it leaves the node to where it re-enters, the direction of travel at the
+// end, and a spot beyond the loop for a label.
+type selfLoop struct {
+	points         []point
+	endUX, endUY   float64
+	labelX, labelY float64
+}
+
+// selfLoopGeometry computes the loop drawn by drawSelfLoop.
+func selfLoopGeometry(cx, cy int, dirX, dirY float64, r float64) selfLoop {
+	nr := r
+	loopR := math.Max(nr*0.45, 5)
+	// distance from the node centre to the loop centre; the loop overlaps
+	// the node slightly so both ends meet the rim
+	d := nr + loopR*0.4
+	lx := float64(cx) + dirX*d
+	ly := float64(cy) + dirY*d
+
+	// The loop meets the rim at angles (about the loop centre) of
+	// phiDir+pi±beta. Travel from one to the other the long way round.
+	a := (d*d + nr*nr - loopR*loopR) / (2 * d)
+	beta := math.Atan2(math.Sqrt(nr*nr-a*a), d-a)
+	phiDir := math.Atan2(dirY, dirX)
+	start := phiDir + math.Pi + beta
+	end := phiDir + 3*math.Pi - beta
+
+	const steps = 32
+	loop := selfLoop{
+		endUX:  -math.Sin(end),
+		endUY:  math.Cos(end),
+		labelX: lx + dirX*(loopR+10),
+		labelY: ly + dirY*(loopR+10),
+	}
+	for i := 0; i <= steps; i++ {
+		phi := start + (end-start)*float64(i)/steps
+		loop.points = append(loop.points, point{lx + loopR*math.Cos(phi), ly + loopR*math.Sin(phi)})
+	}
+	return loop
+}
+
+// maxLineWidth is the widest lineWidth, so that a huge weight is drawn as
+// the heaviest edge rather than a line too wide to draw.
+const maxLineWidth = 8
+
+// lineWidth maps the edge weight to a stroke width in pixels, up to
+// maxLineWidth.
+func (e Edge) lineWidth() int {
+	if !(e.Weight > 1) {
+		return 1
+	}
+	return int(math.Round(math.Min(e.Weight, maxLineWidth)))
+}
+
+// strokeWidth is the width of e drawn with lines stroke pixels wide: its
+// lineWidth that many times over.
+func (e Edge) strokeWidth(stroke int) int {
+	return e.lineWidth() * max(stroke, 1)
+}
+
+// drawHead draws the head for an edge of the given kind with its tip at
+// (hx, hy), where (ux, uy) is the unit direction the edge travels in.
+func drawHead(img *image.RGBA, hx, hy, ux, uy float64, kind EdgeKind, stroke int, col color.Color) {
+	switch kind {
+	case Inhibition:
+		drawTeeHead(img, hx, hy, ux, uy, stroke, col)
+	default:
+		drawArrowHead(img, hx, hy, ux, uy, col)
+	}
+}
+
+func drawArrowHead(img *image.RGBA, hx, hy, ux, uy float64, col color.Color) {
+	tri := arrowHeadPoints(hx, hy, ux, uy)
+	fillTriangle(img,
+		int(tri[0].x), int(tri[0].y),
+		int(tri[1].x), int(tri[1].y),
+		int(tri[2].x), int(tri[2].y),
+		col,
+	)
+}
+
+// arrowHeadPoints returns the corners of an arrowhead with its tip at
+// (hx, hy), the tip first.
+func arrowHeadPoints(hx, hy, ux, uy float64) [3]point {
+	arrowLen := 10.0
+	perpX := -uy
+	perpY := ux
+
+	return [3]point{
+		{hx, hy},
+		{hx - ux*arrowLen + perpX*(arrowLen/2), hy - uy*arrowLen + perpY*(arrowLen/2)},
+		{hx - ux*arrowLen - perpX*(arrowLen/2), hy - uy*arrowLen - perpY*(arrowLen/2)},
+	}
+}
+
+// teeHalfWidth is half the length of the bar of a tee head.
+const teeHalfWidth = 7.0
+
+// drawTeeHead draws a flat bar across the edge end, two strokes deep so it
+// reads clearly at small sizes.
+func drawTeeHead(img *image.RGBA, hx, hy, ux, uy float64, stroke int, col color.Color) {
+	perpX := -uy
+	perpY := ux
+
+	for depth := 0.0; depth < float64(2*max(stroke, 1)); depth++ {
+		cx := hx - ux*depth
+		cy := hy - uy*depth
+		drawLine(img,
+			int(math.Round(cx+perpX*teeHalfWidth)), int(math.Round(cy+perpY*teeHalfWidth)),
+			int(math.Round(cx-perpX*teeHalfWidth)), int(math.Round(cy-perpY*teeHalfWidth)),
+			col,
+		)
+	}
+}
+
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, col color.Color) {
+	drawDashedLine(img, x0, y0, x1, y1, nil, col)
+}
+
+// Dash patterns as alternating on/off run lengths in pixels.
+var (
+	dashPattern = []int{6, 4}
+	dotPattern  = []int{1, 3}
+)
+
+// dashPatternFor returns the on/off runs for style, or nil when solid.
+func dashPatternFor(style EdgeStyle) []int {
+	switch style {
+	case Dashed:
+		return dashPattern
+	case Dotted:
+		return dotPattern
+	default:
+		return nil
+	}
+}
+
+// drawDashedLine draws a one-pixel line, skipping pixels according to the
+// on/off runs in pattern. A nil pattern draws a solid line.
+func drawDashedLine(img *image.RGBA, x0, y0, x1, y1 int, pattern []int, col color.Color) {
+	dx := abs(x1 - x0)
+	sx := 1
+	if x0 > x1 {
+		sx = -1
+	}
+	dy := -abs(y1 - y0)
+	sy := 1
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+
+	run, left := 0, 0
+	if len(pattern) > 0 {
+		left = pattern[0]
+	}
+
+	for {
+		if len(pattern) == 0 || run%2 == 0 {
+			img.Set(x0, y0, col)
+		}
+		if len(pattern) > 0 {
+			left--
+			if left <= 0 {
+				run = (run + 1) % len(pattern)
+				left = pattern[run]
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// patternOn reports whether the point pos pixels along a stroke of the given
+// width falls in an "on" run of pattern. Runs are scaled by the width, as in
+// drawStroke.
+func patternOn(pattern []int, width int, pos float64) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	total := 0
+	for _, run := range pattern {
+		total += run * width
+	}
+	offset := int(pos) % total
+	for i, run := range pattern {
+		offset -= run * width
+		if offset < 0 {
+			return i%2 == 0
+		}
+	}
+	return true
+}
+
+// drawStroke draws a line of the given width and style. Wide patterned
+// strokes are split into solid pieces, with runs scaled by the width so
+// dots stay roughly square.
+func drawStroke(img *image.RGBA, x0, y0, x1, y1, width int, style EdgeStyle, col color.Color) {
+	pattern := dashPatternFor(style)
+	if width <= 1 {
+		drawDashedLine(img, x0, y0, x1, y1, pattern, col)
+		return
+	}
+	if pattern == nil {
+		drawThickLine(img, x0, y0, x1, y1, width, col)
+		return
+	}
+
+	dx := float64(x1 - x0)
+	dy := float64(y1 - y0)
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return
+	}
+	ux, uy := dx/dist, dy/dist
+
+	pos := 0.0
+	for run := 0; pos < dist; run = (run + 1) % len(pattern) {
+		length := float64(pattern[run] * width)
+		end := math.Min(pos+length, dist)
+		if run%2 == 0 {
+			drawThickLine(img,
+				x0+int(math.Round(ux*pos)), y0+int(math.Round(uy*pos)),
+				x0+int(math.Round(ux*end)), y0+int(math.Round(uy*end)),
+				width, col)
+		}
+		pos = end
+	}
+}
+
+// drawThickLine draws a stroke width pixels wide as a filled quad centred
+// on the segment.
+func drawThickLine(img *image.RGBA, x0, y0, x1, y1, width int, col color.Color) {
+	if width <= 1 {
+		drawLine(img, x0, y0, x1, y1, col)
+		return
+	}
+
+	dx := float64(x1 - x0)
+	dy := float64(y1 - y0)
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		drawLine(img, x0, y0, x1, y1, col)
+		return
+	}
+	half := float64(width) / 2
+	ox := -dy / dist * half
+	oy := dx / dist * half
+
+	ax, ay := int(math.Round(float64(x0)+ox)), int(math.Round(float64(y0)+oy))
+	bx, by := int(math.Round(float64(x1)+ox)), int(math.Round(float64(y1)+oy))
+	cx, cy := int(math.Round(float64(x1)-ox)), int(math.Round(float64(y1)-oy))
+	ex, ey := int(math.Round(float64(x0)-ox)), int(math.Round(float64(y0)-oy))
+	fillTriangle(img, ax, ay, bx, by, cx, cy, col)
+	fillTriangle(img, ax, ay, cx, cy, ex, ey, col)
+}
+
+func fillTriangle(img *image.RGBA, x1, y1, x2, y2, x3, y3 int, col color.Color) {
+	minX := min(x1, min(x2, x3))
+	maxX := max(x1, max(x2, x3))
+	minY := min(y1, min(y2, y3))
+	maxY := max(y1, max(y2, y3))
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			if pointInTriangle(x, y, x1, y1, x2, y2, x3, y3) {
+				img.Set(x, y, col)
+			}
+		}
+	}
+}
+
+func pointInTriangle(px, py, x1, y1, x2, y2, x3, y3 int) bool {
+	dx := float64(px)
+	dy := float64(py)
+
+	ax := float64(x1)
+	ay := float64(y1)
+	bx := float64(x2)
+	by := float64(y2)
+	cx := float64(x3)
+	cy := float64(y3)
+
+	v0x := cx - ax
+	v0y := cy - ay
+	v1x := bx - ax
+	v1y := by - ay
+	v2x := dx - ax
+	v2y := dy - ay
+
+	dot00 := v0x*v0x + v0y*v0y
+	dot01 := v0x*v1x + v0y*v1y
+	dot02 := v0x*v2x + v0y*v2y
+	dot11 := v1x*v1x + v1y*v1y
+	dot12 := v1x*v2x + v1y*v2y
+
+	denom := dot00*dot11 - dot01*dot01
+	if denom == 0 {
+		return false
+	}
+	u := (dot11*dot02 - dot01*dot12) / denom
+	v := (dot00*dot12 - dot01*dot02) / denom
+
+	return u >= 0 && v >= 0 && u+v <= 1
+}
+
+// ----------------------------------------------------------------------
+// small helpers
+// ----------------------------------------------------------------------
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,321 @@
+// Package scen builds scenarios a call at a time instead of assembling
+// their Nodes, Edges and Spans by hand:
+//
+//	s, err := scen.New("Supply chain").
+//		Event("A").Process("B").
+//		After("A", "B").
+//		Influences("C", "A").Delayed().
+//		Inhibits("A", "B").Weight(2).
+//		Build()
+//
+// Nodes named by an edge without being declared are events, added in the
+// order they first appear, as in a scenario file. Build checks the result
+// with interactions.Validate and fails with every problem found.
+package scen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/arran4/interactions"
+)
+
+// Builder collects a scenario. Each method returns the Builder so calls
+// chain; mistakes are kept until Build reports them.
+type Builder struct {
+	s interactions.Scenario
+	// processes are the nodes declared with Process or given a Span, and
+	// spans the spans given
+	processes map[string]bool
+	spans     map[string]interactions.Span
+	after     [][2]string
+	problems  []interactions.Problem
+}
+
+// New starts a scenario with the given title.
+func New(title string) *Builder {
+	return &Builder{
+		s:         interactions.Scenario{Title: title},
+		processes: map[string]bool{},
+		spans:     map[string]interactions.Span{},
+	}
+}
+
+// Subtitle sets the line drawn under the title.
+func (b *Builder) Subtitle(subtitle string) *Builder {
+	b.s.Subtitle = subtitle
+	return b
+}
+
+// Description sets the free text drawn below the diagram.
+func (b *Builder) Description(description string) *Builder {
+	b.s.Description = description
+	return b
+}
+
+// Code sets the scenario's code, such as "AB3.C1.D0".
+func (b *Builder) Code(code string) *Builder {
+	b.s.Code = code
+	return b
+}
+
+// Dimension records the scenario's value of a taxonomy dimension, such as
+// "ab" and "mutualism", for queries to select on.
+func (b *Builder) Dimension(name, value string) *Builder {
+	if b.s.Dimensions == nil {
+		b.s.Dimensions = map[string]string{}
+	}
+	b.s.Dimensions[name] = value
+	return b
+}
+
+// Event declares instantaneous nodes, placed in the upper or lower row by
+// the edges into them.
+func (b *Builder) Event(names ...string) *Builder {
+	b.s.Nodes = append(b.s.Nodes, names...)
+	return b
+}
+
+// Process declares nodes that run for a while, drawn as boxes on the time
+// axis. A process spans the whole axis unless After orders it or Span
+// places it.
+func (b *Builder) Process(names ...string) *Builder {
+	for _, name := range names {
+		b.s.Nodes = append(b.s.Nodes, name)
+		b.processes[name] = true
+	}
+	return b
+}
+
+// Span places name from start to end on the time axis, 0 being the
+// earliest and 1 the latest, making it a process and declaring it if need
+// be.
+func (b *Builder) Span(name string, start, end float64) *Builder {
+	if !slices.Contains(b.s.Nodes, name) {
+		b.s.Nodes = append(b.s.Nodes, name)
+	}
+	b.processes[name] = true
+	b.spans[name] = interactions.Span{Start: start, End: end}
+	return b
+}
+
+// Size draws name larger or smaller than the usual size.
+func (b *Builder) Size(name string, size interactions.Size) *Builder {
+	if b.s.Sizes == nil {
+		b.s.Sizes = map[string]interactions.Size{}
+	}
+	b.s.Sizes[name] = size
+	return b
+}
+
+// After says later happens after earlier, at least one of which must be a
+// process: events have no place on the time axis of their own. Processes
+// ordered by After without a Span share the axis out in turn, each
+// starting where those before it end.
+func (b *Builder) After(earlier, later string) *Builder {
+	b.after = append(b.after, [2]string{earlier, later})
+	return b
+}
+
+// Influences adds an arrow from one node to another.
+func (b *Builder) Influences(from, to string) *Builder {
+	return b.edge(interactions.Edge{From: from, To: to})
+}
+
+// Inhibits adds a tee-headed edge from one node to another.
+func (b *Builder) Inhibits(from, to string) *Builder {
+	return b.edge(interactions.Edge{From: from, To: to, Kind: interactions.Inhibition})
+}
+
+// Preys adds an edge from predator to prey, which feeds the one and
+// suppresses the other.
+func (b *Builder) Preys(predator, prey string) *Builder {
+	return b.edge(interactions.Edge{From: predator, To: prey, Kind: interactions.Predation})
+}
+
+// Mutual adds a double-headed arrow between two nodes that influence each
+// other.
+func (b *Builder) Mutual(first, second string) *Builder {
+	return b.edge(interactions.Edge{From: first, To: second, Bidirectional: true})
+}
+
+// Feedback adds a loop from name back to itself, for self-reinforcement.
+func (b *Builder) Feedback(name string) *Builder {
+	return b.edge(interactions.Edge{From: name, To: name})
+}
+
+func (b *Builder) edge(e interactions.Edge) *Builder {
+	b.s.Edges = append(b.s.Edges, e)
+	return b
+}
+
+// Delayed dashes the edge added last, for a delayed influence.
+func (b *Builder) Delayed() *Builder {
+	return b.modify("Delayed", func(e *interactions.Edge) { e.Style = interactions.Dashed })
+}
+
+// Weight sets the strength of the edge added last, drawn thicker and
+// labelled.
+func (b *Builder) Weight(w float64) *Builder {
+	return b.modify("Weight", func(e *interactions.Edge) { e.Weight = w })
+}
+
+// Probability sets the chance, between 0 and 1, that the edge added last
+// happens.
+func (b *Builder) Probability(p float64) *Builder {
+	return b.modify("Probability", func(e *interactions.Edge) { e.Probability = p })
+}
+
+// Conditional marks the edge added last as happening only under some
+// condition.
+func (b *Builder) Conditional() *Builder {
+	return b.modify("Conditional", func(e *interactions.Edge) { e.Conditional = true })
+}
+
+// Polarity labels the edge added last with ecological signs such as "+-".
+func (b *Builder) Polarity(signs string) *Builder {
+	return b.modify("Polarity", func(e *interactions.Edge) { e.Polarity = signs })
+}
+
+// modify applies change to the edge added last, named method for the
+// problem reported when there is none.
+func (b *Builder) modify(method string, change func(*interactions.Edge)) *Builder {
+	if len(b.s.Edges) == 0 {
+		b.report("edges", "%s comes before any edge to apply it to", method)
+		return b
+	}
+	change(&b.s.Edges[len(b.s.Edges)-1])
+	return b
+}
+
+func (b *Builder) report(path, format string, args ...any) {
+	b.problems = append(b.problems, interactions.Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Build returns the scenario, or an *Error holding every problem with it:
+// those of the calls made, the order given by After, and those found by
+// interactions.Validate.
+func (b *Builder) Build() (interactions.Scenario, error) {
+	s := b.s
+	s.Nodes = slices.Clone(s.Nodes)
+	s.Edges = slices.Clone(s.Edges)
+	s.Dimensions = maps.Clone(s.Dimensions)
+	s.Sizes = maps.Clone(s.Sizes)
+	problems := slices.Clone(b.problems)
+
+	for _, e := range s.Edges {
+		for _, name := range []string{e.From, e.To} {
+			if !slices.Contains(s.Nodes, name) {
+				s.Nodes = append(s.Nodes, name)
+			}
+		}
+	}
+
+	spans, orderProblems := b.orderSpans(s.Nodes)
+	problems = append(problems, orderProblems...)
+	if len(spans) > 0 {
+		s.Spans = spans
+	}
+	problems = append(problems, interactions.Validate(s)...)
+	if len(problems) > 0 {
+		return interactions.Scenario{}, &Error{Title: s.Title, Problems: problems}
+	}
+	return s, nil
+}
+
+// MustBuild is Build for scenarios known to be right, such as those in
+// package variables; it panics if there is a problem.
+func (b *Builder) MustBuild() interactions.Scenario {
+	s, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// orderSpans gives every process of nodes its span: the one set by Span,
+// or its turn on the time axis among the nodes ordered by After, or the
+// whole axis.
+func (b *Builder) orderSpans(nodes []string) (map[string]interactions.Span, []interactions.Problem) {
+	var problems []interactions.Problem
+	before := map[string][]string{}
+	for i, pair := range b.after {
+		path := fmt.Sprintf("after[%d]", i)
+		earlier, later := pair[0], pair[1]
+		ok := true
+		for _, name := range pair {
+			if !slices.Contains(nodes, name) {
+				problems = append(problems, interactions.Problem{Path: path, Message: fmt.Sprintf("unknown node %q", name)})
+				ok = false
+			}
+		}
+		if ok && !b.processes[earlier] && !b.processes[later] {
+			problems = append(problems, interactions.Problem{Path: path, Message: fmt.Sprintf("%q and %q are both events, which are placed by their edges, not by After", earlier, later)})
+			ok = false
+		}
+		if ok {
+			before[later] = append(before[later], earlier)
+		}
+	}
+
+	// each node's turn is one after the latest turn of the nodes before
+	// it
+	turn := map[string]int{}
+	const visiting = -1
+	var turnOf func(name string, chain []string) int
+	turnOf = func(name string, chain []string) int {
+		switch t, seen := turn[name]; {
+		case seen && t == visiting:
+			problems = append(problems, interactions.Problem{Path: "after", Message: "order goes round in a circle: " + strings.Join(chain, " after ")})
+			return 0
+		case seen:
+			return t
+		}
+		turn[name] = visiting
+		t := 0
+		for _, e := range before[name] {
+			t = max(t, turnOf(e, append(chain, e))+1)
+		}
+		turn[name] = t
+		return t
+	}
+	turns := 0
+	for _, name := range nodes {
+		if len(before[name]) > 0 {
+			turns = max(turns, turnOf(name, []string{name})+1)
+		}
+	}
+
+	spans := map[string]interactions.Span{}
+	for _, name := range nodes {
+		if !b.processes[name] {
+			continue
+		}
+		sp, ok := b.spans[name]
+		if !ok {
+			sp = interactions.Span{Start: 0, End: 1}
+			if t, ordered := turn[name]; ordered && t != visiting {
+				sp = interactions.Span{Start: float64(t) / float64(turns), End: float64(t+1) / float64(turns)}
+			}
+		}
+		spans[name] = sp
+	}
+	return spans, problems
+}
+
+// Error is the problems that stopped Build.
+type Error struct {
+	// Title is the title of the scenario built.
+	Title    string
+	Problems []interactions.Problem
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.String()
+	}
+	return fmt.Sprintf("scenario %q: %s", e.Title, strings.Join(msgs, "; "))
+}
@@ -0,0 +1,118 @@
+// Package interactions models and draws interaction patterns between
+// actors: which influences, inhibits or preys on which, and in what order.
+// Project home: https://github.com/arran4/interactions
+package interactions
+
+// EdgeKind describes what an edge means and selects the head drawn at its
+// target end.
+type EdgeKind int
+
+const (
+	// Influence is a plain causal influence, drawn with an arrowhead.
+	Influence EdgeKind = iota
+	// Inhibition is a suppressing influence, drawn with a flat "tee" head
+	// as in biology diagrams.
+	Inhibition
+	// Predation is the source consuming the target: a tee at the prey (To)
+	// end and an arrowhead back at the predator (From) end.
+	Predation
+)
+
+// EdgeStyle selects the line pattern of an edge.
+type EdgeStyle int
+
+const (
+	// Solid is an ordinary, immediate influence.
+	Solid EdgeStyle = iota
+	// Dashed marks a delayed influence.
+	Dashed
+	// Dotted marks an uncertain influence.
+	Dotted
+)
+
+type Edge struct {
+	From          string    `yaml:"from" json:"from"`
+	To            string    `yaml:"to" json:"to"`
+	Bidirectional bool      `yaml:"bidirectional,omitempty" json:"bidirectional,omitempty"`
+	Kind          EdgeKind  `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Style         EdgeStyle `yaml:"style,omitempty" json:"style,omitempty"`
+	// Weight is the optional strength of the edge. Zero means unweighted;
+	// weighted edges are drawn thicker and labelled with their value.
+	Weight float64 `yaml:"weight,omitempty" json:"weight,omitempty"`
+	// Polarity is an optional ecological sign annotation such as "+-",
+	// drawn beside the edge.
+	Polarity string `yaml:"polarity,omitempty" json:"polarity,omitempty"`
+	// Probability is the chance, between 0 and 1, that the influence
+	// happens. Zero means it always does; other values draw the edge
+	// dotted and labelled, as in "p=0.5".
+	Probability float64 `yaml:"probability,omitempty" json:"probability,omitempty"`
+	// Conditional marks an influence that only happens under some
+	// condition, drawn dotted and labelled "?".
+	Conditional bool `yaml:"conditional,omitempty" json:"conditional,omitempty"`
+}
+
+// uncertain reports whether e might not happen.
+func (e Edge) uncertain() bool {
+	return e.Conditional || (e.Probability != 0 && e.Probability != 1)
+}
+
+// style is the line pattern e is drawn with: dotted when it is uncertain
+// and not otherwise styled.
+func (e Edge) style() EdgeStyle {
+	if e.Style == Solid && e.uncertain() {
+		return Dotted
+	}
+	return e.Style
+}
+
+// heads returns the heads drawn at the To end and, when tail is true, at the
+// From end of e.
+func (e Edge) heads() (to, from EdgeKind, tail bool) {
+	if e.Kind == Predation {
+		// the prey is suppressed and the predator fed
+		return Inhibition, Influence, true
+	}
+	return e.Kind, e.Kind, e.Bidirectional
+}
+
+type Scenario struct {
+	// Code is the scenario's short identifier; see Code.
+	Code string `yaml:"code,omitempty" json:"code,omitempty"`
+	// Title is drawn at the top of the panel, and Subtitle, such as what
+	// the actors other than A and B do, under it in the muted text colour.
+	// Both wrap to fit, moving the diagram down.
+	Title    string `yaml:"title" json:"title"`
+	Subtitle string `yaml:"subtitle,omitempty" json:"subtitle,omitempty"`
+	// Description is optional free text drawn word-wrapped below the
+	// diagram; panels grow to fit it.
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Nodes       []string `yaml:"nodes,omitempty" json:"nodes,omitempty"`
+	Edges       []Edge   `yaml:"edges,omitempty" json:"edges,omitempty"`
+	// Spans gives the lifetime of process nodes on the panel's time axis.
+	// Nodes without a span are instantaneous events placed by the chronology
+	// inferred from the edges.
+	Spans map[string]Span `yaml:"spans,omitempty" json:"spans,omitempty"`
+	// Sizes draws nodes larger or smaller than the usual size, such as a
+	// key actor among many.
+	Sizes map[string]Size `yaml:"sizes,omitempty" json:"sizes,omitempty"`
+	// Dimensions records the value of each taxonomy dimension the scenario
+	// belongs to, such as "ab": "mutualism", for queries to select on.
+	Dimensions map[string]string `yaml:"dimensions,omitempty" json:"dimensions,omitempty"`
+}
+
+// Size is how large a node is drawn, in pixels of a panel of the default
+// size; like the usual size, it scales with the panel. A zero field keeps
+// the usual size, and Validate rejects fields over 200.
+type Size struct {
+	// Radius is the radius of an event's circle, usually 20.
+	Radius int `yaml:"radius,omitempty" json:"radius,omitempty"`
+	// Width is the width of a process's box, usually 40; its height comes
+	// from its span.
+	Width int `yaml:"width,omitempty" json:"width,omitempty"`
+}
+
+// Span is the interval a process runs for, from 0 (earliest) to 1 (latest).
+type Span struct {
+	Start float64 `yaml:"start" json:"start"`
+	End   float64 `yaml:"end" json:"end"`
+}
@@ -0,0 +1,286 @@
+package interactions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scenarioFile is the YAML document accepted by --scenarios:
+//
+//	scenarios:
+//	  - title: Supply chain
+//	    subtitle: The supplier drives both plants
+//	    nodes: [S, A, B]
+//	    edges:
+//	      - {from: S, to: A}
+//	      - {from: S, to: B, style: dashed}
+//	      - {from: A, to: B, kind: inhibition, weight: 2}
+//
+// Nodes may be omitted, in which case they are taken from the edges in the
+// order they first appear. Sets name collections of scenarios for a
+// Catalog, in place of or besides the scenarios:
+//
+//	sets:
+//	  ecology-basics:
+//	    - title: Mutualism
+//	      edges: [{from: A, to: B, bidirectional: true}]
+//
+// Dimensions declare dimensions of the generated taxonomy instead; see
+// Dimension.
+type scenarioFile struct {
+	Scenarios  []Scenario            `yaml:"scenarios,omitempty"`
+	Sets       map[string][]Scenario `yaml:"sets,omitempty"`
+	Dimensions []Dimension           `yaml:"dimensions,omitempty"`
+}
+
+// all returns the scenarios of the file, or those of all its sets in order
+// of name when it has only sets.
+func (f *scenarioFile) all() []Scenario {
+	if len(f.Scenarios) > 0 {
+		return f.Scenarios
+	}
+	var all []Scenario
+	for _, name := range slices.Sorted(maps.Keys(f.Sets)) {
+		all = append(all, f.Sets[name]...)
+	}
+	return all
+}
+
+// scenarios returns all, failing for a file of path with only dimensions,
+// which are not scenarios themselves.
+func (f *scenarioFile) scenarios(path string) ([]Scenario, error) {
+	all := f.all()
+	if len(all) == 0 {
+		return nil, fmt.Errorf("%s: no scenarios, only dimensions to generate them with", path)
+	}
+	return all, nil
+}
+
+// LoadScenarioFile reads the scenarios in a YAML scenario file, or those of
+// all its sets when it has only named sets. A file that does not match
+// ScenarioSchema gives a *SchemaError.
+func LoadScenarioFile(path string) ([]Scenario, error) {
+	return LoadScenarioFileFS(nil, path)
+}
+
+// LoadScenarioFileFS is LoadScenarioFile reading the file name of fsys,
+// such as an embed.FS of scenario files built into a program.
+func LoadScenarioFileFS(fsys fs.FS, name string) ([]Scenario, error) {
+	doc, _, err := readScenarioFile(fsys, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doc.scenarios(name)
+}
+
+// LoadScenarioTemplate reads the scenarios in a scenario file that is a
+// template, filling in its variables from vars, so one file gives a family
+// of diagrams labelled for each use. Every text in the file, including
+// node names and the keys of spans and sizes, may use Go template syntax:
+//
+//	scenarios:
+//	  - title: "{{.Org}} approves {{.Doc}}"
+//	    edges: [{from: "{{.Org}}", to: "{{.Doc}}"}]
+//
+// A variable missing from vars is an error.
+func LoadScenarioTemplate(path string, vars map[string]string) ([]Scenario, error) {
+	return LoadScenarioTemplateFS(nil, path, vars)
+}
+
+// LoadScenarioTemplateFS is LoadScenarioTemplate reading the file name of
+// fsys.
+func LoadScenarioTemplateFS(fsys fs.FS, name string, vars map[string]string) ([]Scenario, error) {
+	doc, _, err := readScenarioFile(fsys, name, vars)
+	if err != nil {
+		return nil, err
+	}
+	return doc.scenarios(name)
+}
+
+// WriteScenarioFile writes scenarios as a YAML scenario file, which
+// LoadScenarioFile reads back.
+func WriteScenarioFile(w io.Writer, scenarios []Scenario) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(scenarioFile{Scenarios: scenarios}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// readScenarioFile reads a scenario file, filling in the variables of a
+// template from vars unless it is nil, and also returns its YAML node tree,
+// from which ValidateFile finds the positions of problems. It reads the
+// file path of fsys, or of the operating system when fsys is nil.
+func readScenarioFile(fsys fs.FS, path string, vars map[string]string) (*scenarioFile, *yaml.Node, error) {
+	data, err := readFile(fsys, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if vars != nil {
+		if problems := expandTemplates(&root, vars); len(problems) > 0 {
+			return nil, nil, errors.New(problemLines(path, problems))
+		}
+	}
+	if problems := checkSchema(&root); len(problems) > 0 {
+		return nil, nil, &SchemaError{File: path, Problems: problems}
+	}
+	var doc scenarioFile
+	if err := root.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Scenarios) == 0 && len(doc.Sets) == 0 && len(doc.Dimensions) == 0 {
+		return nil, nil, fmt.Errorf("%s: no scenarios", path)
+	}
+	for _, scenarios := range append(slices.Collect(maps.Values(doc.Sets)), doc.Scenarios) {
+		for i := range scenarios {
+			if len(scenarios[i].Nodes) == 0 {
+				scenarios[i].Nodes = edgeNodes(scenarios[i].Edges)
+			}
+		}
+	}
+	return &doc, &root, nil
+}
+
+// readFile reads the file path of fsys, or of the operating system when
+// fsys is nil.
+func readFile(fsys fs.FS, path string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(path)
+	}
+	return fs.ReadFile(fsys, path)
+}
+
+// expandTemplates fills in the variables of every template in a YAML node
+// tree from vars, reporting the templates that are malformed or use a
+// variable vars lacks.
+func expandTemplates(root *yaml.Node, vars map[string]string) []Problem {
+	var problems []Problem
+	var expand func(n *yaml.Node, path string)
+	expand = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				expand(c, path)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				expand(c, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				keyPath := strings.TrimPrefix(path+"."+n.Content[i].Value, ".")
+				expand(n.Content[i], keyPath)
+				expand(n.Content[i+1], keyPath)
+			}
+		case yaml.ScalarNode:
+			if !strings.Contains(n.Value, "{{") {
+				return
+			}
+			report := func(format string, args ...any) {
+				problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...), Line: n.Line, Column: n.Column})
+			}
+			t, err := template.New("").Option("missingkey=error").Parse(n.Value)
+			if err != nil {
+				report("malformed template: %s", strings.TrimPrefix(err.Error(), "template: :"))
+				return
+			}
+			var b strings.Builder
+			if err := t.Execute(&b, vars); err != nil {
+				if m := missingVariable.FindStringSubmatch(err.Error()); m != nil {
+					report("no value for variable %s", m[1])
+				} else {
+					report("%s", strings.TrimPrefix(err.Error(), "template: :"))
+				}
+				return
+			}
+			// the filled-in text is typed afresh, so "{{.Weight}}" can be a
+			// number
+			n.Value, n.Tag, n.Style = b.String(), "", 0
+		}
+	}
+	expand(root, "")
+	return problems
+}
+
+// missingVariable matches the error of a template using a variable it was
+// given no value for.
+var missingVariable = regexp.MustCompile(`map has no entry for key "([^"]*)"`)
+
+// edgeNodes returns the nodes the edges touch, in order of first appearance.
+func edgeNodes(edges []Edge) []string {
+	seen := map[string]bool{}
+	var nodes []string
+	for _, e := range edges {
+		for _, n := range []string{e.From, e.To} {
+			if !seen[n] {
+				seen[n] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes
+}
+
+var edgeKindNames = map[EdgeKind]string{
+	Influence:  "influence",
+	Inhibition: "inhibition",
+	Predation:  "predation",
+}
+
+func (k EdgeKind) MarshalText() ([]byte, error) {
+	name, ok := edgeKindNames[k]
+	if !ok {
+		return nil, fmt.Errorf("unknown edge kind %d", int(k))
+	}
+	return []byte(name), nil
+}
+
+func (k *EdgeKind) UnmarshalText(text []byte) error {
+	for kind, name := range edgeKindNames {
+		if strings.EqualFold(string(text), name) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown edge kind %q (want influence, inhibition or predation)", text)
+}
+
+var edgeStyleNames = map[EdgeStyle]string{
+	Solid:  "solid",
+	Dashed: "dashed",
+	Dotted: "dotted",
+}
+
+func (s EdgeStyle) MarshalText() ([]byte, error) {
+	name, ok := edgeStyleNames[s]
+	if !ok {
+		return nil, fmt.Errorf("unknown edge style %d", int(s))
+	}
+	return []byte(name), nil
+}
+
+func (s *EdgeStyle) UnmarshalText(text []byte) error {
+	for style, name := range edgeStyleNames {
+		if strings.EqualFold(string(text), name) {
+			*s = style
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown edge style %q (want solid, dashed or dotted)", text)
+}
@@ -0,0 +1,207 @@
+package interactions
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed scenario.schema.json
+var scenarioSchemaJSON []byte
+
+// ScenarioSchema returns the JSON Schema of scenario files, for editors to
+// check and complete them as they are written. LoadScenarioFile checks
+// files against it.
+func ScenarioSchema() []byte {
+	return slices.Clone(scenarioSchemaJSON)
+}
+
+// SchemaError is the error of a scenario file that does not match
+// ScenarioSchema, with the line and column of each mismatch.
+type SchemaError struct {
+	File     string
+	Problems []Problem
+}
+
+func (e *SchemaError) Error() string {
+	return problemLines(e.File, e.Problems)
+}
+
+// problemLines lists problems in file a line each, as file:line:column:
+// path: message.
+func problemLines(file string, problems []Problem) string {
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = file + ":" + p.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// schema is the part of JSON Schema that ScenarioSchema uses.
+type schema struct {
+	Ref                  string               `json:"$ref"`
+	Defs                 map[string]*schema   `json:"$defs"`
+	Type                 string               `json:"type"`
+	Properties           map[string]*schema   `json:"properties"`
+	Required             []string             `json:"required"`
+	AdditionalProperties additionalProperties `json:"additionalProperties"`
+	Items                *schema              `json:"items"`
+	MinItems             int                  `json:"minItems"`
+	Enum                 []string             `json:"enum"`
+	Minimum              *float64             `json:"minimum"`
+	Maximum              *float64             `json:"maximum"`
+}
+
+// additionalProperties is false for an object with only its properties,
+// or the schema of the values of an object with any keys.
+type additionalProperties struct {
+	closed bool
+	values *schema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if string(data) == "false" {
+		a.closed = true
+		return nil
+	}
+	a.values = &schema{}
+	return json.Unmarshal(data, a.values)
+}
+
+// scenarioSchema is ScenarioSchema parsed.
+var scenarioSchema = func() *schema {
+	var s schema
+	if err := json.Unmarshal(scenarioSchemaJSON, &s); err != nil {
+		panic("scenario.schema.json: " + err.Error())
+	}
+	return &s
+}()
+
+// checkSchema reports where a YAML document does not match ScenarioSchema.
+// YAML reads any scalar into a string, so any scalar is a string here, and
+// edge kinds and styles are read ignoring case, so enums are matched so
+// too. Empty values are as good as missing.
+func checkSchema(root *yaml.Node) []Problem {
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil
+		}
+		root = root.Content[0]
+	}
+	var problems []Problem
+	var check func(s *schema, n *yaml.Node, path string)
+	report := func(n *yaml.Node, path, format string, args ...any) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...), Line: n.Line, Column: n.Column})
+	}
+	check = func(s *schema, n *yaml.Node, path string) {
+		if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+			s = scenarioSchema.Defs[name]
+		}
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+		if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+			return
+		}
+		if want := s.Type; want != "" && !hasType(n, want) {
+			report(n, path, "want %s, not %s", typeNames[want], yamlTypeName(n))
+			return
+		}
+		if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(v string) bool { return strings.EqualFold(v, n.Value) }) {
+			report(n, path, "%q is not one of %s", n.Value, strings.Join(s.Enum, ", "))
+			return
+		}
+		if s.Minimum != nil || s.Maximum != nil {
+			v, _ := strconv.ParseFloat(n.Value, 64)
+			switch {
+			case s.Maximum == nil && v < *s.Minimum:
+				report(n, path, "%s is less than %g", n.Value, *s.Minimum)
+			case s.Minimum == nil && v > *s.Maximum:
+				report(n, path, "%s is more than %g", n.Value, *s.Maximum)
+			case s.Minimum != nil && s.Maximum != nil && (v < *s.Minimum || v > *s.Maximum):
+				report(n, path, "%s is outside %g to %g", n.Value, *s.Minimum, *s.Maximum)
+			}
+		}
+
+		switch n.Kind {
+		case yaml.SequenceNode:
+			if len(n.Content) < s.MinItems {
+				report(n, path, "want at least %d items", s.MinItems)
+			}
+			if s.Items != nil {
+				for i, item := range n.Content {
+					check(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				keyPath := strings.TrimPrefix(path+"."+key.Value, ".")
+				switch prop := s.Properties[key.Value]; {
+				case prop != nil:
+					check(prop, value, keyPath)
+				case s.AdditionalProperties.values != nil:
+					check(s.AdditionalProperties.values, value, keyPath)
+				case s.AdditionalProperties.closed:
+					report(key, keyPath, "unknown field %q", key.Value)
+				}
+			}
+			for _, key := range s.Required {
+				if v := mappingValue(n, key); v == nil || v.ShortTag() == "!!null" {
+					report(n, strings.TrimPrefix(path+"."+key, "."), "missing %s", key)
+				}
+			}
+		}
+	}
+	check(scenarioSchema, root, "")
+	return problems
+}
+
+// typeNames describe the JSON Schema types in messages.
+var typeNames = map[string]string{
+	"object":  "a mapping",
+	"array":   "a list",
+	"string":  "a string",
+	"number":  "a number",
+	"integer": "a whole number",
+	"boolean": "true or false",
+}
+
+// hasType reports whether a YAML node is of JSON Schema type want.
+func hasType(n *yaml.Node, want string) bool {
+	switch want {
+	case "object":
+		return n.Kind == yaml.MappingNode
+	case "array":
+		return n.Kind == yaml.SequenceNode
+	case "string":
+		return n.Kind == yaml.ScalarNode
+	case "number":
+		return n.ShortTag() == "!!int" || n.ShortTag() == "!!float"
+	case "integer":
+		return n.ShortTag() == "!!int"
+	case "boolean":
+		return n.ShortTag() == "!!bool"
+	}
+	return true
+}
+
+// yamlTypeName describes what a YAML node is in messages.
+func yamlTypeName(n *yaml.Node) string {
+	switch {
+	case n.Kind == yaml.MappingNode:
+		return "a mapping"
+	case n.Kind == yaml.SequenceNode:
+		return "a list"
+	case n.ShortTag() == "!!int" || n.ShortTag() == "!!float":
+		return "the number " + n.Value
+	case n.ShortTag() == "!!bool":
+		return n.Value
+	}
+	return strconv.Quote(n.Value)
+}
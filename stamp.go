@@ -0,0 +1,161 @@
+package interactions
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// WithWatermark draws text large, faint and diagonally across a grid,
+// matrix or poster, as in "DRAFT", over the panels.
+func WithWatermark(text string) Option {
+	return func(o *options) { o.watermark = text }
+}
+
+// WithFooter adds a line of text centred under the panels of a grid,
+// matrix or poster, such as who rendered it.
+func WithFooter(text string) Option {
+	return func(o *options) { o.footer = text }
+}
+
+// WithLogo stamps img into the top right corner of a grid, matrix or
+// poster, beside the title, scaled down to fit the height of the title
+// and source line when it is taller.
+func WithLogo(img image.Image) Option {
+	return func(o *options) { o.logo = img }
+}
+
+// WithPostProcess calls fn with each finished image, after everything
+// else is drawn on it and before it is encoded, so an embedder can overlay
+// its own branding, clear part of it, or composite it into a larger figure
+// without decoding it again. Hooks from several WithPostProcess options
+// run in the order given, stopping at the first error. Every function
+// drawing an image runs them on it: RenderContext returns their error, and
+// DrawGrid, DrawPanel, DrawMatrix, DrawPoster, DrawLegend and DrawContents,
+// which have no error to return, report it through WithWarnings.
+// WriteTiledGrid never holds the whole image, so it fails when given any.
+func WithPostProcess(fn func(draw.Image) error) Option {
+	return func(o *options) { o.postProcess = append(o.postProcess, fn) }
+}
+
+// PostProcess runs the WithPostProcess hooks of opts on img.
+func PostProcess(img draw.Image, opts ...Option) error {
+	return collectOptions(opts).runPostProcess(img)
+}
+
+// finish runs the WithPostProcess hooks on img, the finished image of a
+// function with no error to return, reporting an error of one through
+// WithWarnings, and returns img.
+func (o options) finish(img *image.RGBA) *image.RGBA {
+	if err := o.runPostProcess(img); err != nil && o.warn != nil {
+		o.warn(Warning{Path: "post-process", Message: err.Error()})
+	}
+	return img
+}
+
+// runPostProcess runs the WithPostProcess hooks on img.
+func (o options) runPostProcess(img draw.Image) error {
+	for _, fn := range o.postProcess {
+		if err := fn(img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	// footerHeight is the height of the line WithFooter adds.
+	footerHeight = 24
+	// logoHeight is the tallest a WithLogo image is drawn, and
+	// logoMaxWidth the widest.
+	logoHeight   = 40
+	logoMaxWidth = 4 * logoHeight
+	// watermarkWeight is how much of its colour the watermark mixes into
+	// what is under it, out of 100.
+	watermarkWeight = 15
+)
+
+// footerHeight is the height added under the panels for the footer, zero
+// without one.
+func (o options) footerHeight() int {
+	if o.footer == "" {
+		return 0
+	}
+	return footerHeight
+}
+
+// drawStamps draws the footer, logo and watermark onto the part of an
+// image with the given bounds that canvas covers, which need not be all
+// of it.
+func (o options) drawStamps(canvas *image.RGBA, bounds image.Rectangle, th Theme) {
+	m := o.geometry().margin
+	if o.footer != "" {
+		drawCenteredLabel(canvas, o.footer, bounds.Dx()/2, bounds.Max.Y-m-4, th.MutedText)
+	}
+	if o.logo != nil {
+		drawLogo(canvas, o.logo, image.Pt(bounds.Max.X-m, bounds.Min.Y+m))
+	}
+	if o.watermark != "" {
+		drawWatermark(canvas, o.watermark, bounds, th.MutedText)
+	}
+}
+
+// drawLogo draws logo with its top right corner at corner, scaled down to
+// fit logoHeight by logoMaxWidth keeping its shape.
+func drawLogo(canvas *image.RGBA, logo image.Image, corner image.Point) {
+	src := logo.Bounds()
+	if src.Empty() {
+		return
+	}
+	scale := math.Min(1, math.Min(float64(logoHeight)/float64(src.Dy()), float64(logoMaxWidth)/float64(src.Dx())))
+	w := max(1, int(math.Round(float64(src.Dx())*scale)))
+	h := max(1, int(math.Round(float64(src.Dy())*scale)))
+	dst := image.Rect(corner.X-w, corner.Y, corner.X, corner.Y+h)
+	xdraw.CatmullRom.Scale(canvas, dst, logo, src, xdraw.Over, nil)
+}
+
+// drawWatermark draws text in col across bounds from the bottom left
+// towards the top right, as large as fits, faintly over what is there.
+// Each pixel depends only on where it is in bounds, so drawing the image a
+// band at a time gives the same result as drawing it whole.
+func drawWatermark(canvas *image.RGBA, text string, bounds image.Rectangle, col color.RGBA) {
+	// the text in the pixel font, to be sampled scaled up
+	tw := textWidth(text)
+	mask := image.NewRGBA(image.Rect(0, 0, tw, 13))
+	drawLabel(mask, text, 0, 11, color.White)
+	mh := mask.Bounds().Dy()
+
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	scale := math.Min(0.6*math.Hypot(w, h)/float64(tw), math.Min(w, h)/float64(3*mh))
+	angle := math.Atan2(h, w)
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	cx, cy := float64(bounds.Min.X)+w/2, float64(bounds.Min.Y)+h/2
+
+	area := canvas.Bounds().Intersect(bounds)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			// along and across the text, in pixels of the mask
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			u := (dx*cos-dy*sin)/scale + float64(tw)/2
+			v := (dx*sin+dy*cos)/scale + float64(mh)/2
+			if u < 0 || v < 0 || u >= float64(tw) || v >= float64(mh) {
+				continue
+			}
+			if mask.RGBAAt(int(u), int(v)).A == 0 {
+				continue
+			}
+			canvas.SetRGBA(x, y, blend(canvas.RGBAAt(x, y), col, watermarkWeight))
+		}
+	}
+}
+
+// blend mixes weight out of 100 of c into under.
+func blend(under, c color.RGBA, weight int) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8((int(a)*(100-weight) + int(b)*weight) / 100)
+	}
+	return color.RGBA{mix(under.R, c.R), mix(under.G, c.G), mix(under.B, c.B), 255}
+}
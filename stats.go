@@ -0,0 +1,79 @@
+package interactions
+
+import (
+	"maps"
+	"slices"
+)
+
+// Statistics summarises a set of scenarios.
+type Statistics struct {
+	Scenarios int
+	Edges     int
+	// Dimensions counts the scenarios with each value of every dimension,
+	// by dimension name in alphabetical order. Scenarios without a
+	// dimension that others have count under the empty value.
+	Dimensions []DimensionStats
+	// Breakdown counts the scenarios by size: Breakdown[n][e] is the number
+	// with exactly n nodes and e edges.
+	Breakdown [][]int
+}
+
+// DimensionStats counts the scenarios with each value of one dimension.
+type DimensionStats struct {
+	Name string
+	// Values are in the order the scenarios first use them.
+	Values []ValueCount
+}
+
+// ValueCount is the number of scenarios with a value.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// Stats counts the scenarios, their edges and the values of their
+// dimensions.
+func Stats(scenarios []Scenario) Statistics {
+	st := Statistics{Scenarios: len(scenarios)}
+	names := map[string]bool{}
+	for _, s := range scenarios {
+		st.Edges += len(s.Edges)
+		for name := range s.Dimensions {
+			names[name] = true
+		}
+		for len(st.Breakdown) <= len(s.Nodes) {
+			st.Breakdown = append(st.Breakdown, nil)
+		}
+		row := st.Breakdown[len(s.Nodes)]
+		for len(row) <= len(s.Edges) {
+			row = append(row, 0)
+		}
+		row[len(s.Edges)]++
+		st.Breakdown[len(s.Nodes)] = row
+	}
+	// every row as wide as the widest, so the breakdown is a matrix
+	width := 0
+	for _, row := range st.Breakdown {
+		width = max(width, len(row))
+	}
+	for n, row := range st.Breakdown {
+		st.Breakdown[n] = append(row, make([]int, width-len(row))...)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		d := DimensionStats{Name: name}
+		index := map[string]int{}
+		for _, s := range scenarios {
+			v := s.Dimensions[name]
+			i, ok := index[v]
+			if !ok {
+				i = len(d.Values)
+				index[v] = i
+				d.Values = append(d.Values, ValueCount{Value: v})
+			}
+			d.Values[i].Count++
+		}
+		st.Dimensions = append(st.Dimensions, d)
+	}
+	return st
+}
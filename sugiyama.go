@@ -0,0 +1,135 @@
+package interactions
+
+import (
+	"image"
+	"strconv"
+)
+
+// Sugiyama is a Layout of as many rows as the longest chain of edges
+// needs, rather than the two of Layered: an event goes in the row under the
+// lowest of the events with edges into it, so a chain A → E → B runs down
+// three rows. An edge that skips rows passes through a waypoint in each row
+// between its ends, kept clear of the nodes there like one of them, instead
+// of cutting diagonally across the rows. The rows are as far apart as the
+// two of Layered, and panels grow taller to fit them. Scenarios that need
+// only two rows are drawn as Layered draws them.
+type Sugiyama struct{}
+
+// Place places the nodes as in a panel of the default size.
+func (Sugiyama) Place(s Scenario, area image.Rectangle) map[string]image.Point {
+	stagger := rowStagger * area.Dy() / (lowerRowY - upperRowY)
+	return placeSugiyama(s, area, nodeRadius, stagger).positions
+}
+
+// placeSugiyama is placeLayered with a row for each layer of the longest
+// path layering of the events of s, and waypoints for the edges that skip
+// rows.
+func placeSugiyama(s Scenario, area image.Rectangle, r, stagger int) panelLayout {
+	rows, processes, waypoints := sugiyamaRows(s)
+	positions, shapes := placeRows(s, rows, processes, area, r, stagger)
+	bends := map[int][]image.Point{}
+	for i, names := range waypoints {
+		for _, name := range names {
+			bends[i] = append(bends[i], positions[name])
+			delete(positions, name)
+		}
+	}
+	return panelLayout{
+		positions: positions,
+		shapes:    shapes,
+		bends:     bends,
+		radius:    r,
+		lowerY:    area.Min.Y + (len(rows)-1)*area.Dy(),
+	}
+}
+
+// sugiyamaRows returns the rows of events and waypoints of s in order, its
+// processes, and the waypoints each edge passes through by the edge's
+// index.
+func sugiyamaRows(s Scenario) (rows [][]string, processes []string, waypoints map[int][]string) {
+	early, late, processes := chronology(s)
+	layer := eventLayers(s, early, late)
+
+	rows = [][]string{early}
+	for _, name := range late {
+		k := layer[name]
+		for len(rows) <= k {
+			rows = append(rows, nil)
+		}
+		rows[k] = append(rows[k], name)
+	}
+
+	// waypoints are named so they cannot clash with the nodes; an edge
+	// through waypoints links each to the next for orderRows
+	waypoints = map[int][]string{}
+	var links [][2]string
+	for i, e := range s.Edges {
+		from, okFrom := layer[e.From]
+		to, okTo := layer[e.To]
+		if !okFrom || !okTo || max(from, to)-min(from, to) < 2 {
+			if e.From != e.To {
+				links = append(links, [2]string{e.From, e.To})
+			}
+			continue
+		}
+		step := 1
+		if to < from {
+			step = -1
+		}
+		for k := from + step; k != to; k += step {
+			name := "\x00" + strconv.Itoa(i) + "." + strconv.Itoa(k)
+			rows[k] = append(rows[k], name)
+			waypoints[i] = append(waypoints[i], name)
+		}
+		chain := append(append([]string{e.From}, waypoints[i]...), e.To)
+		for j := 1; j < len(chain); j++ {
+			links = append(links, [2]string{chain[j-1], chain[j]})
+		}
+	}
+	return orderRows(rows, links), processes, waypoints
+}
+
+// eventLayers numbers the row of each event of s, given the earlier events
+// of the upper row and the later ones: the upper row is 0 and every later
+// event goes one row under the lowest of the events with a one-way edge
+// into it, or in row 1 when there are none. Edges that would make a cycle
+// are passed over.
+func eventLayers(s Scenario, early, late []string) map[string]int {
+	layer := map[string]int{}
+	events := map[string]bool{}
+	for _, name := range early {
+		layer[name] = 0
+		events[name] = true
+	}
+	for _, name := range late {
+		events[name] = true
+	}
+	into := map[string][]string{}
+	for _, e := range s.Edges {
+		if _, _, tail := e.heads(); tail || e.From == e.To || !events[e.From] || !events[e.To] {
+			continue
+		}
+		into[e.To] = append(into[e.To], e.From)
+	}
+
+	const visiting = -1
+	var layerOf func(name string) int
+	layerOf = func(name string) int {
+		if k, ok := layer[name]; ok {
+			return k
+		}
+		layer[name] = visiting
+		k := 1
+		for _, from := range into[name] {
+			if kf := layerOf(from); kf != visiting {
+				k = max(k, kf+1)
+			}
+		}
+		layer[name] = k
+		return k
+	}
+	for _, name := range late {
+		layerOf(name)
+	}
+	return layer
+}
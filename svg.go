@@ -0,0 +1,232 @@
+package interactions
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// WritePanelSVG writes a single scenario panel as an SVG document with the
+// same layout as DrawPanel. scale multiplies the document's display size.
+// The document is titled after the scenario and described by Describe, for
+// screen readers.
+func WritePanelSVG(w io.Writer, s Scenario, th Theme, scale int, opts ...Option) error {
+	if scale < 1 {
+		scale = 1
+	}
+	o := collectOptions(opts)
+	geo, rect, bounds := o.panelFrame(s)
+	width, height := bounds.Dx(), bounds.Dy()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="monospace" font-size="12" role="img">`+"\n",
+		width*scale, height*scale, width, height)
+	// screen readers name the image by its title and read the description
+	fmt.Fprintf(&b, "<title>%s</title>\n<desc>%s</desc>\n", html.EscapeString(cmp.Or(s.Title, s.Code)), html.EscapeString(Describe(s)))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, svgColor(th.Background))
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s"%s/>`+"\n",
+		rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy(), svgColor(th.Panel), svgColor(th.PanelBorder), svgStrokeWidth(o))
+
+	// Title & subtitle
+	c := o.caption(0)
+	text, titleY, subtitleY, shift := c.text(s, rect, geo)
+	room := lineRoom(rect.Dx())
+	svgPanelLines(&b, o.fitLines(text.title, room), rightToLeft(s.Title), rect, titleY, th.Title)
+	svgPanelLines(&b, o.fitLines(text.subtitle, room), rightToLeft(s.Subtitle), rect, subtitleY, th.MutedText)
+	if c.Code && s.Code != "" {
+		code := o.fit(s.Code, codeRoom(rect.Dx()))
+		x, y := codePos(rect, code)
+		svgText(&b, code, x, y, th.MutedText)
+	}
+	if c.Index {
+		x, y := indexPos(rect)
+		svgText(&b, ListNumber(c.number), x, y, th.MutedText)
+	}
+	if c.badge != "" {
+		x, y := badgePos(rect, c.badge)
+		svgText(&b, c.badge, x, y, th.Accent)
+	}
+
+	svgPanelLines(&b, o.fitLines(wrapDescription(s, rect.Dx()), room), rightToLeft(s.Description), rect, descriptionY(rect, geo), th.Text)
+
+	layout := o.layoutScenario(s, rect, shift, geo)
+
+	// Edges first, as in drawScenario
+	for k, e := range s.Edges {
+		from := layout.positions[e.From]
+		to := layout.positions[e.To]
+		if e.From == e.To {
+			dirY := layout.loopDir(from)
+			svgSelfLoop(&b, from, dirY, layout.shape(e.From).rim(0, dirY), e, o.strokeWidth(), o.edgeColor(e, th))
+			continue
+		}
+		if bends := layout.bends[k]; len(bends) > 0 {
+			line := routedLine(from, bends, to, layout.shape(e.From), layout.shape(e.To))
+			svgRoutedEdge(&b, line, e, o.pen(), o.edgeColor(e, th), o.signColor(e, th))
+			continue
+		}
+		svgEdge(&b, from, to, layout.shape(e.From), layout.shape(e.To), e, o.pen(), o.edgeColor(e, th), o.signColor(e, th))
+	}
+
+	// Nodes on top
+	for _, name := range s.Nodes {
+		pt := layout.positions[name]
+		fill, border, label := o.nodeColors(name, th)
+		sh := layout.shape(name)
+		if sh.box() {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s"%s/>`+"\n",
+				pt.X-sh.halfW, pt.Y-sh.halfH, 2*sh.halfW, 2*sh.halfH, svgColor(fill), svgColor(border), svgStrokeWidth(o))
+		} else {
+			fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="%d" fill="%s" stroke="%s"%s/>`+"\n",
+				pt.X, pt.Y, sh.radius, svgColor(fill), svgColor(border), svgStrokeWidth(o))
+		}
+		for _, l := range o.nodeLabel(name, sh, pt) {
+			svgText(&b, l.text, l.x, l.y, label)
+		}
+	}
+
+	b.WriteString("</svg>\n")
+	o.checkText(s, geo, 0)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func svgEdge(b *strings.Builder, from, to image.Point, fromShape, toShape nodeShape, e Edge, p pen, col, accent color.RGBA) {
+	ux, uy, tailX, tailY, headX, headY, ok := edgeSegment(from.X, from.Y, to.X, to.Y, fromShape, toShape)
+	if !ok {
+		return
+	}
+
+	for _, l := range p.edgeLines([][2]float64{{tailX, tailY}, {headX, headY}}, e) {
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"%s/>`+"\n",
+			l[0][0], l[0][1], l[1][0], l[1][1], svgColor(col), e.strokeWidth(p.stroke), svgDash(e, p.stroke))
+	}
+	toKind, fromKind, tail := e.heads()
+	if !p.parallel(e) {
+		svgHead(b, headX, headY, ux, uy, toKind, p.stroke, col)
+	}
+	if tail && !p.parallel(e) {
+		svgHead(b, tailX, tailY, -ux, -uy, fromKind, p.stroke, col)
+	}
+
+	if label := edgeLabel(e); label != "" {
+		x, y := edgeLabelPos(e, tailX, tailY, headX, headY, ux, uy)
+		svgText(b, label, x, y, col)
+	}
+	if e.Polarity != "" {
+		x, y := polarityLabelPos(e, tailX, tailY, headX, headY, ux, uy)
+		svgText(b, e.Polarity, x, y, accent)
+	}
+}
+
+// svgRoutedEdge draws the same routed edges as drawRoutedEdge.
+func svgRoutedEdge(b *strings.Builder, line [][2]float64, e Edge, p pen, col, accent color.RGBA) {
+	for _, l := range p.edgeLines(line, e) {
+		pts := make([]string, len(l))
+		for i, pt := range l {
+			pts[i] = fmt.Sprintf("%.1f,%.1f", pt[0], pt[1])
+		}
+		fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%d"%s/>`+"\n",
+			strings.Join(pts, " "), svgColor(col), e.strokeWidth(p.stroke), svgDash(e, p.stroke))
+	}
+	n := len(line)
+	toKind, fromKind, tail := e.heads()
+	if ux, uy, ok := unitBetween(line[n-2], line[n-1]); ok && !p.parallel(e) {
+		svgHead(b, line[n-1][0], line[n-1][1], ux, uy, toKind, p.stroke, col)
+	}
+	if ux, uy, ok := unitBetween(line[0], line[1]); ok && tail && !p.parallel(e) {
+		svgHead(b, line[0][0], line[0][1], -ux, -uy, fromKind, p.stroke, col)
+	}
+
+	m := (n - 1) / 2
+	s, t := line[m], line[m+1]
+	ux, uy, _ := unitBetween(s, t)
+	if label := edgeLabel(e); label != "" {
+		x, y := edgeLabelPos(e, s[0], s[1], t[0], t[1], ux, uy)
+		svgText(b, label, x, y, col)
+	}
+	if e.Polarity != "" {
+		x, y := polarityLabelPos(e, s[0], s[1], t[0], t[1], ux, uy)
+		svgText(b, e.Polarity, x, y, accent)
+	}
+}
+
+func svgSelfLoop(b *strings.Builder, pt image.Point, dirY, r float64, e Edge, stroke int, col color.RGBA) {
+	loop := selfLoopGeometry(pt.X, pt.Y, 0, dirY, r)
+
+	pts := make([]string, len(loop.points))
+	for i, p := range loop.points {
+		pts[i] = fmt.Sprintf("%.1f,%.1f", p.x, p.y)
+	}
+	fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%d"%s/>`+"\n",
+		strings.Join(pts, " "), svgColor(col), e.strokeWidth(stroke), svgDash(e, stroke))
+	end := loop.points[len(loop.points)-1]
+	svgHead(b, end.x, end.y, loop.endUX, loop.endUY, e.Kind, stroke, col)
+
+	if label := edgeLabel(e); label != "" {
+		svgText(b, label, int(loop.labelX)-textWidth(label)/2, int(loop.labelY+4), col)
+	}
+}
+
+// svgHead draws the same heads as drawHead.
+func svgHead(b *strings.Builder, hx, hy, ux, uy float64, kind EdgeKind, stroke int, col color.RGBA) {
+	switch kind {
+	case Inhibition:
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"/>`+"\n",
+			hx-uy*teeHalfWidth, hy+ux*teeHalfWidth, hx+uy*teeHalfWidth, hy-ux*teeHalfWidth, svgColor(col), 2*max(stroke, 1))
+	default:
+		tri := arrowHeadPoints(hx, hy, ux, uy)
+		fmt.Fprintf(b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/>`+"\n",
+			tri[0].x, tri[0].y, tri[1].x, tri[1].y, tri[2].x, tri[2].y, svgColor(col))
+	}
+}
+
+// svgDash returns the stroke-dasharray attribute for e's style, scaled by its
+// width as drawStroke does, or "" for solid edges.
+func svgDash(e Edge, stroke int) string {
+	pattern := dashPatternFor(e.style())
+	if pattern == nil {
+		return ""
+	}
+	runs := make([]string, len(pattern))
+	for i, run := range pattern {
+		runs[i] = fmt.Sprint(run * e.strokeWidth(stroke))
+	}
+	return fmt.Sprintf(` stroke-dasharray="%s"`, strings.Join(runs, " "))
+}
+
+// svgStrokeWidth returns the stroke-width attribute of outlines drawn
+// WithStrokeWidth, or "" for the default hairline.
+func svgStrokeWidth(o options) string {
+	if o.strokeWidth() == 1 {
+		return ""
+	}
+	return fmt.Sprintf(` stroke-width="%d"`, o.strokeWidth())
+}
+
+// svgPanelLines draws lines of panel text as drawPanelLines does.
+func svgPanelLines(b *strings.Builder, lines []string, rtl bool, rect image.Rectangle, y int, col color.RGBA) {
+	for i, l := range lines {
+		svgText(b, l, panelLineX(l, rtl, rect), y+i*lineHeight, col)
+	}
+}
+
+// svgText draws text starting at x. Right-to-left text is marked so the
+// viewer orders mixed-direction text correctly, which makes x its right
+// end, so it moves over by the text's width.
+func svgText(b *strings.Builder, text string, x, y int, col color.RGBA) {
+	dir := ""
+	if rightToLeft(text) {
+		x += textWidth(text)
+		dir = ` direction="rtl"`
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" fill="%s"%s>%s</text>`+"\n", x, y, svgColor(col), dir, html.EscapeString(text))
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
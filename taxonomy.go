@@ -0,0 +1,886 @@
+package interactions
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/arran4/interactions/combinatorics"
+)
+
+// TaxonomyName is the name the built-in taxonomy is registered under as a
+// Generator.
+const TaxonomyName = "taxonomy"
+
+func init() {
+	RegisterGenerator(TaxonomyName, func(o GeneratorOptions) []Scenario {
+		return o.taxonomy()
+	})
+}
+
+// actorNames is the number of actor names available (A to Z), shared by
+// the core actors, the external ones and the mediator.
+const actorNames = 'Z' - 'A' + 1
+
+// GenOption is an option of Scenarios and CountScenarios.
+type GenOption func(*genConfig)
+
+// genConfig is the taxonomy GenOptions choose, and the first mistake in
+// them.
+type genConfig struct {
+	GeneratorOptions
+	err error
+}
+
+// Include switches on the optional dimensions named, by the field each
+// records on the scenarios for queries: strength, delay, certainty,
+// feedback, time and chain; see GeneratorOptions. The name ecology, which
+// is not a dimension of its own, adds competition and predation to the ab
+// dimension and labels every A-B edge with its ecological signs.
+func Include(dims ...string) GenOption {
+	return func(c *genConfig) { c.set(dims, true) }
+}
+
+// Exclude switches the optional dimensions named off again; see Include.
+func Exclude(dims ...string) GenOption {
+	return func(c *genConfig) { c.set(dims, false) }
+}
+
+// set switches each of the optional dimensions named on or off.
+func (c *genConfig) set(dims []string, on bool) {
+	for _, name := range dims {
+		option := c.optional(name)
+		if option == nil {
+			c.fail(fmt.Errorf("unknown dimension %q (want strength, delay, certainty, feedback, time, chain or ecology)", name))
+			continue
+		}
+		*option = on
+	}
+}
+
+// optional returns the option switching on the optional dimension name,
+// or nil if there is none of that name.
+func (o *GeneratorOptions) optional(name string) *bool {
+	switch strings.ToLower(name) {
+	case "strength":
+		return &o.Strengths
+	case "delay":
+		return &o.Delays
+	case "certainty":
+		return &o.Uncertain
+	case "feedback":
+		return &o.Feedback
+	case "time":
+		return &o.Timing
+	case "chain":
+		return &o.Chains
+	case "ecology":
+		return &o.Ecology
+	}
+	return nil
+}
+
+// Drop leaves the dimensions named out of the taxonomy altogether, for
+// smaller grids focused on the rest: "externals" for every external actor,
+// an external actor's own field such as "d", or an optional dimension such
+// as "time". "type", the event or process field the time dimension records,
+// drops it too. The core actors' pairs cannot be dropped.
+func Drop(dims ...string) GenOption {
+	return func(c *genConfig) { c.Drop = append(c.Drop, dims...) }
+}
+
+// CustomDimensions adds dimensions of a scenario file, such as those of
+// LoadDimensionsFile, to the taxonomy, each scenario taking every value of
+// each in turn.
+func CustomDimensions(dims ...Dimension) GenOption {
+	return func(c *genConfig) { c.Custom = append(c.Custom, dims...) }
+}
+
+// CoreActors sets the number of core actors, from A onwards, with a
+// pattern for each pair of them. There are two by default.
+func CoreActors(n int) GenOption {
+	return func(c *genConfig) { c.Actors = n }
+}
+
+// ExternalActors sets the number of external actors influencing the core
+// actors, named after them. There are two by default, C and D.
+func ExternalActors(n int) GenOption {
+	return func(c *genConfig) { c.Externals = n }
+}
+
+// InLanguage sets the language of the titles and subtitles, one of
+// Languages. They are in English by default.
+func InLanguage(lang string) GenOption {
+	return func(c *genConfig) { c.Lang = lang }
+}
+
+// FromOptions replaces every option given before it with o, as a
+// Generator is given them.
+func FromOptions(o GeneratorOptions) GenOption {
+	return func(c *genConfig) { c.GeneratorOptions = o }
+}
+
+// fail records err unless an earlier option has failed.
+func (c *genConfig) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// newGenConfig applies opts to the default taxonomy, of A and B with C and
+// D influencing them, titled in English, and checks the result.
+func newGenConfig(opts []GenOption) (*genConfig, error) {
+	c := &genConfig{GeneratorOptions: GeneratorOptions{Actors: 2, Externals: 2, Lang: "en"}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// check fails if o cannot be generated: too few or too many actors to
+// name, or an unknown language.
+func (o GeneratorOptions) check() error {
+	if o.Actors < 2 || o.Actors > actorNames {
+		return fmt.Errorf("actors must be between 2 and %d", actorNames)
+	}
+	if maxExternals := actorNames - o.Actors; o.Externals < 0 || o.Externals > maxExternals {
+		return fmt.Errorf("externals must be between 0 and %d with %d actors", maxExternals, o.Actors)
+	}
+	if o.Chains && o.Actors+o.Externals == actorNames {
+		return fmt.Errorf("chains need a name for their mediator; use at most %d externals", actorNames-o.Actors-1)
+	}
+	if langs := Languages(); !slices.Contains(langs, o.Lang) {
+		return fmt.Errorf("unknown language %q (want %s)", o.Lang, strings.Join(langs, ", "))
+	}
+	droppable := []string{"externals", "type"}
+	codes := map[string]bool{}
+	fields := map[string]bool{"externals": true, "type": true, "relation": true}
+	for _, d := range o.allDimensions() {
+		if !d.pair {
+			droppable = append(droppable, d.field)
+		}
+		if d.custom {
+			if d.field == "" || d.values == 0 {
+				return fmt.Errorf("dimension %q needs a name and values", d.field)
+			}
+			if strings.Trim(d.code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+				return fmt.Errorf("dimension %q: code %q is not letters", d.field, d.code)
+			}
+			if codes[d.code] || fields[d.field] {
+				return fmt.Errorf("dimension %q: code %s or name taken by another dimension", d.field, d.code)
+			}
+		}
+		codes[d.code], fields[d.field] = true, true
+	}
+	for _, name := range o.Drop {
+		if !slices.Contains(droppable, name) {
+			return fmt.Errorf("cannot drop dimension %q (want %s)", name, strings.Join(droppable, ", "))
+		}
+	}
+	return nil
+}
+
+// dropped reports whether o.Drop leaves out the dimension recording field,
+// one of the external actors when external is true.
+func (o GeneratorOptions) dropped(field string, external bool) bool {
+	for _, name := range o.Drop {
+		if name == field || name == "externals" && external || name == "type" && field == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+// Scenarios returns the built-in taxonomy of scenarios, the canonical list
+// the command line draws, in order: by default every pattern of A and B
+// with every pattern of C and D influencing them. opts add and remove
+// dimensions and actors.
+func Scenarios(opts ...GenOption) ([]Scenario, error) {
+	c, err := newGenConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.taxonomy(), nil
+}
+
+// CountScenarios returns how many scenarios Scenarios would return for
+// opts, without generating them, as there can be far too many to.
+func CountScenarios(opts ...GenOption) (*big.Int, error) {
+	c, err := newGenConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return countScenarios(c.dimensions()), nil
+}
+
+// ExternalNames returns the names of the external actors of o: C, D and so
+// on with A and B alone, less any o.Drop leaves out.
+func (o GeneratorOptions) ExternalNames() []string {
+	var names []string
+	for _, name := range externalNames(o.Actors, o.Externals) {
+		if !o.dropped(strings.ToLower(name), true) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// taxonomy generates the built-in taxonomy of o, in which every combination
+// of the values of the dimensions below is a scenario.
+//
+// AB pattern codes:
+// 0 = no direct link
+// 1 = A -> B
+// 2 = B -> A
+// 3 = A <-> B (mutualism)
+// 4 = A -| B (amensalism: A inhibits B)
+// 5 = A |-| B (competition; only with GeneratorOptions.Ecology)
+// 6 = A preys on B (predation; only with GeneratorOptions.Ecology)
+//
+// AB strength codes (only with GeneratorOptions.Strengths, and only for AB
+// patterns that have an edge):
+// 0 = unweighted
+// 1 = weak
+// 2 = strong
+//
+// AB delay codes (only with GeneratorOptions.Delays, and only for AB patterns
+// that have an edge):
+// 0 = immediate
+// 1 = delayed (drawn dashed)
+//
+// AB certainty codes (only with GeneratorOptions.Uncertain, and only for AB
+// patterns that have an edge):
+// 0 = always happens
+// 1 = sometimes happens (probability 0.5, drawn dotted)
+// 2 = happens only under some condition (drawn dotted with a "?")
+//
+// Feedback codes (only with GeneratorOptions.Feedback):
+// 0 = no self-loops
+// 1 = A reinforces itself
+// 2 = B reinforces itself
+// 3 = A and B both reinforce themselves
+//
+// Timing codes (only with GeneratorOptions.Timing), as Allen interval
+// relations between A and B drawn as processes:
+// 0 = none (A and B are events)
+// 1 = A meets B
+// 2 = A overlaps B
+// 3 = A contains B
+//
+// Chain codes (only with GeneratorOptions.Chains), for indirect influence
+// through a mediator M, the actor after the last external one:
+// 0 = no mediator
+// 1 = A -> M -> B (A influences B through M)
+// 2 = B -> M -> A (B influences A through M)
+// 3 = A -> M <- B (fan-in: M is a common effect of A and B)
+// 4 = A <- M -> B (fan-out: M is a common cause of A and B)
+//
+// External pattern codes, one per external actor (C, D, ...):
+// 0 = no edges
+// 1 = -> A only
+// 2 = -> B only
+// 3 = -> A and B
+//
+// Each scenario's Code is built from these codes: AB, then SW (strength),
+// DL (delay), CT (certainty), FB (feedback), TM (timing) and CH (chain)
+// when they are not 0, then one segment per external actor named after it,
+// as in "AB3.TM2.C1.D0". Codes are published, so a code must keep its
+// meaning: never renumber existing values, and give new dimensions a
+// default of 0 that is left out of the code.
+func (o GeneratorOptions) taxonomy() []Scenario {
+	dims := o.dimensions()
+	var scenarios []Scenario
+	for values := range combinatorics.Product(axes(dims)...) {
+		scenarios = append(scenarios, o.scenario(dims, values))
+	}
+	return scenarios
+}
+
+// countScenarios is how many scenarios taxonomy makes of dims, worked out
+// without making them, as there can be far too many to.
+func countScenarios(dims []dimension) *big.Int {
+	return combinatorics.Count(axes(dims)...)
+}
+
+// axes describes dims to the combinatorics package.
+func axes(dims []dimension) []combinatorics.Dim {
+	axes := make([]combinatorics.Dim, len(dims))
+	for i, d := range dims {
+		axes[i] = combinatorics.Dim{Name: d.code, Size: d.values, Needs: d.needs}
+	}
+	return axes
+}
+
+// dimension is one axis of the generated taxonomy, such as the pattern of
+// A and B or of an external actor. taxonomy makes a scenario for
+// every combination of the values of the dimensions, varying the last
+// fastest.
+type dimension struct {
+	// code is the dimension's segment of scenario codes, such as "AB",
+	// and field the dimension recorded for queries, such as "ab", taking
+	// the value of names for each value
+	code, field string
+	names       []string
+	// values is how many values the dimension takes, 1 when its option is
+	// off
+	values int
+	// optional dimensions are left out of the code at their default, 0
+	optional bool
+	// pair dimensions are the links between the core actors, which
+	// cannot be dropped, and external ones the patterns of external actors
+	pair, external bool
+	// custom dimensions are those of GeneratorOptions.Custom
+	custom bool
+	// needs is the code of an earlier dimension that must be other than 0
+	// for this one to take other than 0
+	needs string
+	// build adds value v of the dimension to a scenario
+	build func(d *draft, v int)
+}
+
+// draft is a generated scenario being built, a dimension at a time.
+type draft struct {
+	// pairs are the titles of the links between the core actors, and
+	// qualifiers those of the optional dimensions
+	pairs, qualifiers []string
+	title             string
+	// influences are the sentences of the subtitle about the external
+	// actors
+	influences []string
+	nodes      map[string]bool
+	// extra are the nodes of custom dimensions that are not actors of
+	// the taxonomy, in the order they were added
+	extra []string
+	edges []Edge
+	// ab is the index in edges of the A-B edge, or -1 when there is none
+	ab    int
+	spans map[string]Span
+	dims  map[string]string
+}
+
+// scenario builds the scenario of the given values of dims.
+func (o GeneratorOptions) scenario(dims []dimension, values []int) Scenario {
+	core := coreNames(o.Actors)
+	externals := o.ExternalNames()
+	d := &draft{nodes: map[string]bool{}, ab: -1, dims: map[string]string{}}
+	for _, name := range core {
+		d.nodes[name] = true
+	}
+	var code Code
+	for i, dim := range dims {
+		v := values[i]
+		d.dims[dim.field] = dim.names[v]
+		dim.build(d, v)
+		// optional dimensions only appear when not at their default
+		if !dim.optional || v != 0 {
+			code = append(code, CodeSegment{Dim: dim.code, Value: v})
+		}
+	}
+
+	title := d.title
+	if title == "" {
+		title = strings.Join(d.pairs, "; ")
+		if title == "" {
+			title = Translate(o.Lang, "No direct links")
+		}
+	}
+	for _, q := range d.qualifiers {
+		title += ", " + Translate(o.Lang, q)
+	}
+	subtitle := strings.Join(d.influences, "; ")
+	if len(externals) == 0 {
+		subtitle = Translate(o.Lang, "No external influences")
+	}
+
+	// Stable ordering for nicer layouts
+	order := append(append(append(slices.Clone(externals), o.mediator()), core...), d.extra...)
+	var nodes []string
+	for _, name := range order {
+		if d.nodes[name] && !slices.Contains(nodes, name) {
+			nodes = append(nodes, name)
+		}
+	}
+	return Scenario{
+		Code:       code.String(),
+		Title:      title,
+		Subtitle:   subtitle,
+		Nodes:      nodes,
+		Edges:      d.edges,
+		Spans:      d.spans,
+		Dimensions: d.dims,
+	}
+}
+
+// dimensions returns the dimensions of the taxonomy o generates, in the
+// order of their segments in the codes, less those o.Drop leaves out.
+func (o GeneratorOptions) dimensions() []dimension {
+	var dims []dimension
+	for _, d := range o.allDimensions() {
+		if !o.dropped(d.field, d.external) {
+			dims = append(dims, d)
+		}
+	}
+	return dims
+}
+
+// allDimensions returns every dimension of the taxonomy of o, dropped or
+// not.
+func (o GeneratorOptions) allDimensions() []dimension {
+	// on is the number of values of an optional dimension switched on by
+	// an option
+	on := func(option bool, n int) int {
+		if option {
+			return n
+		}
+		return 1
+	}
+	patterns := 5
+	if o.Ecology {
+		patterns = 7
+	}
+	core := coreNames(o.Actors)
+
+	var dims []dimension
+	for i, x := range core {
+		for _, y := range core[i+1:] {
+			dims = append(dims, o.pairDimension(x, y, patterns))
+		}
+	}
+
+	// the A-B edge, once added, takes the strength, delay and certainty
+	ab := func(change func(e *Edge)) func(d *draft) {
+		return func(d *draft) {
+			if d.ab >= 0 {
+				change(&d.edges[d.ab])
+			}
+		}
+	}
+	dims = append(dims,
+		dimension{code: "SW", field: "strength", names: strengthNames, values: on(o.Strengths, 3), optional: true, needs: "AB",
+			build: func(d *draft, v int) {
+				ab(func(e *Edge) { e.Weight = strengthWeight(v) })(d)
+				d.qualify(strengthQualifier(v))
+			}},
+		dimension{code: "DL", field: "delay", names: delayNames, values: on(o.Delays, 2), optional: true, needs: "AB",
+			build: func(d *draft, v int) {
+				if v == 1 {
+					ab(func(e *Edge) { e.Style = Dashed })(d)
+				}
+				d.qualify(delayQualifier(v))
+			}},
+		dimension{code: "CT", field: "certainty", names: certaintyNames, values: on(o.Uncertain, 3), optional: true, needs: "AB",
+			build: func(d *draft, v int) {
+				switch v {
+				case 1:
+					ab(func(e *Edge) { e.Probability = 0.5 })(d)
+				case 2:
+					ab(func(e *Edge) { e.Conditional = true })(d)
+				}
+				d.qualify(certaintyQualifier(v))
+			}},
+		dimension{code: "FB", field: "feedback", names: feedbackNames, values: on(o.Feedback, 4), optional: true,
+			build: func(d *draft, v int) {
+				// Self-reinforcement loops
+				if v == 1 || v == 3 {
+					d.edges = append(d.edges, Edge{From: "A", To: "A"})
+				}
+				if v == 2 || v == 3 {
+					d.edges = append(d.edges, Edge{From: "B", To: "B"})
+				}
+				d.qualify(feedbackQualifier(v))
+			}},
+		dimension{code: "TM", field: "time", names: timingNames, values: on(o.Timing, 4), optional: true,
+			build: func(d *draft, v int) {
+				d.spans = timingSpans(v)
+				d.dims["type"] = "event"
+				if v != 0 {
+					d.dims["type"] = "process"
+				}
+				d.qualify(timingQualifier(v))
+			}},
+	)
+	mediator := o.mediator()
+	dims = append(dims, dimension{code: "CH", field: "chain", names: chainNames, values: on(o.Chains, 5), optional: true,
+		build: func(d *draft, v int) {
+			// Indirect influence through the mediator
+			if v != 0 {
+				d.nodes[mediator] = true
+				d.edges = append(d.edges, chainEdges(v, mediator)...)
+			}
+			d.qualify(chainQualifier(v, mediator))
+		}})
+
+	for _, name := range externalNames(o.Actors, o.Externals) {
+		dims = append(dims, o.externalDimension(name, core))
+	}
+	for _, c := range o.Custom {
+		d := c.dimension()
+		d.custom = true
+		dims = append(dims, d)
+	}
+	return dims
+}
+
+// addNode adds the node name, which need not be an actor of the
+// taxonomy.
+func (d *draft) addNode(name string) {
+	if !d.nodes[name] {
+		d.extra = append(d.extra, name)
+	}
+	d.nodes[name] = true
+}
+
+// qualify adds the qualifier q to the title, unless it is "" for a
+// dimension at its default.
+func (d *draft) qualify(q string) {
+	if q != "" {
+		d.qualifiers = append(d.qualifiers, q)
+	}
+}
+
+// pairDimension is the pattern of the link between the core actors x and
+// y, one of patterns AB pattern codes. A and B alone are titled as ever;
+// with more core actors the title lists the links there are.
+func (o GeneratorOptions) pairDimension(x, y string, patterns int) dimension {
+	code := x + y
+	return dimension{code: code, field: strings.ToLower(code), names: abNames, values: patterns, pair: true,
+		build: func(d *draft, v int) {
+			if code == "AB" {
+				d.dims["relation"] = ecologicalRelations[v].name
+				if o.Actors == 2 {
+					d.title = Translate(o.Lang, abTitle(v))
+					if o.Ecology {
+						d.title = ecologyTitle(v, o.Lang)
+					}
+				}
+			}
+			e, ok := pairEdge(x, y, v)
+			if !ok {
+				return
+			}
+			if o.Ecology {
+				e.Polarity = ecologicalRelations[v].signs
+			}
+			if code == "AB" {
+				d.ab = len(d.edges)
+			}
+			d.edges = append(d.edges, e)
+			d.pairs = append(d.pairs, pairTitle(x, y, v, o.Lang))
+		}}
+}
+
+// pairEdge returns the edge of AB pattern code v between x and y, with ok
+// false when there is none.
+func pairEdge(x, y string, v int) (e Edge, ok bool) {
+	switch v {
+	case 1:
+		return Edge{From: x, To: y}, true
+	case 2:
+		return Edge{From: y, To: x}, true
+	case 3:
+		return Edge{From: x, To: y, Bidirectional: true}, true // mutualism
+	case 4:
+		return Edge{From: x, To: y, Kind: Inhibition}, true // amensalism
+	case 5:
+		return Edge{From: x, To: y, Bidirectional: true, Kind: Inhibition}, true // competition
+	case 6:
+		return Edge{From: x, To: y, Kind: Predation}, true
+	default:
+		return Edge{}, false
+	}
+}
+
+// pairTitle is the short title of the link of AB pattern code v between x
+// and y, in the language lang, for titles listing several links.
+func pairTitle(x, y string, v int, lang string) string {
+	switch v {
+	case 1:
+		return x + " → " + y
+	case 2:
+		return y + " → " + x
+	case 3:
+		return x + " ↔ " + y
+	case 4:
+		return x + " ⊣ " + y
+	case 5:
+		return x + " ⊣⊢ " + y
+	case 6:
+		return Translate(lang, "%s preys on %s", x, y)
+	default:
+		return ""
+	}
+}
+
+// externalDimension is the pattern of the external actor name: which of
+// the core actors it influences.
+func (o GeneratorOptions) externalDimension(name string, core []string) dimension {
+	names := make([]string, 1<<len(core))
+	for p := range names {
+		names[p] = externalPatternName(p, core)
+	}
+	return dimension{code: name, field: strings.ToLower(name), names: names, values: len(names), external: true,
+		build: func(d *draft, p int) {
+			d.influences = append(d.influences, externalSentence(name, p, core, o.Lang))
+			if p == 0 {
+				return
+			}
+			d.nodes[name] = true
+			for _, target := range influenced(p, core) {
+				d.edges = append(d.edges, Edge{From: name, To: target})
+			}
+		}}
+}
+
+// influenced returns the core actors an external actor with pattern p
+// influences, in order: the core actors whose bits are set in p, A being
+// bit 0.
+func influenced(p int, core []string) []string {
+	var targets []string
+	for i, name := range core {
+		if p&(1<<i) != 0 {
+			targets = append(targets, name)
+		}
+	}
+	return targets
+}
+
+// Dimension values recorded on generated scenarios for queries, indexed by
+// the codes above. Each external actor is a dimension named after it in
+// lower case ("c", "d", ...) taking externalPatternNames; "relation" is the
+// ecological relation and "type" is event or process.
+var (
+	abNames              = []string{"none", "a-influences-b", "b-influences-a", "mutualism", "amensalism", "competition", "predation"}
+	strengthNames        = []string{"normal", "weak", "strong"}
+	delayNames           = []string{"immediate", "delayed"}
+	certaintyNames       = []string{"always", "sometimes", "conditional"}
+	feedbackNames        = []string{"none", "a", "b", "both"}
+	timingNames          = []string{"none", "meets", "overlaps", "contains"}
+	chainNames           = []string{"none", "a-to-b", "b-to-a", "common-effect", "common-cause"}
+	externalPatternNames = []string{"none", "a", "b", "both"}
+)
+
+func strengthWeight(strength int) float64 {
+	switch strength {
+	case 1:
+		return 0.5
+	case 2:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// The qualifiers below are added to the title, after a comma, for the
+// optional dimensions that are not at their default; they return "" at the
+// default.
+
+func delayQualifier(delay int) string {
+	if delay == 1 {
+		return "delayed"
+	}
+	return ""
+}
+
+func certaintyQualifier(ct int) string {
+	switch ct {
+	case 1:
+		return "sometimes"
+	case 2:
+		return "conditional"
+	default:
+		return ""
+	}
+}
+
+func feedbackQualifier(fb int) string {
+	switch fb {
+	case 1:
+		return "A self-reinforcing"
+	case 2:
+		return "B self-reinforcing"
+	case 3:
+		return "A and B self-reinforcing"
+	default:
+		return ""
+	}
+}
+
+func timingQualifier(tm int) string {
+	switch tm {
+	case 1:
+		return "A meets B"
+	case 2:
+		return "A overlaps B"
+	case 3:
+		return "A contains B"
+	default:
+		return ""
+	}
+}
+
+func chainQualifier(ch int, mediator string) string {
+	switch ch {
+	case 1:
+		return "A → " + mediator + " → B"
+	case 2:
+		return "B → " + mediator + " → A"
+	case 3:
+		return "A → " + mediator + " ← B"
+	case 4:
+		return "A ← " + mediator + " → B"
+	default:
+		return ""
+	}
+}
+
+// chainEdges returns the edges through the mediator for a chain code.
+func chainEdges(ch int, mediator string) []Edge {
+	switch ch {
+	case 1:
+		return []Edge{{From: "A", To: mediator}, {From: mediator, To: "B"}}
+	case 2:
+		return []Edge{{From: "B", To: mediator}, {From: mediator, To: "A"}}
+	case 3:
+		return []Edge{{From: "A", To: mediator}, {From: "B", To: mediator}}
+	case 4:
+		return []Edge{{From: mediator, To: "A"}, {From: mediator, To: "B"}}
+	default:
+		return nil
+	}
+}
+
+// timingSpans returns the process spans of A and B for a timing code, or nil
+// when A and B are events.
+func timingSpans(tm int) map[string]Span {
+	switch tm {
+	case 1:
+		return map[string]Span{"A": {Start: 0, End: 0.5}, "B": {Start: 0.5, End: 1}}
+	case 2:
+		return map[string]Span{"A": {Start: 0, End: 0.65}, "B": {Start: 0.35, End: 1}}
+	case 3:
+		return map[string]Span{"A": {Start: 0, End: 1}, "B": {Start: 0.3, End: 0.7}}
+	default:
+		return nil
+	}
+}
+
+func strengthQualifier(strength int) string {
+	switch strength {
+	case 1:
+		return "weak"
+	case 2:
+		return "strong"
+	default:
+		return ""
+	}
+}
+
+func abTitle(ab int) string {
+	switch ab {
+	case 0:
+		return "A & B: no direct link"
+	case 1:
+		return "A → B"
+	case 2:
+		return "B → A"
+	case 3:
+		return "A ↔ B (mutualism)"
+	case 4:
+		return "A ⊣ B (amensalism)"
+	case 5:
+		return "A ⊣⊢ B (competition)"
+	case 6:
+		return "A preys on B (predation)"
+	default:
+		return "A/B pattern ?"
+	}
+}
+
+// ecologicalRelations names the classic ecological relation for each AB
+// pattern code, with the signs of the effects on the two parties: + gains,
+// - loses, 0 unaffected.
+var ecologicalRelations = map[int]struct{ name, signs string }{
+	0: {"neutralism", "00"},
+	1: {"commensalism", "+0"},
+	2: {"commensalism", "+0"},
+	3: {"mutualism", "++"},
+	4: {"amensalism", "-0"},
+	5: {"competition", "--"},
+	6: {"predation", "+-"},
+}
+
+// ecologyTitle titles an AB pattern by its ecological relation, in the
+// language lang.
+func ecologyTitle(ab int, lang string) string {
+	symbols := []string{"A & B", "A → B", "B → A", "A ↔ B", "A ⊣ B", "A ⊣⊢ B", "A preys on B"}
+	rel, ok := ecologicalRelations[ab]
+	if !ok {
+		return Translate(lang, abTitle(ab))
+	}
+	return fmt.Sprintf("%s: %s (%s)", Translate(lang, symbols[ab]), Translate(lang, rel.name), rel.signs)
+}
+
+// coreNames returns the names of the n core actors: A, B, C and so on.
+func coreNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = string(rune('A' + i))
+	}
+	return names
+}
+
+// externalNames returns the names of the first n external actors, named
+// after the core actors: C, D, E and so on with A and B alone.
+func externalNames(actors, n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = string(rune('A' + actors + i))
+	}
+	return names
+}
+
+// mediator is the name of the mediator of --chains, the actor after the
+// last external one.
+func (o GeneratorOptions) mediator() string {
+	return string(rune('A' + o.Actors + o.Externals))
+}
+
+// externalPatternName is the query value of external pattern p: those of
+// externalPatternNames for A and B, and the influenced core actors joined
+// by "-" once there are more, as in "a-c".
+func externalPatternName(p int, core []string) string {
+	if p < len(externalPatternNames) {
+		return externalPatternNames[p]
+	}
+	return strings.ToLower(strings.Join(influenced(p, core), "-"))
+}
+
+// externalSentence describes what the external actor name with pattern p
+// influences, in the language lang.
+func externalSentence(name string, p int, core []string, lang string) string {
+	switch {
+	case p == 0 && len(core) > 2:
+		return Translate(lang, "%s has no effect", name)
+	case p == 0:
+		return Translate(lang, "%s has no effect on A or B", name)
+	case p == 1:
+		return Translate(lang, "%s influences A only", name)
+	case p == 2:
+		return Translate(lang, "%s influences B only", name)
+	case p == 3:
+		return Translate(lang, "%s influences both A and B", name)
+	}
+	targets := influenced(p, core)
+	list := targets[0]
+	if len(targets) > 1 {
+		list = Translate(lang, "%s and %s", strings.Join(targets[:len(targets)-1], ", "), targets[len(targets)-1])
+	}
+	return Translate(lang, "%s influences %s", name, list)
+}
@@ -0,0 +1,99 @@
+package interactions
+
+import (
+	"cmp"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadThemeFile reads a colour theme from a YAML file: the built-in theme
+// named by base, light when it has none, with any of its colours given as
+// hex in place of the theme's own:
+//
+//	base: dark
+//	edge: "#ff8800"
+//	node-fill: "#123"
+//
+// The colours are named as the fields of Theme, in lower case with a
+// hyphen between words, and a name of no colour is an error.
+func LoadThemeFile(path string) (Theme, error) {
+	return LoadThemeFileFS(nil, path)
+}
+
+// LoadThemeFileFS is LoadThemeFile reading the file name of fsys, such as
+// an embed.FS of themes built into a program.
+func LoadThemeFileFS(fsys fs.FS, name string) (Theme, error) {
+	data, err := readFile(fsys, name)
+	if err != nil {
+		return Theme{}, err
+	}
+	var doc map[string]string
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	th, err := ThemeNamed(cmp.Or(doc["base"], "light"))
+	if err != nil {
+		return Theme{}, fmt.Errorf("%s: base: %w", name, err)
+	}
+	colors := th.colors()
+	for _, key := range slices.Sorted(maps.Keys(doc)) {
+		if key == "base" {
+			continue
+		}
+		i := slices.IndexFunc(colors, func(c themeColor) bool { return c.name == key })
+		if i < 0 {
+			return Theme{}, fmt.Errorf("%s: unknown colour %q", name, key)
+		}
+		col, err := ParseHexColor(doc[key])
+		if err != nil {
+			return Theme{}, fmt.Errorf("%s: %s: %w", name, key, err)
+		}
+		*colors[i].field = col
+	}
+	return th, nil
+}
+
+// themeColor is a colour of a Theme, by its name in theme files.
+type themeColor struct {
+	name  string
+	field *color.RGBA
+}
+
+// colors returns the colours of th by their names in theme files.
+func (th *Theme) colors() []themeColor {
+	return []themeColor{
+		{"background", &th.Background},
+		{"panel", &th.Panel},
+		{"panel-border", &th.PanelBorder},
+		{"legend-border", &th.LegendBorder},
+		{"title", &th.Title},
+		{"text", &th.Text},
+		{"muted-text", &th.MutedText},
+		{"edge", &th.Edge},
+		{"node-fill", &th.NodeFill},
+		{"node-border", &th.NodeBorder},
+		{"accent", &th.Accent},
+	}
+}
+
+// ParseHexColor reads an opaque colour written as #rrggbb or #rgb, with or
+// without the #.
+func ParseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("bad colour %q (want #rrggbb or #rgb)", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
@@ -0,0 +1,71 @@
+package interactions
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// WriteTiledGrid encodes the grid as a PNG without ever holding the whole
+// image in memory. The grid is drawn one band at a time, the title and
+// legend first and then each row of panels, as the encoder asks for rows,
+// so peak memory is one band however many scenarios there are.
+func WriteTiledGrid(w io.Writer, scenarios []Scenario, columns int, th Theme, opts ...Option) error {
+	o := collectOptions(opts)
+	if len(o.postProcess) > 0 {
+		return errors.New("WriteTiledGrid never holds the whole image, so cannot run WithPostProcess hooks on it")
+	}
+	return EncodePNG(w, &bandedGrid{layout: newGridLayout(scenarios, columns, o), theme: th})
+}
+
+// bandedGrid is an image.Image over the full grid that draws horizontal
+// bands on demand and keeps only the most recent one. The PNG encoder reads
+// rows in order, so each band is drawn once.
+type bandedGrid struct {
+	layout gridLayout
+	theme  Theme
+	band   *image.RGBA
+}
+
+func (b *bandedGrid) ColorModel() color.Model { return color.RGBAModel }
+
+func (b *bandedGrid) Bounds() image.Rectangle { return b.layout.bounds() }
+
+// Opaque reports that every pixel is opaque, as the background is, which
+// saves the encoder a full pass over the image to find out.
+func (b *bandedGrid) Opaque() bool { return true }
+
+func (b *bandedGrid) At(x, y int) color.Color {
+	if b.band == nil || !(image.Point{x, y}).In(b.band.Bounds()) {
+		r := b.bandAt(y)
+		if r.Empty() {
+			return color.RGBA{}
+		}
+		// each band is done with once the encoder has moved below it, so
+		// its pixels go to the next
+		ReleaseImage(b.band)
+		b.band = newCanvas(r)
+		b.layout.draw(b.band, b.theme)
+	}
+	return b.band.RGBAAt(x, y)
+}
+
+// bandAt returns the band containing row y: everything down to the bottom
+// of the legend, or one row of panels with the margin above it.
+func (b *bandedGrid) bandAt(y int) image.Rectangle {
+	g := b.layout
+	top := g.legendRect().Max.Y
+	if y < top {
+		return image.Rect(0, 0, g.width, top)
+	}
+	step := g.panelHeight + g.geo.margin
+	row := (y - top) / step
+	y0 := top + row*step
+	y1 := y0 + step
+	if row == g.rows-1 {
+		// the last band takes the bottom margin too
+		y1 = g.height
+	}
+	return image.Rect(0, y0, g.width, y1).Intersect(g.bounds())
+}
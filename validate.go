@@ -0,0 +1,224 @@
+package interactions
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a mistake in a scenario that would stop it drawing as
+// intended.
+type Problem struct {
+	// Path locates the offending element, e.g. "edges[2].to". ValidateFile
+	// prefixes it with the scenario, as in "scenarios[0].edges[2].to".
+	Path    string
+	Message string
+	// Line and Column give the element's position in the scenario file, or
+	// are zero when the scenario did not come from one.
+	Line, Column int
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Path, p.Message)
+	}
+	return p.Path + ": " + p.Message
+}
+
+// Validate reports edges referencing missing nodes, duplicate node names,
+// duplicate edges, probabilities outside 0 to 1, spans that are out of range or belong to no node,
+// sizes that are negative, larger than maxNodeSize or belong to no node,
+// and malformed codes.
+func Validate(s Scenario) []Problem {
+	var problems []Problem
+	report := func(path, format string, args ...any) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Code != "" {
+		if _, err := ParseCode(s.Code); err != nil {
+			report("code", "%v", err)
+		}
+	}
+
+	nodes := map[string]bool{}
+	for i, n := range s.Nodes {
+		path := fmt.Sprintf("nodes[%d]", i)
+		switch {
+		case n == "":
+			report(path, "empty node name")
+		case nodes[n]:
+			report(path, "duplicate node %q", n)
+		}
+		nodes[n] = true
+	}
+
+	seen := map[edgeKey]int{}
+	for i, e := range s.Edges {
+		path := fmt.Sprintf("edges[%d]", i)
+		if !nodes[e.From] {
+			report(path+".from", "unknown node %q", e.From)
+		}
+		if !nodes[e.To] {
+			report(path+".to", "unknown node %q", e.To)
+		}
+		if e.Weight < 0 || math.IsNaN(e.Weight) || math.IsInf(e.Weight, 0) {
+			report(path+".weight", "weight %g is not a finite number of at least 0", e.Weight)
+		}
+		if e.Probability < 0 || e.Probability > 1 {
+			report(path+".probability", "probability %g is outside 0 to 1", e.Probability)
+		}
+		k := keyOf(e)
+		if j, ok := seen[k]; ok {
+			report(path, "duplicates edges[%d] (%s to %s)", j, e.From, e.To)
+			continue
+		}
+		seen[k] = i
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Spans)) {
+		span := s.Spans[name]
+		path := "spans." + name
+		if !nodes[name] {
+			report(path, "span for unknown node %q", name)
+		}
+		if span.Start < 0 || span.End > 1 {
+			report(path, "span %g to %g is outside the time axis 0 to 1", span.Start, span.End)
+		}
+		if span.Start >= span.End {
+			report(path, "span starts at %g but ends at %g", span.Start, span.End)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Sizes)) {
+		size := s.Sizes[name]
+		path := "sizes." + name
+		if !nodes[name] {
+			report(path, "size for unknown node %q", name)
+		}
+		if size.Radius < 0 || size.Width < 0 {
+			report(path, "size has a negative radius or width")
+		}
+		if size.Radius > maxNodeSize || size.Width > maxNodeSize {
+			report(path, "size has a radius or width over the limit of %d", maxNodeSize)
+		}
+	}
+	return problems
+}
+
+// maxNodeSize is the largest radius or width of a node Validate accepts, ten
+// times the usual radius, since panels grow to fit their largest node.
+const maxNodeSize = 200
+
+// edgeKey identifies edges that would be drawn over each other. Edges with a
+// head at both ends are the same whichever way round they are written.
+type edgeKey struct {
+	from, to string
+	kind     EdgeKind
+}
+
+func keyOf(e Edge) edgeKey {
+	if _, _, tail := e.heads(); tail && e.Kind != Predation && e.To < e.From {
+		return edgeKey{e.To, e.From, e.Kind}
+	}
+	return edgeKey{e.From, e.To, e.Kind}
+}
+
+// ValidateFile validates every scenario in a scenario file, and its named
+// sets, and checks no two of the scenarios or of a set share a code,
+// giving each problem the line and column of the element it concerns. A
+// file that does not match ScenarioSchema has only the mismatches as
+// problems. The error is for files that cannot be read or parsed at all.
+func ValidateFile(path string) ([]Problem, error) {
+	return ValidateFileFS(nil, path)
+}
+
+// ValidateFileFS is ValidateFile reading the file name of fsys.
+func ValidateFileFS(fsys fs.FS, name string) ([]Problem, error) {
+	doc, root, err := readScenarioFile(fsys, name, nil)
+	if se, ok := err.(*SchemaError); ok {
+		return se.Problems, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	validate := func(scenarios []Scenario, path string) {
+		codes := map[string]int{}
+		for i, s := range scenarios {
+			found := Validate(s)
+			if c, err := ParseCode(s.Code); err == nil {
+				if j, ok := codes[c.String()]; ok {
+					found = append(found, Problem{Path: "code", Message: fmt.Sprintf("code %s is also used by %s[%d]", c, path, j)})
+				} else {
+					codes[c.String()] = i
+				}
+			}
+			for _, p := range found {
+				p.Path = fmt.Sprintf("%s[%d].%s", path, i, p.Path)
+				if n := yamlNodeAt(root, p.Path); n != nil {
+					p.Line, p.Column = n.Line, n.Column
+				}
+				problems = append(problems, p)
+			}
+		}
+	}
+	validate(doc.Scenarios, "scenarios")
+	for _, name := range slices.Sorted(maps.Keys(doc.Sets)) {
+		validate(doc.Sets[name], "sets."+name)
+	}
+	return problems, nil
+}
+
+// yamlNodeAt follows a problem path such as "scenarios[0].edges[2].to"
+// through a YAML document, stopping at the deepest element that exists.
+// Nodes filled in from the edges have no element of their own, for example,
+// so their problems are placed at the scenario.
+func yamlNodeAt(root *yaml.Node, path string) *yaml.Node {
+	n := root
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	for _, part := range strings.Split(path, ".") {
+		key, index := part, -1
+		if open := strings.IndexByte(part, '['); open >= 0 && strings.HasSuffix(part, "]") {
+			key = part[:open]
+			if i, err := strconv.Atoi(part[open+1 : len(part)-1]); err == nil {
+				index = i
+			}
+		}
+
+		next := mappingValue(n, key)
+		if next == nil {
+			return n
+		}
+		if index >= 0 {
+			if next.Kind != yaml.SequenceNode || index >= len(next.Content) {
+				return next
+			}
+			next = next.Content[index]
+		}
+		n = next
+	}
+	return n
+}
+
+// mappingValue returns the value of key in a YAML mapping, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}